@@ -14,6 +14,11 @@ import (
 
 const idsOnCachePage = 1000
 
+type cachedSearchResult struct {
+	ids   []uint64
+	total int
+}
+
 func cachedSearch(engine *Engine, entities interface{}, indexName string, pager *Pager,
 	arguments []interface{}, references []string) (totalRows int, ids []uint64) {
 	value := reflect.ValueOf(entities)
@@ -103,7 +108,19 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 
 	if hasNil {
 		searchPager := NewPager(minPage, maxPage*idsOnCachePage)
-		results, total := searchIDsWithCount(true, engine, Where, searchPager, entityType)
+		flightKey := fmt.Sprintf("search:%s:%d:%d", cacheKey, minPage, maxPage)
+		searchIDs := func() interface{} {
+			results, total := searchIDsWithCount(true, engine, Where, searchPager, entityType)
+			return cachedSearchResult{ids: results, total: total}
+		}
+		var found interface{}
+		if schema.GetMysql(engine).inTransaction {
+			found = searchIDs()
+		} else {
+			found, _ = engine.registry.singleFlight.Do(flightKey, searchIDs)
+		}
+		results := found.(cachedSearchResult).ids
+		total := found.(cachedSearchResult).total
 		totalRows = total
 		cacheFields := make(map[string]interface{})
 		for key, ids := range fromCache {
@@ -129,7 +146,7 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 			}
 		}
 		if hasRedis {
-			redisCache.HMset(cacheKey, cacheFields)
+			redisCache.hmset(cacheKey, cacheFields, schema.redisCacheTTL)
 		}
 	}
 
@@ -143,7 +160,7 @@ func cachedSearch(engine *Engine, entities interface{}, indexName string, pager
 			cacheValue = strings.Trim(cacheValue, "[]")
 			fields[v] = cacheValue
 		}
-		localCache.HMset(cacheKey, fields)
+		localCache.hmset(cacheKey, fields, schema.localCacheTTL)
 	}
 
 	resultsIDs := make([]uint64, 0, len(filledPages)*idsOnCachePage)
@@ -208,10 +225,10 @@ func cachedSearchOne(engine *Engine, entity Entity, indexName string, arguments
 		}
 		fields := map[string]interface{}{"1": value}
 		if hasLocalCache {
-			localCache.HMset(cacheKey, fields)
+			localCache.hmset(cacheKey, fields, schema.localCacheTTL)
 		}
 		if hasRedis {
-			redisCache.HMset(cacheKey, fields)
+			redisCache.hmset(cacheKey, fields, schema.redisCacheTTL)
 		}
 	} else {
 		ids := strings.Split(fromCache["1"].(string), " ")
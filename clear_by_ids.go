@@ -9,6 +9,7 @@ func clearByIDs(engine *Engine, entity Entity, ids ...uint64) {
 	localCache, has := schema.GetLocalCache(engine)
 	if has {
 		localCache.Remove(cacheKeys...)
+		addLocalCacheInvalidation(engine, schema.GetMysql(engine).inTransaction, map[string][]string{localCache.code: cacheKeys})
 	}
 	redisCache, has := schema.GetRedisCache(engine)
 	if has {
@@ -0,0 +1,328 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/juju/errors"
+)
+
+type ClickHouseEntity interface {
+	getClickHouseORM() *ClickHouseORM
+}
+
+type ClickHouseORM struct{}
+
+func (orm *ClickHouseORM) getClickHouseORM() *ClickHouseORM {
+	return orm
+}
+
+type ClickHouseTableSchema interface {
+	GetTableName() string
+	GetType() reflect.Type
+	GetColumns() []string
+	GetCreateTableSQL() string
+	CreateTable(engine *Engine)
+	DropTable(engine *Engine)
+	TruncateTable(engine *Engine)
+	GetClickHouse(engine *Engine) *ClickHouse
+	GetSchemaChanges(engine *Engine) (has bool, alters []Alter)
+	UpdateSchema(engine *Engine)
+}
+
+type clickHouseColumn struct {
+	name       string
+	columnType string
+	index      int
+}
+
+type clickHouseTableSchema struct {
+	tableName   string
+	poolName    string
+	t           reflect.Type
+	tags        map[string]map[string]string
+	columns     []*clickHouseColumn
+	columnNames []string
+	engine      string
+	partition   string
+	order       []string
+	ttl         string
+}
+
+func getClickHouseTableSchema(registry *validatedRegistry, entityType reflect.Type) *clickHouseTableSchema {
+	return registry.clickHouseTableSchemas[entityType]
+}
+
+func (tableSchema *clickHouseTableSchema) GetTableName() string {
+	return tableSchema.tableName
+}
+
+func (tableSchema *clickHouseTableSchema) GetType() reflect.Type {
+	return tableSchema.t
+}
+
+func (tableSchema *clickHouseTableSchema) GetColumns() []string {
+	return tableSchema.columnNames
+}
+
+func (tableSchema *clickHouseTableSchema) GetClickHouse(engine *Engine) *ClickHouse {
+	return engine.GetClickHouse(tableSchema.poolName)
+}
+
+func (tableSchema *clickHouseTableSchema) CreateTable(engine *Engine) {
+	tableSchema.GetClickHouse(engine).Exec(tableSchema.GetCreateTableSQL())
+}
+
+func (tableSchema *clickHouseTableSchema) DropTable(engine *Engine) {
+	tableSchema.GetClickHouse(engine).Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteClickHouseTable(tableSchema.tableName)))
+}
+
+func (tableSchema *clickHouseTableSchema) TruncateTable(engine *Engine) {
+	tableSchema.GetClickHouse(engine).Exec(fmt.Sprintf("TRUNCATE TABLE IF EXISTS %s", quoteClickHouseTable(tableSchema.tableName)))
+}
+
+func (tableSchema *clickHouseTableSchema) GetCreateTableSQL() string {
+	return tableSchema.getCreateTableSQL(tableSchema.tableName)
+}
+
+func (tableSchema *clickHouseTableSchema) getCreateTableSQL(table string) string {
+	columns := make([]string, len(tableSchema.columns))
+	for i, column := range tableSchema.columns {
+		columns[i] = fmt.Sprintf("`%s` %s", column.name, column.columnType)
+	}
+	sql := fmt.Sprintf("CREATE TABLE %s (%s) ENGINE = %s", quoteClickHouseTable(table),
+		strings.Join(columns, ", "), tableSchema.engine)
+	if tableSchema.partition != "" {
+		sql += " PARTITION BY " + tableSchema.partition
+	}
+	sql += " ORDER BY " + tableSchema.getOrderBy()
+	if tableSchema.ttl != "" {
+		sql += " TTL " + tableSchema.ttl
+	}
+	return sql
+}
+
+func (tableSchema *clickHouseTableSchema) getOrderBy() string {
+	if len(tableSchema.order) == 0 {
+		return "tuple()"
+	}
+	order := make([]string, len(tableSchema.order))
+	for i, field := range tableSchema.order {
+		order[i] = "`" + field + "`"
+	}
+	if len(order) == 1 {
+		return order[0]
+	}
+	return "(" + strings.Join(order, ", ") + ")"
+}
+
+func initClickHouseTableSchema(registry *Registry, entityType reflect.Type) (*clickHouseTableSchema, error) {
+	if entityType.NumField() == 0 || entityType.Field(0).Type != reflect.TypeOf(ClickHouseORM{}) {
+		return nil, errors.Errorf("missing orm.ClickHouseORM as first field in %s", entityType.String())
+	}
+	tags := extractTags(registry, entityType, "")
+	pool, has := tags["ClickHouseORM"]["clickhouse"]
+	if !has {
+		pool = "default"
+	}
+	_, has = registry.clickHouseClients[pool]
+	if !has {
+		return nil, errors.NotFoundf("clickhouse pool '%s'", pool)
+	}
+	table, has := tags["ClickHouseORM"]["table"]
+	if !has {
+		table = entityType.Name()
+	}
+	tableEngine, has := tags["ClickHouseORM"]["engine"]
+	if !has {
+		tableEngine = "MergeTree()"
+	}
+	columns := make([]*clickHouseColumn, 0)
+	columnNames := make([]string, 0)
+	for i := 1; i < entityType.NumField(); i++ {
+		field := entityType.Field(i)
+		_, has := tags[field.Name]["ignore"]
+		if has {
+			continue
+		}
+		columnType, has := tags[field.Name]["type"]
+		if !has {
+			columnType, has = getClickHouseColumnType(field.Type)
+			if !has {
+				return nil, errors.NotSupportedf("type %s in field %s of %s", field.Type.String(), field.Name, entityType.String())
+			}
+		}
+		columns = append(columns, &clickHouseColumn{name: field.Name, columnType: columnType, index: i})
+		columnNames = append(columnNames, field.Name)
+	}
+	if len(columns) == 0 {
+		return nil, errors.Errorf("missing columns in %s", entityType.String())
+	}
+	order := make([]string, 0)
+	orderTag, has := tags["ClickHouseORM"]["order"]
+	if has {
+		for _, field := range strings.Split(orderTag, ",") {
+			field = strings.TrimSpace(field)
+			valid := false
+			for _, column := range columnNames {
+				if column == field {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, errors.Errorf("unknown order field '%s' in %s", field, entityType.String())
+			}
+			order = append(order, field)
+		}
+	}
+	return &clickHouseTableSchema{tableName: table,
+		poolName:    pool,
+		t:           entityType,
+		tags:        tags,
+		columns:     columns,
+		columnNames: columnNames,
+		engine:      tableEngine,
+		partition:   tags["ClickHouseORM"]["partition"],
+		order:       order,
+		ttl:         tags["ClickHouseORM"]["ttl"]}, nil
+}
+
+func getClickHouseColumnType(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Ptr {
+		columnType, has := getClickHouseColumnType(t.Elem())
+		if !has || t.Elem().Kind() == reflect.Slice {
+			return "", false
+		}
+		return "Nullable(" + columnType + ")", true
+	}
+	switch t.String() {
+	case "uint8":
+		return "UInt8", true
+	case "uint16":
+		return "UInt16", true
+	case "uint32":
+		return "UInt32", true
+	case "uint", "uint64":
+		return "UInt64", true
+	case "int8":
+		return "Int8", true
+	case "int16":
+		return "Int16", true
+	case "int32":
+		return "Int32", true
+	case "int", "int64":
+		return "Int64", true
+	case "float32":
+		return "Float32", true
+	case "float64":
+		return "Float64", true
+	case "string":
+		return "String", true
+	case "bool":
+		return "UInt8", true
+	case "time.Time":
+		return "DateTime", true
+	case "[]string":
+		return "Array(String)", true
+	}
+	return "", false
+}
+
+func quoteClickHouseTable(table string) string {
+	parts := strings.Split(table, ".")
+	for i, part := range parts {
+		parts[i] = "`" + part + "`"
+	}
+	return strings.Join(parts, ".")
+}
+
+func clickHouseInsert(engine *Engine, entities ...ClickHouseEntity) {
+	grouped := make(map[reflect.Type][]reflect.Value)
+	types := make([]reflect.Type, 0)
+	for _, entity := range entities {
+		value := reflect.ValueOf(entity).Elem()
+		t := value.Type()
+		if grouped[t] == nil {
+			types = append(types, t)
+		}
+		grouped[t] = append(grouped[t], value)
+	}
+	for _, t := range types {
+		schema := getClickHouseTableSchema(engine.registry, t)
+		if schema == nil {
+			panic(fmt.Errorf("clickhouse entity '%s' is not registered", t.String()))
+		}
+		pool := schema.GetClickHouse(engine)
+		inTransaction := pool.tx != nil
+		if !inTransaction {
+			pool.Begin()
+		}
+		func() {
+			committed := false
+			if !inTransaction {
+				defer func() {
+					if !committed {
+						pool.Rollback()
+					}
+				}()
+			}
+			/* #nosec */
+			query := fmt.Sprintf("INSERT INTO %s (`%s`) VALUES (%s)", quoteClickHouseTable(schema.tableName),
+				strings.Join(schema.columnNames, "`, `"), strings.TrimRight(strings.Repeat("?,", len(schema.columns)), ","))
+			statement, def := pool.Prepare(query)
+			defer def()
+			args := make([]interface{}, len(schema.columns))
+			for _, value := range grouped[t] {
+				for i, column := range schema.columns {
+					args[i] = value.Field(column.index).Interface()
+				}
+				statement.Exec(args...)
+			}
+			if !inTransaction {
+				pool.Commit()
+				committed = true
+			}
+		}()
+	}
+}
+
+func clickHouseSearch(engine *Engine, where *Where, entities interface{}) {
+	value := reflect.ValueOf(entities)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Slice {
+		panic(fmt.Errorf("entities must be pointer to slice"))
+	}
+	slice := value.Elem()
+	slice.SetLen(0)
+	entityType := slice.Type().Elem()
+	isPointer := entityType.Kind() == reflect.Ptr
+	if isPointer {
+		entityType = entityType.Elem()
+	}
+	schema := getClickHouseTableSchema(engine.registry, entityType)
+	if schema == nil {
+		panic(fmt.Errorf("clickhouse entity '%s' is not registered", entityType.String()))
+	}
+	/* #nosec */
+	query := fmt.Sprintf("SELECT `%s` FROM %s WHERE %s", strings.Join(schema.columnNames, "`, `"),
+		quoteClickHouseTable(schema.tableName), where.String())
+	rows, def := schema.GetClickHouse(engine).Queryx(query, where.GetParameters()...)
+	defer def()
+	pointers := make([]interface{}, len(schema.columns))
+	for rows.Next() {
+		entity := reflect.New(entityType)
+		elem := entity.Elem()
+		for i, column := range schema.columns {
+			pointers[i] = elem.Field(column.index).Addr().Interface()
+		}
+		checkError(rows.Scan(pointers...))
+		if isPointer {
+			slice = reflect.Append(slice, entity)
+		} else {
+			slice = reflect.Append(slice, elem)
+		}
+	}
+	checkError(rows.Err())
+	value.Elem().Set(slice)
+}
@@ -0,0 +1,89 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clickHouseEntityHit struct {
+	ClickHouseORM `orm:"table=test.entity_hits;partition=toYYYYMM(EventDate);order=WatchID,EventDate"`
+	WatchID       uint64
+	UserID        uint32
+	EventDate     time.Time `orm:"type=Date"`
+	URL           string
+	Score         *float64
+	Tags          []string
+	Active        bool
+	Ignored       string `orm:"ignore"`
+}
+
+type clickHouseEntityInvalid struct {
+	ClickHouseORM `orm:"order=Missing"`
+	Name          string
+}
+
+func TestClickHouseEntity(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterClickHouse("http://localhost:9002?debug=false")
+	registry.RegisterClickHouseEntity(&clickHouseEntityHit{})
+	engine := PrepareTables(t, registry)
+
+	schema := engine.GetRegistry().GetClickHouseTableSchema("orm.clickHouseEntityHit")
+	assert.NotNil(t, schema)
+	assert.Nil(t, engine.GetRegistry().GetClickHouseTableSchema("orm.invalid"))
+	assert.Len(t, engine.GetRegistry().GetClickHouseEntities(), 1)
+	assert.Equal(t, "test.entity_hits", schema.GetTableName())
+	assert.Equal(t, []string{"WatchID", "UserID", "EventDate", "URL", "Score", "Tags", "Active"}, schema.GetColumns())
+	assert.Equal(t, "CREATE TABLE `test`.`entity_hits` (`WatchID` UInt64, `UserID` UInt32, `EventDate` Date, `URL` String, "+
+		"`Score` Nullable(Float64), `Tags` Array(String), `Active` UInt8) ENGINE = MergeTree() PARTITION BY toYYYYMM(EventDate) "+
+		"ORDER BY (`WatchID`, `EventDate`)", schema.GetCreateTableSQL())
+
+	engine.GetClickHouse().Exec("CREATE DATABASE IF NOT EXISTS test")
+	schema.DropTable(engine)
+	schema.CreateTable(engine)
+
+	score := 12.5
+	date := time.Date(2020, 11, 20, 0, 0, 0, 0, time.UTC)
+	engine.ClickHouseInsert(&clickHouseEntityHit{WatchID: 1, UserID: 10, EventDate: date, URL: "/a", Score: &score, Tags: []string{"a", "b"}, Active: true},
+		&clickHouseEntityHit{WatchID: 2, UserID: 20, EventDate: date, URL: "/b", Tags: []string{}})
+
+	var rows []*clickHouseEntityHit
+	engine.ClickHouseSearch(NewWhere("UserID IN ? ORDER BY WatchID", []uint32{10, 20}), &rows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, uint64(1), rows[0].WatchID)
+	assert.Equal(t, "/a", rows[0].URL)
+	assert.Equal(t, 12.5, *rows[0].Score)
+	assert.Equal(t, []string{"a", "b"}, rows[0].Tags)
+	assert.True(t, rows[0].Active)
+	assert.Equal(t, date, rows[0].EventDate.UTC())
+	assert.Nil(t, rows[1].Score)
+	assert.False(t, rows[1].Active)
+
+	engine.GetClickHouse().Begin()
+	engine.ClickHouseInsert(&clickHouseEntityHit{WatchID: 3, UserID: 30, EventDate: date})
+	engine.GetClickHouse().Commit()
+	var values []clickHouseEntityHit
+	engine.ClickHouseSearch(NewWhere("1"), &values)
+	assert.Len(t, values, 3)
+
+	schema.TruncateTable(engine)
+	engine.ClickHouseSearch(NewWhere("1"), &values)
+	assert.Len(t, values, 0)
+
+	assert.Panics(t, func() {
+		engine.ClickHouseSearch(NewWhere("1"), values)
+	})
+
+	registry = &Registry{}
+	registry.RegisterClickHouseEntity(&clickHouseEntityHit{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "clickhouse pool 'default' not found")
+
+	registry = &Registry{}
+	registry.RegisterClickHouse("http://localhost:9002?debug=false")
+	registry.RegisterClickHouseEntity(&clickHouseEntityInvalid{})
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "unknown order field 'Missing' in orm.clickHouseEntityInvalid")
+}
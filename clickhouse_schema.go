@@ -0,0 +1,208 @@
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type clickHouseTableDB struct {
+	engine string
+	ttl    string
+}
+
+type clickHouseColumnDB struct {
+	name       string
+	columnType string
+}
+
+func getClickHouseAlters(engine *Engine) (alters []Alter) {
+	alters = make([]Alter, 0)
+	names := make([]string, 0, len(engine.registry.clickHouseEntities))
+	for name := range engine.registry.clickHouseEntities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tableSchema := getClickHouseTableSchema(engine.registry, engine.registry.clickHouseEntities[name])
+		_, newAlters := tableSchema.GetSchemaChanges(engine)
+		alters = append(alters, newAlters...)
+	}
+	return alters
+}
+
+func (tableSchema *clickHouseTableSchema) UpdateSchema(engine *Engine) {
+	pool := tableSchema.GetClickHouse(engine)
+	_, alters := tableSchema.GetSchemaChanges(engine)
+	for _, alter := range alters {
+		pool.Exec(alter.SQL)
+	}
+}
+
+func (tableSchema *clickHouseTableSchema) GetSchemaChanges(engine *Engine) (has bool, alters []Alter) {
+	pool := tableSchema.GetClickHouse(engine)
+	table := quoteClickHouseTable(tableSchema.tableName)
+	alters = make([]Alter, 0)
+	tableDB, columnsDB := tableSchema.getDefinitionFromDB(pool)
+	if tableDB == nil {
+		alters = append(alters, Alter{SQL: tableSchema.GetCreateTableSQL(), Safe: true, Pool: tableSchema.poolName,
+			table: tableSchema.tableName, changes: []SchemaChange{{Kind: "table", Action: "created"}}})
+		return true, alters
+	}
+	isEmpty := isClickHouseTableEmpty(pool, table)
+
+	if normalizeClickHouseEngine(tableSchema.getEngineFull()) != normalizeClickHouseEngine(tableDB.engine) {
+		rebuild := []SchemaChange{{Kind: "engine", Action: "changed", Details: "rebuild from " + tableDB.engine}}
+		common := make([]string, 0)
+		for _, column := range tableSchema.columns {
+			for _, columnDB := range columnsDB {
+				if columnDB.name == column.name {
+					common = append(common, column.name)
+					break
+				}
+			}
+		}
+		rebuildTable := quoteClickHouseTable(tableSchema.tableName + "_rebuild")
+		oldTable := quoteClickHouseTable(tableSchema.tableName + "_old")
+		alters = append(alters, Alter{SQL: tableSchema.getCreateTableSQL(tableSchema.tableName + "_rebuild"), Safe: isEmpty, Pool: tableSchema.poolName,
+			table: tableSchema.tableName, changes: rebuild})
+		if len(common) > 0 {
+			columns := "`" + strings.Join(common, "`, `") + "`"
+			alters = append(alters, Alter{SQL: fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", rebuildTable, columns, columns, table),
+				Safe: isEmpty, Pool: tableSchema.poolName, table: tableSchema.tableName, changes: rebuild})
+		}
+		alters = append(alters, Alter{SQL: fmt.Sprintf("RENAME TABLE %s TO %s, %s TO %s", table, oldTable, rebuildTable, table),
+			Safe: isEmpty, Pool: tableSchema.poolName, table: tableSchema.tableName, changes: rebuild})
+		alters = append(alters, Alter{SQL: fmt.Sprintf("DROP TABLE %s", oldTable), Safe: isEmpty, Pool: tableSchema.poolName,
+			table: tableSchema.tableName, changes: rebuild})
+		return true, alters
+	}
+
+	for i, column := range tableSchema.columns {
+		var columnDB *clickHouseColumnDB
+		for _, value := range columnsDB {
+			if value.name == column.name {
+				columnDB = value
+				break
+			}
+		}
+		if columnDB == nil {
+			position := "FIRST"
+			if i > 0 {
+				position = fmt.Sprintf("AFTER `%s`", tableSchema.columns[i-1].name)
+			}
+			alters = append(alters, Alter{SQL: fmt.Sprintf("ALTER TABLE %s ADD COLUMN `%s` %s %s", table, column.name, column.columnType, position),
+				Safe: true, Pool: tableSchema.poolName, table: tableSchema.tableName,
+				changes: []SchemaChange{{Kind: "column", Action: "added", Name: column.name, Details: column.columnType + " " + position}}})
+		} else if columnDB.columnType != column.columnType {
+			alters = append(alters, Alter{SQL: fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN `%s` %s", table, column.name, column.columnType),
+				Safe: isEmpty, Pool: tableSchema.poolName, table: tableSchema.tableName,
+				changes: []SchemaChange{{Kind: "column", Action: "changed", Name: column.name, Details: "definition was " + columnDB.columnType}}})
+		}
+	}
+	for _, columnDB := range columnsDB {
+		found := false
+		for _, column := range tableSchema.columns {
+			if column.name == columnDB.name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			alters = append(alters, Alter{SQL: fmt.Sprintf("ALTER TABLE %s DROP COLUMN `%s`", table, columnDB.name),
+				Safe: isEmpty, Pool: tableSchema.poolName, table: tableSchema.tableName,
+				changes: []SchemaChange{{Kind: "column", Action: "dropped", Name: columnDB.name}}})
+		}
+	}
+	if normalizeClickHouseExpression(tableSchema.ttl) != normalizeClickHouseExpression(tableDB.ttl) {
+		if tableSchema.ttl == "" {
+			alters = append(alters, Alter{SQL: fmt.Sprintf("ALTER TABLE %s REMOVE TTL", table), Safe: true, Pool: tableSchema.poolName,
+				table: tableSchema.tableName, changes: []SchemaChange{{Kind: "ttl", Action: "dropped"}}})
+		} else {
+			alters = append(alters, Alter{SQL: fmt.Sprintf("ALTER TABLE %s MODIFY TTL %s", table, tableSchema.ttl), Safe: isEmpty, Pool: tableSchema.poolName,
+				table: tableSchema.tableName, changes: []SchemaChange{{Kind: "ttl", Action: "changed", Details: tableSchema.ttl}}})
+		}
+	}
+	return len(alters) > 0, alters
+}
+
+func (tableSchema *clickHouseTableSchema) getDefinitionFromDB(pool *ClickHouse) (*clickHouseTableDB, []*clickHouseColumnDB) {
+	table := tableSchema.tableName
+	databaseCondition := "currentDatabase()"
+	args := make([]interface{}, 0)
+	parts := strings.SplitN(table, ".", 2)
+	if len(parts) == 2 {
+		databaseCondition = "?"
+		args = append(args, parts[0])
+		table = parts[1]
+	}
+	args = append(args, table)
+
+	/* #nosec */
+	query := fmt.Sprintf("SELECT engine_full FROM system.tables WHERE database = %s AND name = ?", databaseCondition)
+	rows, def := pool.Queryx(query, args...)
+	defer def()
+	if !rows.Next() {
+		return nil, nil
+	}
+	var engineFull string
+	checkError(rows.Scan(&engineFull))
+	def()
+	tableDB := parseClickHouseEngineFull(engineFull)
+
+	/* #nosec */
+	query = fmt.Sprintf("SELECT name, type FROM system.columns WHERE database = %s AND table = ? ORDER BY position", databaseCondition)
+	rows, def = pool.Queryx(query, args...)
+	defer def()
+	columns := make([]*clickHouseColumnDB, 0)
+	for rows.Next() {
+		column := &clickHouseColumnDB{}
+		checkError(rows.Scan(&column.name, &column.columnType))
+		columns = append(columns, column)
+	}
+	return tableDB, columns
+}
+
+var clickHouseEngineFullRegexp = regexp.MustCompile(`^(.+?)(?: TTL (.+?))?(?: SETTINGS .+)?$`)
+
+func parseClickHouseEngineFull(engineFull string) *clickHouseTableDB {
+	parts := clickHouseEngineFullRegexp.FindStringSubmatch(engineFull)
+	return &clickHouseTableDB{engine: parts[1], ttl: parts[2]}
+}
+
+func (tableSchema *clickHouseTableSchema) getEngineFull() string {
+	engine := tableSchema.engine
+	if tableSchema.partition != "" {
+		engine += " PARTITION BY " + tableSchema.partition
+	}
+	return engine + " ORDER BY " + tableSchema.getOrderBy()
+}
+
+var clickHouseEmptyEngineParametersRegexp = regexp.MustCompile(`^(\w+)\(\)`)
+
+func normalizeClickHouseEngine(engine string) string {
+	return normalizeClickHouseExpression(clickHouseEmptyEngineParametersRegexp.ReplaceAllString(engine, "$1"))
+}
+
+func isClickHouseTableEmpty(pool *ClickHouse, table string) bool {
+	/* #nosec */
+	rows, def := pool.Queryx(fmt.Sprintf("SELECT count() FROM %s", table))
+	defer def()
+	total := uint64(0)
+	if rows.Next() {
+		checkError(rows.Scan(&total))
+	}
+	return total == 0
+}
+
+var clickHouseIntervalRegexp = regexp.MustCompile(`(?i)INTERVAL\s+(\d+)\s+(SECOND|MINUTE|HOUR|DAY|WEEK|MONTH|QUARTER|YEAR)`)
+
+func normalizeClickHouseExpression(expression string) string {
+	expression = clickHouseIntervalRegexp.ReplaceAllStringFunc(expression, func(match string) string {
+		parts := clickHouseIntervalRegexp.FindStringSubmatch(match)
+		unit := strings.ToLower(parts[2])
+		return "toInterval" + strings.ToUpper(unit[0:1]) + unit[1:] + "(" + parts[1] + ")"
+	})
+	return strings.NewReplacer(" ", "", "`", "").Replace(expression)
+}
@@ -0,0 +1,118 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type clickHouseSchemaEntity struct {
+	ClickHouseORM `orm:"table=test.schema_hits;order=WatchID"`
+	WatchID       uint64
+	EventDate     time.Time `orm:"type=Date"`
+}
+
+type clickHouseSchemaEntityV2 struct {
+	ClickHouseORM `orm:"table=test.schema_hits;order=WatchID;ttl=EventDate + INTERVAL 1 MONTH"`
+	UserID        uint32
+	WatchID       uint64
+	EventDate     time.Time `orm:"type=Date"`
+	Name          string
+}
+
+type clickHouseSchemaEntityV3 struct {
+	ClickHouseORM `orm:"table=test.schema_hits;order=UserID,WatchID;ttl=EventDate + INTERVAL 1 MONTH"`
+	UserID        uint64
+	WatchID       uint64
+	EventDate     time.Time `orm:"type=Date"`
+}
+
+func prepareClickHouseSchema(t *testing.T, entity ClickHouseEntity) *Engine {
+	registry := &Registry{}
+	registry.RegisterClickHouse("http://localhost:9002?debug=false")
+	registry.RegisterClickHouseEntity(entity)
+	validatedRegistry, err := registry.Validate()
+	assert.NoError(t, err)
+	return validatedRegistry.CreateEngine()
+}
+
+func TestClickHouseSchema(t *testing.T) {
+	engine := prepareClickHouseSchema(t, &clickHouseSchemaEntity{})
+	engine.GetClickHouse().Exec("CREATE DATABASE IF NOT EXISTS test")
+	engine.GetClickHouse().Exec("DROP TABLE IF EXISTS test.schema_hits")
+
+	alters := engine.GetClickHouseAlters()
+	assert.Len(t, alters, 1)
+	assert.True(t, alters[0].Safe)
+	assert.Equal(t, "default", alters[0].Pool)
+	assert.Equal(t, "CREATE TABLE `test`.`schema_hits` (`WatchID` UInt64, `EventDate` Date) ENGINE = MergeTree() ORDER BY `WatchID`", alters[0].SQL)
+	engine.GetClickHouse().Exec(alters[0].SQL)
+	assert.Len(t, engine.GetClickHouseAlters(), 0)
+	engine.ClickHouseInsert(&clickHouseSchemaEntity{WatchID: 1, EventDate: time.Now()})
+
+	engine = prepareClickHouseSchema(t, &clickHouseSchemaEntityV2{})
+	alters = engine.GetClickHouseAlters()
+	assert.Len(t, alters, 3)
+	assert.Equal(t, "ALTER TABLE `test`.`schema_hits` ADD COLUMN `UserID` UInt32 FIRST", alters[0].SQL)
+	assert.True(t, alters[0].Safe)
+	assert.Equal(t, "ALTER TABLE `test`.`schema_hits` ADD COLUMN `Name` String AFTER `EventDate`", alters[1].SQL)
+	assert.True(t, alters[1].Safe)
+	assert.Equal(t, "ALTER TABLE `test`.`schema_hits` MODIFY TTL EventDate + INTERVAL 1 MONTH", alters[2].SQL)
+	assert.False(t, alters[2].Safe)
+	schema := engine.GetRegistry().GetClickHouseTableSchema("orm.clickHouseSchemaEntityV2")
+	schema.UpdateSchema(engine)
+	has, _ := schema.GetSchemaChanges(engine)
+	assert.False(t, has)
+
+	engine = prepareClickHouseSchema(t, &clickHouseSchemaEntityV3{})
+	alters = engine.GetClickHouseAlters()
+	assert.Len(t, alters, 4)
+	assert.Equal(t, "CREATE TABLE `test`.`schema_hits_rebuild` (`UserID` UInt64, `WatchID` UInt64, `EventDate` Date) "+
+		"ENGINE = MergeTree() ORDER BY (`UserID`, `WatchID`) TTL EventDate + INTERVAL 1 MONTH", alters[0].SQL)
+	assert.Equal(t, "INSERT INTO `test`.`schema_hits_rebuild` (`UserID`, `WatchID`, `EventDate`) SELECT `UserID`, `WatchID`, `EventDate` FROM `test`.`schema_hits`", alters[1].SQL)
+	assert.Equal(t, "RENAME TABLE `test`.`schema_hits` TO `test`.`schema_hits_old`, `test`.`schema_hits_rebuild` TO `test`.`schema_hits`", alters[2].SQL)
+	assert.Equal(t, "DROP TABLE `test`.`schema_hits_old`", alters[3].SQL)
+	for _, alter := range alters {
+		assert.False(t, alter.Safe)
+		engine.GetClickHouse().Exec(alter.SQL)
+	}
+	assert.Len(t, engine.GetClickHouseAlters(), 0)
+	var rows []*clickHouseSchemaEntityV3
+	engine.ClickHouseSearch(NewWhere("1"), &rows)
+	assert.Len(t, rows, 1)
+
+	engine = prepareClickHouseSchema(t, &clickHouseSchemaEntity{})
+	alters = engine.GetClickHouseAlters()
+	assert.Len(t, alters, 4)
+	assert.Equal(t, "CREATE TABLE `test`.`schema_hits_rebuild` (`WatchID` UInt64, `EventDate` Date) ENGINE = MergeTree() ORDER BY `WatchID`", alters[0].SQL)
+
+	engine = prepareClickHouseSchema(t, &clickHouseSchemaEntityV2{})
+	engine.GetClickHouse().Exec("TRUNCATE TABLE test.schema_hits")
+	alters = engine.GetClickHouseAlters()
+	assert.Len(t, alters, 4)
+	for _, alter := range alters {
+		assert.True(t, alter.Safe)
+		engine.GetClickHouse().Exec(alter.SQL)
+	}
+	assert.Len(t, engine.GetClickHouseAlters(), 0)
+}
+
+func TestClickHouseEngineFull(t *testing.T) {
+	tableDB := parseClickHouseEngineFull("MergeTree PARTITION BY toYYYYMM(EventDate) ORDER BY (UserID, WatchID) " +
+		"TTL EventDate + toIntervalMonth(1) SETTINGS index_granularity = 8192")
+	assert.Equal(t, "MergeTree PARTITION BY toYYYYMM(EventDate) ORDER BY (UserID, WatchID)", tableDB.engine)
+	assert.Equal(t, "EventDate + toIntervalMonth(1)", tableDB.ttl)
+	tableDB = parseClickHouseEngineFull("MergeTree ORDER BY WatchID SETTINGS index_granularity = 8192")
+	assert.Equal(t, "MergeTree ORDER BY WatchID", tableDB.engine)
+	assert.Equal(t, "", tableDB.ttl)
+
+	schema := &clickHouseTableSchema{engine: "MergeTree()", partition: "toYYYYMM(EventDate)", order: []string{"UserID", "WatchID"}}
+	assert.Equal(t, normalizeClickHouseEngine("MergeTree PARTITION BY toYYYYMM(EventDate) ORDER BY (UserID, WatchID)"),
+		normalizeClickHouseEngine(schema.getEngineFull()))
+	schema.engine = "ReplacingMergeTree(Version)"
+	assert.NotEqual(t, normalizeClickHouseEngine("ReplacingMergeTree PARTITION BY toYYYYMM(EventDate) ORDER BY (UserID, WatchID)"),
+		normalizeClickHouseEngine(schema.getEngineFull()))
+	assert.Equal(t, normalizeClickHouseEngine("ReplacingMergeTree(Version) PARTITION BY toYYYYMM(EventDate) ORDER BY (UserID, WatchID)"),
+		normalizeClickHouseEngine(schema.getEngineFull()))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"plugin"
+
+	"github.com/juju/errors"
+	"github.com/summer-solutions/orm"
+	"gopkg.in/yaml.v2"
+)
+
+const usage = `Usage: orm -config config.yaml -plugin entities.so [options]
+
+Prints schema changes grouped by pool and table. Entities are registered by
+plugin that exports function:
+
+    func RegisterEntities(registry *orm.Registry)
+
+Options:
+`
+
+func main() {
+	err := run(os.Args[1:], os.Stdout)
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) (err error) {
+	flags := flag.NewFlagSet("orm", flag.ContinueOnError)
+	flags.Usage = func() {
+		_, _ = fmt.Fprint(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+	configFile := flags.String("config", "config.yaml", "yaml configuration file")
+	pluginFile := flags.String("plugin", "", "plugin with RegisterEntities function")
+	applySafe := flags.Bool("apply-safe", false, "execute safe alters")
+	sqlFile := flags.String("sql", "", "write all alters to SQL script, use - for stdout")
+	err = flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			asErr, isErr := r.(error)
+			if !isErr {
+				asErr = fmt.Errorf("%v", r)
+			}
+			err = asErr
+		}
+	}()
+
+	registry, err := loadRegistry(*configFile, *pluginFile)
+	if err != nil {
+		return err
+	}
+	validatedRegistry, err := registry.Validate()
+	if err != nil {
+		return err
+	}
+	engine := validatedRegistry.CreateEngine()
+	return processAlters(engine.GetAlters(), *sqlFile, *applySafe, out, func(alter orm.Alter) {
+		engine.GetMysql(alter.Pool).Exec(alter.SQL)
+	})
+}
+
+func processAlters(alters []orm.Alter, sqlFile string, applySafe bool, out io.Writer, exec func(alter orm.Alter)) error {
+	sqlToOut := sqlFile == "-"
+	if sqlToOut {
+		orm.WriteSchemaSQL(out, alters)
+	} else if sqlFile != "" {
+		file, err := os.Create(sqlFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		orm.WriteSchemaSQL(file, alters)
+	}
+
+	if len(alters) == 0 {
+		if !sqlToOut {
+			_, _ = fmt.Fprintln(out, "schema is up to date")
+		}
+		return nil
+	}
+	if !sqlToOut {
+		orm.WriteSchemaDiff(out, orm.GetSchemaDiff(alters))
+	}
+	if applySafe {
+		applied := 0
+		for _, alter := range alters {
+			if alter.Safe {
+				exec(alter)
+				applied++
+			}
+		}
+		summary := "applied %d of %d alters\n"
+		if sqlToOut {
+			summary = "-- " + summary
+		}
+		_, _ = fmt.Fprintf(out, summary, applied, len(alters))
+	}
+	return nil
+}
+
+func loadRegistry(configFile string, pluginFile string) (*orm.Registry, error) {
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	var parsedYaml map[string]interface{}
+	err = yaml.Unmarshal(data, &parsedYaml)
+	if err != nil {
+		return nil, err
+	}
+	registry := orm.InitByYaml(parsedYaml)
+	if pluginFile == "" {
+		return registry, nil
+	}
+	p, err := plugin.Open(pluginFile)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := p.Lookup("RegisterEntities")
+	if err != nil {
+		return nil, err
+	}
+	registerEntities, ok := symbol.(func(registry *orm.Registry))
+	if !ok {
+		return nil, errors.NotValidf("RegisterEntities function in plugin '%s'", pluginFile)
+	}
+	registerEntities(registry)
+	return registry, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/summer-solutions/orm"
+)
+
+func TestRunErrors(t *testing.T) {
+	out := &bytes.Buffer{}
+	err := run([]string{"-unknown"}, out)
+	assert.Error(t, err)
+
+	err = run([]string{"-config", filepath.Join(os.TempDir(), "orm-missing-config.yaml")}, out)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessAlters(t *testing.T) {
+	alters := []orm.Alter{
+		{SQL: "CREATE TABLE `test`.`a` (`ID` int);", Safe: true, Pool: "default"},
+		{SQL: "DROP TABLE IF EXISTS `test`.`b`;", Safe: false, Pool: "default"},
+	}
+	executed := make([]string, 0)
+	exec := func(alter orm.Alter) {
+		executed = append(executed, alter.SQL)
+	}
+
+	out := &bytes.Buffer{}
+	err := processAlters(alters, "-", true, out, exec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CREATE TABLE `test`.`a` (`ID` int);"}, executed)
+	assert.Equal(t, "-- pool: default\n\nCREATE TABLE `test`.`a` (`ID` int);\n\n"+
+		"-- unsafe: data can be lost\nDROP TABLE IF EXISTS `test`.`b`;\n\n-- applied 1 of 2 alters\n", out.String())
+
+	executed = executed[:0]
+	out = &bytes.Buffer{}
+	err = processAlters(alters, "-", false, out, exec)
+	assert.NoError(t, err)
+	assert.Len(t, executed, 0)
+	assert.NotContains(t, out.String(), "applied")
+
+	dir, err := ioutil.TempDir("", "orm")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sqlFile := filepath.Join(dir, "alters.sql")
+	out = &bytes.Buffer{}
+	err = processAlters(alters, sqlFile, true, out, exec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CREATE TABLE `test`.`a` (`ID` int);"}, executed)
+	assert.Contains(t, out.String(), "pool default\n")
+	assert.Contains(t, out.String(), "applied 1 of 2 alters\n")
+	data, err := ioutil.ReadFile(sqlFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "DROP TABLE IF EXISTS `test`.`b`;")
+
+	out = &bytes.Buffer{}
+	err = processAlters(nil, "-", true, out, exec)
+	assert.NoError(t, err)
+	assert.Equal(t, "", out.String())
+	out = &bytes.Buffer{}
+	err = processAlters(nil, "", false, out, exec)
+	assert.NoError(t, err)
+	assert.Equal(t, "schema is up to date\n", out.String())
+}
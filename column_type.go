@@ -0,0 +1,138 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+type ColumnTypeHandler interface {
+	MySQLDefinition(attributes map[string]string) (definition string, nullable bool)
+	Serialize(value reflect.Value) (data string, isNil bool)
+	Deserialize(data string, isNil bool, value reflect.Value)
+}
+
+type valuerScannerColumnType struct{}
+
+func (r *Registry) RegisterColumnType(t reflect.Type, handler ColumnTypeHandler) {
+	if r.columnTypes == nil {
+		r.columnTypes = make(map[reflect.Type]ColumnTypeHandler)
+	}
+	r.columnTypes[t] = handler
+}
+
+func getColumnTypeHandler(registry *Registry, t reflect.Type, tags map[string]string) (ColumnTypeHandler, bool) {
+	handler, has := registry.columnTypes[t]
+	if has {
+		return handler, true
+	}
+	if t.Kind() == reflect.Ptr {
+		if t.Elem().Kind() == reflect.Ptr {
+			return nil, false
+		}
+		handler, has = getColumnTypeHandler(registry, t.Elem(), tags)
+		if has {
+			return &pointerColumnType{handler: handler}, true
+		}
+		return nil, false
+	}
+	if tags["valuer"] != "true" {
+		return nil, false
+	}
+	ptr := reflect.PtrTo(t)
+	if ptr.Implements(scannerType) && ptr.Implements(valuerType) {
+		return &valuerScannerColumnType{}, true
+	}
+	return nil, false
+}
+
+type pointerColumnType struct {
+	handler ColumnTypeHandler
+}
+
+func (h *pointerColumnType) MySQLDefinition(attributes map[string]string) (definition string, nullable bool) {
+	definition, _ = h.handler.MySQLDefinition(attributes)
+	return definition, true
+}
+
+func (h *pointerColumnType) Serialize(value reflect.Value) (data string, isNil bool) {
+	if value.IsNil() {
+		return "", true
+	}
+	return h.handler.Serialize(value.Elem())
+}
+
+func (h *pointerColumnType) Deserialize(data string, isNil bool, value reflect.Value) {
+	if isNil {
+		value.Set(reflect.Zero(value.Type()))
+		return
+	}
+	elem := reflect.New(value.Type().Elem())
+	h.handler.Deserialize(data, false, elem.Elem())
+	value.Set(elem)
+}
+
+func (h *valuerScannerColumnType) MySQLDefinition(attributes map[string]string) (definition string, nullable bool) {
+	definition, has := attributes["mysqlType"]
+	if !has {
+		definition = "varchar(255)"
+	}
+	return definition, true
+}
+
+func (h *valuerScannerColumnType) Serialize(value reflect.Value) (data string, isNil bool) {
+	if !value.Type().Implements(valuerType) {
+		if !value.CanAddr() {
+			copied := reflect.New(value.Type())
+			copied.Elem().Set(value)
+			value = copied.Elem()
+		}
+		value = value.Addr()
+	}
+	val, err := value.Interface().(driver.Valuer).Value()
+	checkError(err)
+	switch v := val.(type) {
+	case nil:
+		return "", true
+	case []byte:
+		return string(v), false
+	case string:
+		return v, false
+	case bool:
+		if v {
+			return "1", false
+		}
+		return "0", false
+	case int64:
+		return strconv.FormatInt(v, 10), false
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05"), false
+	}
+	return fmt.Sprintf("%v", val), false
+}
+
+func (h *valuerScannerColumnType) Deserialize(data string, isNil bool, value reflect.Value) {
+	if isNil {
+		value.Set(reflect.Zero(value.Type()))
+		return
+	}
+	scanned := reflect.New(value.Type())
+	checkError(scanned.Interface().(sql.Scanner).Scan([]byte(data)))
+	value.Set(scanned.Elem())
+}
+
+func (fields *tableFields) getColumnTypes(columnTypes map[string]ColumnTypeHandler) map[string]ColumnTypeHandler {
+	for k, i := range fields.customs {
+		columnTypes[fields.prefix+fields.fields[i].Name] = fields.customsHandlers[k]
+	}
+	for _, subFields := range fields.structs {
+		subFields.getColumnTypes(columnTypes)
+	}
+	return columnTypes
+}
@@ -0,0 +1,191 @@
+package orm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type columnTypeMoney struct {
+	Amount   int64
+	Currency string
+}
+
+func (m columnTypeMoney) Value() (driver.Value, error) {
+	if m.Currency == "" {
+		return nil, nil
+	}
+	return fmt.Sprintf("%d %s", m.Amount, m.Currency), nil
+}
+
+func (m *columnTypeMoney) Scan(src interface{}) error {
+	if src == nil {
+		*m = columnTypeMoney{}
+		return nil
+	}
+	_, err := fmt.Sscanf(string(src.([]byte)), "%d %s", &m.Amount, &m.Currency)
+	return err
+}
+
+type columnTypeIP struct{}
+
+func (h *columnTypeIP) MySQLDefinition(_ map[string]string) (definition string, nullable bool) {
+	return "varbinary(16)", true
+}
+
+func (h *columnTypeIP) Serialize(value reflect.Value) (data string, isNil bool) {
+	ip := value.Interface().(net.IP)
+	if len(ip) == 0 {
+		return "", true
+	}
+	return string(ip.To16()), false
+}
+
+func (h *columnTypeIP) Deserialize(data string, isNil bool, value reflect.Value) {
+	if isNil {
+		value.Set(reflect.Zero(value.Type()))
+		return
+	}
+	value.Set(reflect.ValueOf(net.IP(data)))
+}
+
+type columnTypeEntity struct {
+	ORM   `orm:"localCache;redisCache"`
+	ID    uint
+	Price columnTypeMoney `orm:"valuer;mysqlType=varchar(30)"`
+	IP    net.IP
+}
+
+type columnTypeNullEntity struct {
+	ORM
+	ID   uint
+	Name sql.NullString
+	Age  sql.NullInt64
+}
+
+type columnTypeRedisEntity struct {
+	ORM   `orm:"redisCache"`
+	ID    uint
+	Price *columnTypeMoney `orm:"valuer;mysqlType=varchar(30)"`
+	IP    net.IP
+}
+
+func TestValuerScannerColumnType(t *testing.T) {
+	_, has := getColumnTypeHandler(&Registry{}, reflect.TypeOf(columnTypeMoney{}), nil)
+	assert.False(t, has)
+	_, has = getColumnTypeHandler(&Registry{}, reflect.TypeOf(sql.NullString{}), nil)
+	assert.False(t, has)
+
+	valuer := map[string]string{"valuer": "true"}
+	handler, has := getColumnTypeHandler(&Registry{}, reflect.TypeOf(columnTypeMoney{}), valuer)
+	assert.True(t, has)
+	definition, nullable := handler.MySQLDefinition(map[string]string{})
+	assert.Equal(t, "varchar(255)", definition)
+	assert.True(t, nullable)
+
+	data, isNil := handler.Serialize(reflect.ValueOf(columnTypeMoney{Amount: 10, Currency: "EUR"}))
+	assert.Equal(t, "10 EUR", data)
+	assert.False(t, isNil)
+	_, isNil = handler.Serialize(reflect.ValueOf(columnTypeMoney{}))
+	assert.True(t, isNil)
+
+	money := columnTypeMoney{}
+	handler.Deserialize("12 USD", false, reflect.ValueOf(&money).Elem())
+	assert.Equal(t, columnTypeMoney{Amount: 12, Currency: "USD"}, money)
+	handler.Deserialize("", true, reflect.ValueOf(&money).Elem())
+	assert.Equal(t, columnTypeMoney{}, money)
+
+	_, has = getColumnTypeHandler(&Registry{}, reflect.TypeOf(net.IP{}), valuer)
+	assert.False(t, has)
+
+	handler, has = getColumnTypeHandler(&Registry{}, reflect.TypeOf(&columnTypeMoney{}), valuer)
+	assert.True(t, has)
+	_, isNil = handler.Serialize(reflect.ValueOf((*columnTypeMoney)(nil)))
+	assert.True(t, isNil)
+	data, isNil = handler.Serialize(reflect.ValueOf(&columnTypeMoney{Amount: 5, Currency: "PLN"}))
+	assert.Equal(t, "5 PLN", data)
+	assert.False(t, isNil)
+	var moneyPointer *columnTypeMoney
+	handler.Deserialize("7 EUR", false, reflect.ValueOf(&moneyPointer).Elem())
+	assert.Equal(t, &columnTypeMoney{Amount: 7, Currency: "EUR"}, moneyPointer)
+	handler.Deserialize("", true, reflect.ValueOf(&moneyPointer).Elem())
+	assert.Nil(t, moneyPointer)
+}
+
+func TestColumnType(t *testing.T) {
+	var entity *columnTypeEntity
+	registry := &Registry{}
+	registry.RegisterColumnType(reflect.TypeOf(net.IP{}), &columnTypeIP{})
+	engine := PrepareTables(t, registry, entity)
+
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	price, _ := schema.t.FieldByName("Price")
+	columns, err := checkColumn(engine, schema, &price, make(map[string]*index), make(map[string]*foreignIndex), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "`Price` varchar(30) DEFAULT NULL", columns[0][1])
+	ip, _ := schema.t.FieldByName("IP")
+	columns, err = checkColumn(engine, schema, &ip, make(map[string]*index), make(map[string]*foreignIndex), "")
+	assert.NoError(t, err)
+	assert.Equal(t, "`IP` varbinary(16) DEFAULT NULL", columns[0][1])
+
+	entity = &columnTypeEntity{Price: columnTypeMoney{Amount: 100, Currency: "EUR"}, IP: net.ParseIP("10.0.0.1")}
+	engine.TrackAndFlush(entity)
+	empty := &columnTypeEntity{}
+	engine.TrackAndFlush(empty)
+
+	entity = &columnTypeEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, columnTypeMoney{Amount: 100, Currency: "EUR"}, entity.Price)
+	assert.Equal(t, "10.0.0.1", entity.IP.String())
+
+	entity.Price.Amount = 200
+	engine.TrackAndFlush(entity)
+	entity = &columnTypeEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, int64(200), entity.Price.Amount)
+
+	entity = &columnTypeEntity{}
+	assert.True(t, engine.LoadByID(2, entity))
+	assert.Equal(t, columnTypeMoney{}, entity.Price)
+	assert.Nil(t, entity.IP)
+}
+
+func TestColumnTypeRedisCache(t *testing.T) {
+	var entity *columnTypeRedisEntity
+	registry := &Registry{}
+	registry.RegisterColumnType(reflect.TypeOf(net.IP{}), &columnTypeIP{})
+	engine := PrepareTables(t, registry, entity)
+
+	entity = &columnTypeRedisEntity{Price: &columnTypeMoney{Amount: 100, Currency: "EUR"}, IP: net.ParseIP("10.0.0.1")}
+	engine.TrackAndFlush(entity)
+	engine.TrackAndFlush(&columnTypeRedisEntity{})
+
+	for i := 0; i < 2; i++ {
+		entity = &columnTypeRedisEntity{}
+		assert.True(t, engine.LoadByID(1, entity))
+		assert.Equal(t, &columnTypeMoney{Amount: 100, Currency: "EUR"}, entity.Price)
+		assert.Equal(t, "10.0.0.1", entity.IP.String())
+	}
+	var rows []*columnTypeRedisEntity
+	engine.LoadByIDs([]uint64{1, 2}, &rows)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, "10.0.0.1", rows[0].IP.String())
+	assert.Nil(t, rows[1].Price)
+	assert.Nil(t, rows[1].IP)
+}
+
+func TestColumnTypeValuerNotOptedIn(t *testing.T) {
+	var entity *columnTypeNullEntity
+	registry := &Registry{}
+	engine := PrepareTables(t, registry, entity)
+	assert.Len(t, engine.GetAlters(), 0)
+
+	rows, def := engine.GetMysql().Query("SELECT * FROM `columnTypeNullEntity`")
+	assert.Equal(t, []string{"ID", "NameString", "NameValid", "AgeInt64", "AgeValid"}, rows.Columns())
+	def()
+}
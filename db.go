@@ -18,6 +18,7 @@ type DBConfig struct {
 	databaseName   string
 	db             *sql.DB
 	autoincrement  uint64
+	version        string
 }
 
 type ExecResult interface {
@@ -188,6 +189,7 @@ type DB struct {
 	code          string
 	databaseName  string
 	autoincrement uint64
+	version       string
 	inTransaction bool
 }
 
@@ -228,10 +230,13 @@ func (db *DB) Commit() {
 		}
 	}
 	db.engine.afterCommitLocalCacheSets = nil
+	if db.engine.afterCommitLocalCacheInvalidations != nil {
+		publishLocalCacheInvalidation(db.engine, db.engine.afterCommitLocalCacheInvalidations)
+	}
+	db.engine.afterCommitLocalCacheInvalidations = nil
 	if db.engine.afterCommitRedisCacheDeletes != nil {
 		for cacheCode, keys := range db.engine.afterCommitRedisCacheDeletes {
-			cache := db.engine.GetRedis(cacheCode)
-			cache.Del(keys...)
+			deleteRedisKeys(db.engine.GetRedis(cacheCode), keys)
 		}
 	}
 	db.engine.afterCommitRedisCacheDeletes = nil
@@ -257,6 +262,7 @@ func (db *DB) Rollback() {
 	}
 	checkError(err)
 	db.engine.afterCommitLocalCacheSets = nil
+	db.engine.afterCommitLocalCacheInvalidations = nil
 	db.engine.afterCommitRedisCacheDeletes = nil
 	db.engine.afterCommitDirtyQueues = nil
 	db.engine.afterCommitLogQueues = nil
@@ -2,8 +2,13 @@ package orm
 
 import (
 	"context"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,12 +21,21 @@ import (
 
 const counterElasticAll = "elastic.all"
 const counterElasticSearch = "elastic.search"
+const counterElasticReindex = "elastic.reindex"
+const counterElasticAlias = "elastic.alias"
+const elasticReindexBatchSize = 1000
+const elasticMigrationRounds = 10
 
 type ElasticIndexDefinition interface {
 	GetName() string
 	GetDefinition() map[string]interface{}
 }
 
+type ElasticIndexEntitySource interface {
+	GetEntity() Entity
+	GetDocument(entity Entity) interface{}
+}
+
 type ElasticIndexAlter struct {
 	Index      ElasticIndexDefinition
 	Safe       bool
@@ -30,6 +44,10 @@ type ElasticIndexAlter struct {
 	OldMapping map[string]interface{}
 }
 
+func (a ElasticIndexAlter) Exec(engine *Engine) {
+	engine.GetElastic(a.Pool).MigrateIndex(a.Index)
+}
+
 type elasticSort struct {
 	fields []string
 	asc    []bool
@@ -65,6 +83,13 @@ func (s *elasticSort) Add(field string, ascending bool) {
 	s.asc = append(s.asc, ascending)
 }
 
+const elasticPitKeepAlive = "1m"
+
+type elasticPitSearchResult struct {
+	elastic.SearchResult
+	PitID string `json:"pit_id"`
+}
+
 type Elastic struct {
 	engine *Engine
 	code   string
@@ -94,35 +119,12 @@ func (e *Elastic) Search(index string, query elastic.Query, pager *Pager, option
 	}
 	result, err := searchService.Do(context.Background())
 	if e.engine.queryLoggers[QueryLoggerSourceElastic] != nil {
-		s, _ := query.Source()
-		queryType := strings.Split(reflect.TypeOf(query).Elem().String(), ".")
-		fields := log2.Fields{"Index": index, "post": s, "type": queryType[len(queryType)-1], "from": from, "size": pager.PageSize}
+		fields := e.getSearchLogFields(index, query, options)
+		fields["from"] = from
+		fields["size"] = pager.PageSize
 		if result != nil {
 			fields["query_time"] = result.TookInMillis * 1000
 		}
-		if options != nil {
-			if options.sort != nil {
-				sortFields := make([]string, len(options.sort.fields))
-				for i, v := range options.sort.fields {
-					asc := "ASC"
-					if !options.sort.asc[i] {
-						asc = "DESC"
-					}
-					sortFields[i] = v + " " + asc
-					searchService.Sort(v, options.sort.asc[i])
-				}
-				fields["sort"] = sortFields
-			}
-			if options.aggregation != nil {
-				aggregation := make([]string, len(options.aggregation))
-				i := 0
-				for _, v := range options.aggregation {
-					source, _ := v.Source()
-					aggregation[i] = fmt.Sprintf("%v", source)
-				}
-				fields["aggregation"] = aggregation
-			}
-		}
 		e.fillLogFields("[ORM][ELASTIC][QUERY]", start, "query", fields, err)
 	}
 	e.engine.dataDog.incrementCounter(counterElasticAll, 1)
@@ -131,15 +133,137 @@ func (e *Elastic) Search(index string, query elastic.Query, pager *Pager, option
 	return result
 }
 
-func (e *Elastic) DropIndex(index ElasticIndexDefinition) {
-	ctx := context.Background()
+func (e *Elastic) Iterate(index string, query elastic.Query, options *SearchOptions, batchSize int, handler func(hits []*elastic.SearchHit) bool) {
+	if options == nil || options.sort == nil {
+		e.iterateScroll(index, query, batchSize, handler)
+		return
+	}
+	pitID := e.openPointInTime(index)
+	defer func() {
+		e.closePointInTime(pitID)
+	}()
+	var searchAfter []interface{}
+	for {
+		start := time.Now()
+		source := elastic.NewSearchSource().Query(query).Size(batchSize).StoredField("_id")
+		for i, v := range options.sort.fields {
+			source.Sort(v, options.sort.asc[i])
+		}
+		source.Sort("_shard_doc", true)
+		if searchAfter != nil {
+			source.SearchAfter(searchAfter...)
+		}
+		body, err := source.Source()
+		checkError(err)
+		body.(map[string]interface{})["pit"] = map[string]interface{}{"id": pitID, "keep_alive": elasticPitKeepAlive}
+		result := &elasticPitSearchResult{}
+		response, err := e.client.PerformRequest(context.Background(), elastic.PerformRequestOptions{Method: "POST", Path: "/_search", Body: body})
+		if err == nil {
+			err = json.Unmarshal(response.Body, result)
+		}
+		if e.engine.queryLoggers[QueryLoggerSourceElastic] != nil {
+			fields := e.getSearchLogFields(index, query, options)
+			fields["from"] = 0
+			fields["size"] = batchSize
+			fields["search_after"] = searchAfter
+			if err == nil {
+				fields["query_time"] = result.TookInMillis * 1000
+			}
+			e.fillLogFields("[ORM][ELASTIC][ITERATE]", start, "iterate", fields, err)
+		}
+		e.engine.dataDog.incrementCounter(counterElasticAll, 1)
+		e.engine.dataDog.incrementCounter(counterElasticSearch, 1)
+		checkError(err)
+		if result.PitID != "" {
+			pitID = result.PitID
+		}
+		hits := result.Hits.Hits
+		if len(hits) == 0 || !handler(hits) || len(hits) < batchSize {
+			return
+		}
+		searchAfter = hits[len(hits)-1].Sort
+	}
+}
 
-	existService := elastic.NewIndicesExistsService(e.client)
-	existService.Index([]string{index.GetName()})
-	indexExists, err := existService.Do(ctx)
+func (e *Elastic) openPointInTime(index string) string {
+	response, err := e.client.PerformRequest(context.Background(), elastic.PerformRequestOptions{Method: "POST",
+		Path: "/" + index + "/_pit", Params: url.Values{"keep_alive": []string{elasticPitKeepAlive}}})
 	checkError(err)
-	if indexExists {
-		_, err := e.client.DeleteIndex(index.GetName()).Do(ctx)
+	pit := struct {
+		ID string `json:"id"`
+	}{}
+	checkError(json.Unmarshal(response.Body, &pit))
+	return pit.ID
+}
+
+func (e *Elastic) closePointInTime(pitID string) {
+	_, _ = e.client.PerformRequest(context.Background(), elastic.PerformRequestOptions{Method: "DELETE", Path: "/_pit",
+		Body: map[string]interface{}{"id": pitID}})
+}
+
+func (e *Elastic) iterateScroll(index string, query elastic.Query, batchSize int, handler func(hits []*elastic.SearchHit) bool) {
+	scrollService := e.client.Scroll(index).Query(query).Size(batchSize).KeepAlive("1m").SortBy(elastic.SortByDoc{})
+	defer func() {
+		_ = scrollService.Clear(context.Background())
+	}()
+	for {
+		start := time.Now()
+		result, err := scrollService.Do(context.Background())
+		if err == io.EOF {
+			err = nil
+		}
+		if e.engine.queryLoggers[QueryLoggerSourceElastic] != nil {
+			fields := e.getSearchLogFields(index, query, nil)
+			fields["from"] = 0
+			fields["size"] = batchSize
+			if result != nil {
+				fields["query_time"] = result.TookInMillis * 1000
+			}
+			e.fillLogFields("[ORM][ELASTIC][ITERATE]", start, "iterate", fields, err)
+		}
+		e.engine.dataDog.incrementCounter(counterElasticAll, 1)
+		e.engine.dataDog.incrementCounter(counterElasticSearch, 1)
+		checkError(err)
+		if result == nil || len(result.Hits.Hits) == 0 || !handler(result.Hits.Hits) {
+			return
+		}
+	}
+}
+
+func (e *Elastic) getSearchLogFields(index string, query elastic.Query, options *SearchOptions) log2.Fields {
+	s, _ := query.Source()
+	queryType := strings.Split(reflect.TypeOf(query).Elem().String(), ".")
+	fields := log2.Fields{"Index": index, "post": s, "type": queryType[len(queryType)-1]}
+	if options != nil {
+		if options.sort != nil {
+			sortFields := make([]string, len(options.sort.fields))
+			for i, v := range options.sort.fields {
+				asc := "ASC"
+				if !options.sort.asc[i] {
+					asc = "DESC"
+				}
+				sortFields[i] = v + " " + asc
+			}
+			fields["sort"] = sortFields
+		}
+		if options.aggregation != nil {
+			aggregation := make([]string, len(options.aggregation))
+			i := 0
+			for _, v := range options.aggregation {
+				source, _ := v.Source()
+				aggregation[i] = fmt.Sprintf("%v", source)
+				i++
+			}
+			fields["aggregation"] = aggregation
+		}
+	}
+	return fields
+}
+
+func (e *Elastic) DropIndex(index ElasticIndexDefinition) {
+	ctx := context.Background()
+	for _, physical := range e.getPhysicalIndices(index.GetName()) {
+		_, err := e.client.DeleteIndex(physical).Do(ctx)
 		checkError(err)
 	}
 }
@@ -147,7 +271,343 @@ func (e *Elastic) DropIndex(index ElasticIndexDefinition) {
 func (e *Elastic) CreateIndex(index ElasticIndexDefinition) {
 	ctx := context.Background()
 	e.DropIndex(index)
-	_, err := e.client.CreateIndex(index.GetName()).BodyJson(index.GetDefinition()).Do(ctx)
+	name := index.GetName()
+	definition := make(map[string]interface{})
+	for key, value := range index.GetDefinition() {
+		definition[key] = value
+	}
+	definition["aliases"] = map[string]interface{}{name: map[string]interface{}{}}
+	_, err := e.client.CreateIndex(name + "_v1").BodyJson(definition).Do(ctx)
+	checkError(err)
+}
+
+func (e *Elastic) MigrateIndex(index ElasticIndexDefinition) {
+	ctx := context.Background()
+	name := index.GetName()
+	current := ""
+	physical := e.getPhysicalIndices(name)
+	if len(physical) > 1 {
+		panic(fmt.Errorf("alias '%s' points to more than one index", name))
+	} else if len(physical) == 1 {
+		current = physical[0]
+	}
+	newIndex := fmt.Sprintf("%s_v%d", name, getElasticIndexVersion(name, current)+1)
+	exists, err := e.client.IndexExists(newIndex).Do(ctx)
+	checkError(err)
+	if exists {
+		_, err = e.client.DeleteIndex(newIndex).Do(ctx)
+		checkError(err)
+	}
+	_, err = e.client.CreateIndex(newIndex).BodyJson(index.GetDefinition()).Do(ctx)
+	checkError(err)
+
+	source, isEntitySource := index.(ElasticIndexEntitySource)
+	sync := func(ids []string, reindexFound bool) {
+		if isEntitySource {
+			e.syncFromEntity(newIndex, source, ids, reindexFound)
+		} else {
+			e.syncFromIndex(current, newIndex, ids, reindexFound)
+		}
+	}
+	var checkpoints map[string]int64
+	if current != "" {
+		checkpoints = e.getSeqNoCheckpoints(current)
+	}
+	if isEntitySource {
+		e.reindexFromEntity(newIndex, source)
+	} else if current != "" {
+		e.reindex(current, newIndex, nil)
+	}
+	if current == "" {
+		e.swapAlias(name, current, newIndex)
+		return
+	}
+	for round := 1; ; round++ {
+		next := e.getSeqNoCheckpoints(current)
+		e.scrollChangedIDs(current, checkpoints, func(_ string, ids []string) {
+			sync(ids, true)
+		})
+		checkpoints = next
+		e.scrollIDs(newIndex, nil, "", func(ids []string) {
+			sync(ids, false)
+		})
+		if e.swapAliasWithWriteBlock(name, current, newIndex, checkpoints, sync) {
+			break
+		}
+		if round == elasticMigrationRounds {
+			panic(fmt.Errorf("documents in '%s' were removed during all %d migration rounds", current, elasticMigrationRounds))
+		}
+	}
+	if current != name {
+		_, err = e.client.DeleteIndex(current).Do(ctx)
+		checkError(err)
+	}
+}
+
+func (e *Elastic) swapAliasWithWriteBlock(alias string, current string, newIndex string, checkpoints map[string]int64,
+	sync func(ids []string, reindexFound bool)) bool {
+	swapped := false
+	defer func() {
+		if !swapped {
+			e.setWriteBlock(current, false)
+		}
+	}()
+	e.setWriteBlock(current, true)
+	final := e.getSeqNoCheckpoints(current)
+	changed := make(map[string][]string)
+	e.scrollChangedIDs(current, checkpoints, func(shard string, ids []string) {
+		changed[shard] = append(changed[shard], ids...)
+	})
+	for shard, checkpoint := range checkpoints {
+		if int64(len(changed[shard])) != final[shard]-checkpoint {
+			return false
+		}
+	}
+	for _, ids := range changed {
+		for i := 0; i < len(ids); i += elasticReindexBatchSize {
+			end := i + elasticReindexBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			sync(ids[i:end], true)
+		}
+	}
+	e.swapAlias(alias, current, newIndex)
+	swapped = true
+	return true
+}
+
+func (e *Elastic) getPhysicalIndices(name string) []string {
+	ctx := context.Background()
+	exists, err := e.client.IndexExists(name).Do(ctx)
+	checkError(err)
+	if !exists {
+		return nil
+	}
+	result, err := e.client.Aliases().Index(name).Do(ctx)
+	checkError(err)
+	indices := make([]string, 0, len(result.Indices))
+	for physical := range result.Indices {
+		indices = append(indices, physical)
+	}
+	return indices
+}
+
+func (e *Elastic) reindex(from string, to string, query elastic.Query) {
+	start := time.Now()
+	source := elastic.NewReindexSource().Index(from)
+	if query != nil {
+		source.Query(query)
+	}
+	result, err := e.client.Reindex().Source(source).DestinationIndex(to).
+		WaitForCompletion(true).Refresh("true").Do(context.Background())
+	if e.engine.queryLoggers[QueryLoggerSourceElastic] != nil {
+		fields := log2.Fields{"Index": to, "source": from, "type": "reindex"}
+		if query != nil {
+			fields["post"], _ = query.Source()
+		}
+		if result != nil {
+			fields["created"] = result.Created
+			fields["query_time"] = result.Took * 1000
+		}
+		e.fillLogFields("[ORM][ELASTIC][REINDEX]", start, "reindex", fields, err)
+	}
+	e.engine.dataDog.incrementCounter(counterElasticAll, 1)
+	e.engine.dataDog.incrementCounter(counterElasticReindex, 1)
+	checkError(err)
+	if len(result.Failures) > 0 {
+		panic(fmt.Errorf("reindex from '%s' to '%s' failed: %v", from, to, result.Failures[0]))
+	}
+}
+
+func (e *Elastic) reindexFromEntity(index string, source ElasticIndexEntitySource) {
+	entityType := reflect.TypeOf(source.GetEntity())
+	entities := reflect.New(reflect.SliceOf(entityType))
+	pager := NewPager(1, elasticReindexBatchSize)
+	schema := getTableSchema(e.engine.registry, entityType.Elem())
+	var lastID interface{} = uint64(0)
+	if schema.primaryKeyType != "" {
+		lastID = ""
+	}
+	for {
+		e.engine.Search(NewWhere("`ID` > ? ORDER BY `ID`", lastID), pager, entities.Interface())
+		rows := entities.Elem()
+		total := rows.Len()
+		if total == 0 {
+			return
+		}
+		bulk := e.client.Bulk().Index(index).Refresh("true")
+		for i := 0; i < total; i++ {
+			entity := rows.Index(i).Interface().(Entity)
+			lastID = entity.getORM().getPrimaryKey()
+			bulk.Add(elastic.NewBulkIndexRequest().Id(formatPrimaryKey(schema, lastID)).Doc(source.GetDocument(entity)))
+		}
+		e.runReindexBulk(index, "mysql", bulk)
+		if total < elasticReindexBatchSize {
+			return
+		}
+	}
+}
+
+func (e *Elastic) syncFromEntity(index string, source ElasticIndexEntitySource, ids []string, reindexFound bool) {
+	entityType := reflect.TypeOf(source.GetEntity())
+	schema := getTableSchema(e.engine.registry, entityType.Elem())
+	keys := make([]interface{}, len(ids))
+	for i, id := range ids {
+		keys[i] = parsePrimaryKey(schema, id)
+		if schema.primaryKeyType == primaryKeyUUIDBinary {
+			decoded, _ := hex.DecodeString(id)
+			keys[i] = string(decoded)
+		}
+	}
+	entities := reflect.New(reflect.SliceOf(entityType))
+	e.engine.Search(NewWhere("`ID` IN ?", keys), NewPager(1, len(keys)), entities.Interface())
+	rows := entities.Elem()
+	found := make(map[string]bool, rows.Len())
+	bulk := e.client.Bulk().Index(index).Refresh("true")
+	for i := 0; i < rows.Len(); i++ {
+		entity := rows.Index(i).Interface().(Entity)
+		id := formatPrimaryKey(schema, entity.getORM().getPrimaryKey())
+		found[id] = true
+		if reindexFound {
+			bulk.Add(elastic.NewBulkIndexRequest().Id(id).Doc(source.GetDocument(entity)))
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			bulk.Add(elastic.NewBulkDeleteRequest().Id(id))
+		}
+	}
+	if bulk.NumberOfActions() > 0 {
+		e.runReindexBulk(index, "mysql", bulk)
+	}
+}
+
+func (e *Elastic) syncFromIndex(from string, to string, ids []string, reindexFound bool) {
+	mget := e.client.MultiGet().Realtime(true)
+	for _, id := range ids {
+		item := elastic.NewMultiGetItem().Index(from).Id(id)
+		if !reindexFound {
+			item.FetchSource(elastic.NewFetchSourceContext(false))
+		}
+		mget.Add(item)
+	}
+	result, err := mget.Do(context.Background())
+	checkError(err)
+	found := make(map[string]bool, len(result.Docs))
+	bulk := e.client.Bulk().Index(to).Refresh("true")
+	for _, doc := range result.Docs {
+		if doc.Found {
+			found[doc.Id] = true
+			if reindexFound {
+				bulk.Add(elastic.NewBulkIndexRequest().Id(doc.Id).Doc(doc.Source))
+			}
+		}
+	}
+	for _, id := range ids {
+		if !found[id] {
+			bulk.Add(elastic.NewBulkDeleteRequest().Id(id))
+		}
+	}
+	if bulk.NumberOfActions() > 0 {
+		e.runReindexBulk(to, from, bulk)
+	}
+}
+
+func (e *Elastic) runReindexBulk(index string, source string, bulk *elastic.BulkService) {
+	start := time.Now()
+	total := bulk.NumberOfActions()
+	result, err := bulk.Do(context.Background())
+	if e.engine.queryLoggers[QueryLoggerSourceElastic] != nil {
+		fields := log2.Fields{"Index": index, "source": source, "type": "reindex", "size": total}
+		if result != nil {
+			fields["query_time"] = result.Took * 1000
+		}
+		e.fillLogFields("[ORM][ELASTIC][REINDEX]", start, "reindex", fields, err)
+	}
+	e.engine.dataDog.incrementCounter(counterElasticAll, 1)
+	e.engine.dataDog.incrementCounter(counterElasticReindex, 1)
+	checkError(err)
+	if result.Errors {
+		failed := result.Failed()[0]
+		panic(fmt.Errorf("reindex of '%s' with id %s failed: %s", index, failed.Id, failed.Error.Reason))
+	}
+}
+
+func (e *Elastic) scrollIDs(index string, query elastic.Query, preference string, handler func(ids []string)) {
+	ctx := context.Background()
+	scroll := e.client.Scroll(index).Size(elasticReindexBatchSize).FetchSource(false)
+	if query != nil {
+		scroll.Query(query)
+	}
+	if preference != "" {
+		scroll.Preference(preference)
+	}
+	defer func() {
+		_ = scroll.Clear(ctx)
+	}()
+	for {
+		result, err := scroll.Do(ctx)
+		if err == io.EOF {
+			return
+		}
+		checkError(err)
+		ids := make([]string, len(result.Hits.Hits))
+		for i, hit := range result.Hits.Hits {
+			ids[i] = hit.Id
+		}
+		handler(ids)
+	}
+}
+
+func (e *Elastic) scrollChangedIDs(index string, checkpoints map[string]int64, handler func(shard string, ids []string)) {
+	_, err := e.client.Refresh(index).Do(context.Background())
+	checkError(err)
+	for shard, checkpoint := range checkpoints {
+		shard := shard
+		e.scrollIDs(index, elastic.NewRangeQuery("_seq_no").Gt(checkpoint), "_shards:"+shard, func(ids []string) {
+			handler(shard, ids)
+		})
+	}
+}
+
+func (e *Elastic) getSeqNoCheckpoints(index string) map[string]int64 {
+	result, err := e.client.IndexStats(index).Level("shards").Do(context.Background())
+	checkError(err)
+	checkpoints := make(map[string]int64)
+	for _, stats := range result.Indices {
+		for shard, copies := range stats.Shards {
+			for _, details := range copies {
+				if details.SeqNo != nil && details.Routing != nil && details.Routing.Primary {
+					checkpoints[shard] = details.SeqNo.MaxSeqNo
+				}
+			}
+		}
+	}
+	return checkpoints
+}
+
+func (e *Elastic) setWriteBlock(index string, block bool) {
+	_, err := e.client.IndexPutSettings(index).BodyJson(map[string]interface{}{"index.blocks.write": block}).Do(context.Background())
+	checkError(err)
+}
+
+func (e *Elastic) swapAlias(alias string, current string, newIndex string) {
+	start := time.Now()
+	aliasService := e.client.Alias()
+	if current == alias {
+		aliasService.Action(elastic.NewAliasRemoveIndexAction(current))
+	} else if current != "" {
+		aliasService.Remove(current, alias)
+	}
+	aliasService.Add(newIndex, alias)
+	_, err := aliasService.Do(context.Background())
+	if e.engine.queryLoggers[QueryLoggerSourceElastic] != nil {
+		fields := log2.Fields{"Index": newIndex, "alias": alias, "source": current, "type": "alias"}
+		e.fillLogFields("[ORM][ELASTIC][ALIAS]", start, "alias", fields, err)
+	}
+	e.engine.dataDog.incrementCounter(counterElasticAll, 1)
+	e.engine.dataDog.incrementCounter(counterElasticAlias, 1)
 	checkError(err)
 }
 
@@ -171,38 +631,49 @@ func (e *Elastic) fillLogFields(message string, start time.Time, operation strin
 	}
 }
 
+func getElasticIndexVersion(name string, physical string) int {
+	if !strings.HasPrefix(physical, name+"_v") {
+		return 0
+	}
+	version, err := strconv.Atoi(physical[len(name)+2:])
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
 func getElasticIndexAlters(engine *Engine) (alters []ElasticIndexAlter) {
 	alters = make([]ElasticIndexAlter, 0)
 	if engine.registry.registry.elasticIndices != nil {
 		ctx := context.Background()
 		for pool, indices := range engine.registry.registry.elasticIndices {
-			existService := elastic.NewIndicesExistsService(engine.GetElastic(pool).client)
+			e := engine.GetElastic(pool)
 			for name, index := range indices {
-				existService.Index([]string{name})
-				indexExists, err := existService.Do(ctx)
-				checkError(err)
-				if !indexExists {
+				physical := e.getPhysicalIndices(name)
+				if len(physical) == 0 {
 					alters = append(alters, ElasticIndexAlter{Index: index, Safe: true, Pool: pool})
 					continue
 				}
-				getMappingService := elastic.NewGetMappingService(engine.GetElastic(pool).client)
-				getMappingService.Index(name)
+				physicalName := physical[0]
+				getMappingService := elastic.NewGetMappingService(e.client)
+				getMappingService.Index(physicalName)
 				currentMapping, err := getMappingService.Do(ctx)
 				checkError(err)
 
-				currentMappingIndex := currentMapping[name].(map[string]interface{})
-				getIndexSettingService := elastic.NewIndicesGetSettingsService(engine.GetElastic(pool).client)
-				getIndexSettingService.Index(name)
+				currentMappingIndex := currentMapping[physicalName].(map[string]interface{})
+				getIndexSettingService := elastic.NewIndicesGetSettingsService(e.client)
+				getIndexSettingService.Index(physicalName)
 				currentSettings, err := getIndexSettingService.Do(ctx)
 				checkError(err)
 
-				delete(currentSettings[name].Settings["index"].(map[string]interface{}), "creation_date")
-				delete(currentSettings[name].Settings["index"].(map[string]interface{}), "provided_name")
-				delete(currentSettings[name].Settings["index"].(map[string]interface{}), "uuid")
-				delete(currentSettings[name].Settings["index"].(map[string]interface{}), "version")
+				currentIndexSettings := currentSettings[physicalName].Settings["index"].(map[string]interface{})
+				delete(currentIndexSettings, "creation_date")
+				delete(currentIndexSettings, "provided_name")
+				delete(currentIndexSettings, "uuid")
+				delete(currentIndexSettings, "version")
 				definition := index.GetDefinition()
 				if !cmp.Equal(definition["mappings"], currentMappingIndex["mappings"]) ||
-					!cmp.Equal(definition["settings"], currentSettings[name].Settings["index"]) {
+					!cmp.Equal(definition["settings"], currentIndexSettings) {
 					alters = append(alters, ElasticIndexAlter{Index: index, Safe: false, Pool: pool, OldMapping: currentMappingIndex, NewMapping: definition})
 				}
 			}
@@ -0,0 +1,274 @@
+package orm
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log2 "github.com/apex/log"
+
+	"github.com/olivere/elastic/v7"
+)
+
+const counterElasticBulk = "elastic.bulk"
+const bulkIndexerMaxFailures = 1000
+
+type BulkIndexerFailure struct {
+	Index  string
+	ID     string
+	Action string
+	Status int
+	Error  string
+}
+
+type bulkIndexerItem struct {
+	request elastic.BulkableRequest
+	index   string
+	id      string
+	action  string
+	size    int
+}
+
+type BulkIndexer struct {
+	elastic       *Elastic
+	items         []*bulkIndexerItem
+	size          int
+	maxActions    int
+	maxSize       int
+	flushInterval time.Duration
+	ticker        *time.Ticker
+	done          chan struct{}
+	stopped       chan struct{}
+	mutex         sync.Mutex
+	flushMutex    sync.Mutex
+	maxRetries    int
+	backoff       time.Duration
+	failures      []BulkIndexerFailure
+	onFailure     func(failure BulkIndexerFailure)
+}
+
+func (e *Elastic) BulkIndexer() *BulkIndexer {
+	return &BulkIndexer{elastic: e, maxActions: 1000, maxSize: 5 << 20, maxRetries: 3, backoff: 100 * time.Millisecond}
+}
+
+func (b *BulkIndexer) SetMaxActions(actions int) *BulkIndexer {
+	b.maxActions = actions
+	return b
+}
+
+func (b *BulkIndexer) SetMaxSize(bytes int) *BulkIndexer {
+	b.maxSize = bytes
+	return b
+}
+
+func (b *BulkIndexer) SetFlushInterval(interval time.Duration) *BulkIndexer {
+	b.stopTicker()
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.flushInterval = interval
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		b.ticker = ticker
+		b.done = done
+		b.stopped = stopped
+		go func() {
+			defer close(stopped)
+			for {
+				select {
+				case <-ticker.C:
+					b.Flush()
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	return b
+}
+
+func (b *BulkIndexer) SetRetries(retries int, backoff time.Duration) *BulkIndexer {
+	b.maxRetries = retries
+	b.backoff = backoff
+	return b
+}
+
+func (b *BulkIndexer) OnFailure(handler func(failure BulkIndexerFailure)) *BulkIndexer {
+	b.onFailure = handler
+	return b
+}
+
+func (b *BulkIndexer) Index(index string, id string, document interface{}) {
+	b.add("index", index, id, elastic.NewBulkIndexRequest().Index(index).Id(id).Doc(document))
+}
+
+func (b *BulkIndexer) Update(index string, id string, document interface{}) {
+	b.add("update", index, id, elastic.NewBulkUpdateRequest().Index(index).Id(id).Doc(document))
+}
+
+func (b *BulkIndexer) Upsert(index string, id string, document interface{}) {
+	b.add("update", index, id, elastic.NewBulkUpdateRequest().Index(index).Id(id).Doc(document).DocAsUpsert(true))
+}
+
+func (b *BulkIndexer) Delete(index string, id string) {
+	b.add("delete", index, id, elastic.NewBulkDeleteRequest().Index(index).Id(id))
+}
+
+func (b *BulkIndexer) Pending() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.items)
+}
+
+func (b *BulkIndexer) Failures() []BulkIndexerFailure {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	failures := make([]BulkIndexerFailure, len(b.failures))
+	copy(failures, b.failures)
+	return failures
+}
+
+func (b *BulkIndexer) Flush() []BulkIndexerFailure {
+	b.flushMutex.Lock()
+	defer b.flushMutex.Unlock()
+	b.mutex.Lock()
+	items := b.items
+	b.items = nil
+	b.size = 0
+	b.mutex.Unlock()
+	return b.flush(items)
+}
+
+func (b *BulkIndexer) Close() []BulkIndexerFailure {
+	b.stopTicker()
+	return b.Flush()
+}
+
+func (b *BulkIndexer) stopTicker() {
+	b.mutex.Lock()
+	ticker := b.ticker
+	done := b.done
+	stopped := b.stopped
+	b.ticker = nil
+	b.done = nil
+	b.stopped = nil
+	b.mutex.Unlock()
+	if ticker != nil {
+		ticker.Stop()
+		close(done)
+		<-stopped
+	}
+}
+
+func (b *BulkIndexer) flush(items []*bulkIndexerItem) []BulkIndexerFailure {
+	if len(items) == 0 {
+		return nil
+	}
+	failures := make([]BulkIndexerFailure, 0)
+	for attempt := 0; len(items) > 0; attempt++ {
+		retry := attempt < b.maxRetries
+		items, failures = b.send(items, retry, failures)
+		if len(items) > 0 {
+			time.Sleep(b.backoff * time.Duration(1<<uint(attempt)))
+		}
+	}
+	for _, failure := range failures {
+		if b.onFailure != nil {
+			b.onFailure(failure)
+		}
+	}
+	b.mutex.Lock()
+	b.failures = append(b.failures, failures...)
+	if len(b.failures) > bulkIndexerMaxFailures {
+		b.failures = append([]BulkIndexerFailure(nil), b.failures[len(b.failures)-bulkIndexerMaxFailures:]...)
+	}
+	b.mutex.Unlock()
+	return failures
+}
+
+func (b *BulkIndexer) add(action string, index string, id string, request elastic.BulkableRequest) {
+	size := 0
+	lines, err := request.Source()
+	checkError(err)
+	for _, line := range lines {
+		size += len(line) + 1
+	}
+	b.mutex.Lock()
+	b.items = append(b.items, &bulkIndexerItem{request: request, index: index, id: id, action: action, size: size})
+	b.size += size
+	full := (b.maxActions > 0 && len(b.items) >= b.maxActions) || (b.maxSize > 0 && b.size >= b.maxSize)
+	b.mutex.Unlock()
+	if full {
+		b.Flush()
+	}
+}
+
+func (b *BulkIndexer) send(items []*bulkIndexerItem, retry bool, failures []BulkIndexerFailure) ([]*bulkIndexerItem, []BulkIndexerFailure) {
+	e := b.elastic
+	start := time.Now()
+	service := e.client.Bulk()
+	for _, item := range items {
+		service.Add(item.request)
+	}
+	result, err := service.Do(context.Background())
+	toRetry := make([]*bulkIndexerItem, 0)
+	if err != nil {
+		if retry {
+			toRetry = items
+		} else {
+			for _, item := range items {
+				failures = append(failures, BulkIndexerFailure{Index: item.index, ID: item.id, Action: item.action, Error: err.Error()})
+			}
+		}
+	} else {
+		for i, responseItem := range result.Items {
+			item := items[i]
+			response := responseItem[item.action]
+			if response == nil || (response.Status >= 200 && response.Status < 300) ||
+				(item.action == "delete" && response.Status == http.StatusNotFound) {
+				continue
+			}
+			if retry && isBulkStatusRetryable(response.Status) {
+				toRetry = append(toRetry, item)
+				continue
+			}
+			failure := BulkIndexerFailure{Index: item.index, ID: item.id, Action: item.action, Status: response.Status}
+			if response.Error != nil {
+				failure.Error = response.Error.Type + ": " + response.Error.Reason
+			}
+			failures = append(failures, failure)
+		}
+	}
+	if e.engine.queryLoggers[QueryLoggerSourceElastic] != nil {
+		fields := log2.Fields{"Index": getBulkIndexNames(items), "type": "bulk", "size": len(items), "retry": len(toRetry)}
+		if result != nil {
+			fields["query_time"] = result.Took * 1000
+		}
+		e.fillLogFields("[ORM][ELASTIC][BULK]", start, "bulk", fields, err)
+	}
+	e.engine.dataDog.incrementCounter(counterElasticAll, 1)
+	e.engine.dataDog.incrementCounter(counterElasticBulk, 1)
+	return toRetry, failures
+}
+
+func isBulkStatusRetryable(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusBadGateway ||
+		status == http.StatusServiceUnavailable || status == http.StatusGatewayTimeout
+}
+
+func getBulkIndexNames(items []*bulkIndexerItem) string {
+	unique := make(map[string]bool)
+	for _, item := range items {
+		unique[item.index] = true
+	}
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
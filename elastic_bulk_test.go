@@ -0,0 +1,89 @@
+package orm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apexLog "github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+
+	"github.com/olivere/elastic/v7"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bulkTestIndex struct {
+}
+
+func (i *bulkTestIndex) GetName() string {
+	return "test_bulk_index"
+}
+
+func (i *bulkTestIndex) GetDefinition() map[string]interface{} {
+	return map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"dynamic": "strict",
+			"properties": map[string]interface{}{
+				"Name": map[string]interface{}{"type": "keyword"},
+				"Age":  map[string]interface{}{"type": "integer"},
+			},
+		},
+	}
+}
+
+func TestElasticBulkIndexer(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterElastic("http://127.0.0.1:9209")
+	registry.RegisterElasticIndex(&bulkTestIndex{})
+	engine := PrepareTables(t, registry)
+
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.InfoLevel, QueryLoggerSourceElastic)
+	engine.DataDog().EnableORMAPMLog(apexLog.DebugLevel, true, QueryLoggerSourceElastic)
+
+	e := engine.GetElastic()
+	indexer := e.BulkIndexer().SetMaxActions(3).SetRetries(1, time.Millisecond)
+	reported := make([]BulkIndexerFailure, 0)
+	indexer.OnFailure(func(failure BulkIndexerFailure) {
+		reported = append(reported, failure)
+	})
+	indexer.Index("test_bulk_index", "1", map[string]interface{}{"Name": "John", "Age": 18})
+	indexer.Index("test_bulk_index", "2", map[string]interface{}{"Name": "Tom", "Age": 20})
+	assert.Equal(t, 2, indexer.Pending())
+	indexer.Index("test_bulk_index", "3", map[string]interface{}{"Name": "Adam", "Age": 30})
+	assert.Equal(t, 0, indexer.Pending())
+	assert.Len(t, testLogger.Entries, 1)
+	assert.Equal(t, "[ORM][ELASTIC][BULK]", testLogger.Entries[0].Message)
+
+	indexer.Update("test_bulk_index", "1", map[string]interface{}{"Age": 19})
+	indexer.Delete("test_bulk_index", "2")
+	indexer.Index("test_bulk_index", "4", map[string]interface{}{"Invalid": "field"})
+	assert.Len(t, reported, 1)
+	assert.Len(t, indexer.Failures(), 1)
+	assert.Equal(t, "4", reported[0].ID)
+	assert.Equal(t, "index", reported[0].Action)
+	assert.Equal(t, 400, reported[0].Status)
+	assert.NotEmpty(t, reported[0].Error)
+
+	indexer.Upsert("test_bulk_index", "5", map[string]interface{}{"Name": "Adam", "Age": 30})
+	indexer.Delete("test_bulk_index", "100")
+	failures := indexer.Flush()
+	assert.Len(t, failures, 0)
+	assert.Nil(t, indexer.Flush())
+
+	_, err := e.Client().Refresh("test_bulk_index").Do(context.Background())
+	assert.NoError(t, err)
+	res := e.Search("test_bulk_index", elastic.NewMatchAllQuery(), NewPager(1, 10), nil)
+	assert.Equal(t, int64(3), res.TotalHits())
+
+	indexer = e.BulkIndexer().SetMaxActions(0).SetMaxSize(0).SetFlushInterval(time.Millisecond * 10)
+	indexer.Index("test_bulk_index", "6", map[string]interface{}{"Name": "Ivona"})
+	assert.Equal(t, 1, indexer.Pending())
+	time.Sleep(time.Millisecond * 200)
+	assert.Equal(t, 0, indexer.Pending())
+	indexer.SetFlushInterval(time.Hour)
+	indexer.Index("test_bulk_index", "7", map[string]interface{}{"Name": "Anna"})
+	assert.Len(t, indexer.Close(), 0)
+	assert.Equal(t, 0, indexer.Pending())
+}
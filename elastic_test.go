@@ -1,6 +1,8 @@
 package orm
 
 import (
+	"context"
+	"strconv"
 	"testing"
 
 	apexLog "github.com/apex/log"
@@ -113,3 +115,182 @@ func TestElastic(t *testing.T) {
 	alters = engine.GetElasticIndexAlters()
 	assert.Len(t, alters, 1)
 }
+
+type elasticMigrateEntity struct {
+	ORM
+	ID   uint
+	Name string
+}
+
+type elasticMigrateIndex struct {
+	Definition map[string]interface{}
+}
+
+func (i *elasticMigrateIndex) GetName() string {
+	return "test_migrate_index"
+}
+
+func (i *elasticMigrateIndex) GetDefinition() map[string]interface{} {
+	return i.Definition
+}
+
+func (i *elasticMigrateIndex) GetEntity() Entity {
+	return &elasticMigrateEntity{}
+}
+
+func (i *elasticMigrateIndex) GetDocument(entity Entity) interface{} {
+	return map[string]interface{}{"Name": entity.(*elasticMigrateEntity).Name}
+}
+
+func TestElasticMigrateIndex(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterElastic("http://127.0.0.1:9209")
+	index := &TestIndex{}
+	index.Definition = map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"Name": map[string]interface{}{"type": "keyword"},
+			},
+		},
+	}
+	entityIndex := &elasticMigrateIndex{Definition: map[string]interface{}{
+		"mappings": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"Name": map[string]interface{}{"type": "keyword"},
+			},
+		},
+	}}
+	registry.RegisterElasticIndex(index)
+	registry.RegisterElasticIndex(entityIndex)
+	var entity *elasticMigrateEntity
+	engine := PrepareTables(t, registry, entity)
+	e := engine.GetElastic()
+	e.DropIndex(index)
+	e.DropIndex(entityIndex)
+
+	alters := engine.GetElasticIndexAlters()
+	assert.Len(t, alters, 2)
+	for _, alter := range alters {
+		alter.Exec(engine)
+	}
+	assert.Len(t, engine.GetElasticIndexAlters(), 0)
+	assert.Equal(t, []string{"test_index_v1"}, e.getPhysicalIndices("test_index"))
+
+	_, err := e.Client().Index().Index("test_index").Id("1").BodyJson(map[string]interface{}{"Name": "John"}).Refresh("true").Do(context.Background())
+	assert.NoError(t, err)
+	index.Definition["mappings"].(map[string]interface{})["properties"].(map[string]interface{})["LastName"] = map[string]interface{}{"type": "keyword"}
+	alters = engine.GetElasticIndexAlters()
+	assert.Len(t, alters, 1)
+	assert.False(t, alters[0].Safe)
+	alters[0].Exec(engine)
+	assert.Len(t, engine.GetElasticIndexAlters(), 0)
+	assert.Equal(t, []string{"test_index_v2"}, e.getPhysicalIndices("test_index"))
+	res := e.Search("test_index", elastic.NewTermQuery("Name", "John"), NewPager(1, 10), nil)
+	assert.Equal(t, int64(1), res.TotalHits())
+
+	engine.TrackAndFlush(&elasticMigrateEntity{Name: "a"}, &elasticMigrateEntity{Name: "b"})
+	entityIndex.Definition["mappings"].(map[string]interface{})["properties"].(map[string]interface{})["Age"] = map[string]interface{}{"type": "integer"}
+	alters = engine.GetElasticIndexAlters()
+	assert.Len(t, alters, 1)
+	alters[0].Exec(engine)
+	assert.Equal(t, []string{"test_migrate_index_v2"}, e.getPhysicalIndices("test_migrate_index"))
+	res = e.Search("test_migrate_index", elastic.NewMatchAllQuery(), NewPager(1, 10), nil)
+	assert.Equal(t, int64(2), res.TotalHits())
+
+	e.CreateIndex(index)
+	assert.Equal(t, []string{"test_index_v1"}, e.getPhysicalIndices("test_index"))
+	_, err = e.Client().Index().Index("test_index").Id("1").BodyJson(map[string]interface{}{"Name": "John"}).Refresh("true").Do(context.Background())
+	assert.NoError(t, err)
+	_, err = e.Client().Index().Index("test_index").Id("2").BodyJson(map[string]interface{}{"Name": "Tom"}).Refresh("true").Do(context.Background())
+	assert.NoError(t, err)
+	checkpoints := e.getSeqNoCheckpoints("test_index_v1")
+	assert.Equal(t, map[string]int64{"0": 1}, checkpoints)
+	_, err = e.Client().Index().Index("test_index").Id("1").BodyJson(map[string]interface{}{"Name": "Adam"}).Refresh("true").Do(context.Background())
+	assert.NoError(t, err)
+	_, err = e.Client().Index().Index("test_index").Id("3").BodyJson(map[string]interface{}{"Name": "Ben"}).Refresh("true").Do(context.Background())
+	assert.NoError(t, err)
+	changed := make([]string, 0)
+	e.scrollChangedIDs("test_index_v1", checkpoints, func(shard string, ids []string) {
+		assert.Equal(t, "0", shard)
+		changed = append(changed, ids...)
+	})
+	assert.ElementsMatch(t, []string{"1", "3"}, changed)
+	e.syncFromIndex("test_index_v1", "test_migrate_index_v2", changed, true)
+	res = e.Search("test_migrate_index_v2", elastic.NewTermsQuery("Name", "Adam", "Ben", "Tom"), NewPager(1, 10), nil)
+	assert.Equal(t, int64(2), res.TotalHits())
+
+	checkpoints = e.getSeqNoCheckpoints("test_index_v1")
+	_, err = e.Client().Delete().Index("test_index").Id("3").Refresh("true").Do(context.Background())
+	assert.NoError(t, err)
+	sync := func(ids []string, reindexFound bool) {
+		e.syncFromIndex("test_index_v1", "test_migrate_index_v2", ids, reindexFound)
+	}
+	assert.False(t, e.swapAliasWithWriteBlock("test_index", "test_index_v1", "test_migrate_index_v2", checkpoints, sync))
+	assert.Equal(t, []string{"test_index_v1"}, e.getPhysicalIndices("test_index"))
+	_, err = e.Client().Index().Index("test_index").Id("3").BodyJson(map[string]interface{}{"Name": "Ben"}).Refresh("true").Do(context.Background())
+	assert.NoError(t, err)
+	index.Definition["mappings"].(map[string]interface{})["properties"].(map[string]interface{})["Age"] = map[string]interface{}{"type": "integer"}
+	engine.GetElasticIndexAlters()[0].Exec(engine)
+	assert.Equal(t, []string{"test_index_v2"}, e.getPhysicalIndices("test_index"))
+	res = e.Search("test_index", elastic.NewMatchAllQuery(), NewPager(1, 10), nil)
+	assert.Equal(t, int64(3), res.TotalHits())
+}
+
+func TestElasticIterate(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterElastic("http://127.0.0.1:9209")
+	registry.RegisterElasticIndex(&bulkTestIndex{})
+	engine := PrepareTables(t, registry)
+	e := engine.GetElastic()
+	indexer := e.BulkIndexer()
+	for i := 1; i <= 25; i++ {
+		indexer.Index("test_bulk_index", strconv.Itoa(i), map[string]interface{}{"Name": "name " + strconv.Itoa(i), "Age": i})
+	}
+	assert.Len(t, indexer.Flush(), 0)
+	_, err := e.Client().Refresh("test_bulk_index").Do(context.Background())
+	assert.NoError(t, err)
+
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.InfoLevel, QueryLoggerSourceElastic)
+	engine.DataDog().EnableORMAPMLog(apexLog.DebugLevel, true, QueryLoggerSourceElastic)
+
+	options := &SearchOptions{}
+	options.AddSort("Age", false)
+	ids := make([]string, 0)
+	batches := 0
+	e.Iterate("test_bulk_index", elastic.NewMatchAllQuery(), options, 10, func(hits []*elastic.SearchHit) bool {
+		batches++
+		for _, hit := range hits {
+			ids = append(ids, hit.Id)
+		}
+		return true
+	})
+	assert.Equal(t, 3, batches)
+	assert.Len(t, ids, 25)
+	assert.Equal(t, "25", ids[0])
+	assert.Equal(t, "1", ids[24])
+	assert.Len(t, testLogger.Entries, 3)
+	assert.Equal(t, "[ORM][ELASTIC][ITERATE]", testLogger.Entries[0].Message)
+
+	batches = 0
+	e.Iterate("test_bulk_index", elastic.NewMatchAllQuery(), options, 10, func(hits []*elastic.SearchHit) bool {
+		batches++
+		return false
+	})
+	assert.Equal(t, 1, batches)
+
+	total := 0
+	e.Iterate("test_bulk_index", elastic.NewRangeQuery("Age").Gt(5), nil, 7, func(hits []*elastic.SearchHit) bool {
+		total += len(hits)
+		return true
+	})
+	assert.Equal(t, 20, total)
+
+	options = &SearchOptions{}
+	options.AddSort("Invalid", false)
+	assert.Panics(t, func() {
+		e.Iterate("test_bulk_index", elastic.NewMatchAllQuery(), options, 10, func(hits []*elastic.SearchHit) bool {
+			return true
+		})
+	})
+}
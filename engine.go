@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"reflect"
@@ -21,26 +22,28 @@ type obj struct {
 }
 
 type Engine struct {
-	registry                     *validatedRegistry
-	dbs                          map[string]*DB
-	clickHouseDbs                map[string]*ClickHouse
-	localCache                   map[string]*LocalCache
-	redis                        map[string]*RedisCache
-	elastic                      map[string]*Elastic
-	locks                        map[string]*Locker
-	rabbitMQChannels             map[string]*rabbitMQChannel
-	rabbitMQQueues               map[string]*RabbitMQQueue
-	rabbitMQRouters              map[string]*RabbitMQRouter
-	logMetaData                  map[string]interface{}
-	trackedEntities              []Entity
-	trackedEntitiesCounter       int
-	queryLoggers                 map[QueryLoggerSource]*logger
-	log                          *log
-	afterCommitLocalCacheSets    map[string][]interface{}
-	afterCommitRedisCacheDeletes map[string][]string
-	afterCommitDirtyQueues       map[string][]*DirtyQueueValue
-	afterCommitLogQueues         []*LogQueueValue
-	dataDog                      *dataDog
+	registry                           *validatedRegistry
+	dbs                                map[string]*DB
+	clickHouseDbs                      map[string]*ClickHouse
+	localCache                         map[string]*LocalCache
+	redis                              map[string]*RedisCache
+	elastic                            map[string]*Elastic
+	locks                              map[string]*Locker
+	rabbitMQChannels                   map[string]*rabbitMQChannel
+	rabbitMQQueues                     map[string]*RabbitMQQueue
+	rabbitMQRouters                    map[string]*RabbitMQRouter
+	logMetaData                        map[string]interface{}
+	trackedEntities                    []Entity
+	trackedEntitiesCounter             int
+	queryLoggers                       map[QueryLoggerSource]*logger
+	log                                *log
+	afterCommitLocalCacheSets          map[string][]interface{}
+	afterCommitRedisCacheDeletes       map[string][]string
+	afterCommitLocalCacheInvalidations map[string][]string
+	afterCommitDirtyQueues             map[string][]*DirtyQueueValue
+	afterCommitLogQueues               []*LogQueueValue
+	dataDog                            *dataDog
+	flushLock                          context.Context
 }
 
 func (e *Engine) DataDog() DataDog {
@@ -198,8 +201,10 @@ func (e *Engine) MarkDirty(entity Entity, queueCode string, ids ...uint64) {
 	if !has {
 		panic(errors.NotFoundf("dirty queue '%s'", queueCode))
 	}
+	schema := initIfNeeded(e, entity).tableSchema
+	schema.checkIntegerPrimaryKey()
 	channel := e.GetRabbitMQQueue("dirty_queue_" + queueCode)
-	entityName := initIfNeeded(e, entity).tableSchema.t.String()
+	entityName := schema.t.String()
 	for _, id := range ids {
 		val := &DirtyQueueValue{Updated: true, ID: id, EntityName: entityName}
 		asJSON, _ := json.Marshal(val)
@@ -383,14 +388,43 @@ func (e *Engine) CachedSearchWithReferences(entities interface{}, indexName stri
 	return total
 }
 
+func (e *Engine) ClickHouseInsert(entities ...ClickHouseEntity) {
+	clickHouseInsert(e, entities...)
+}
+
+func (e *Engine) ClickHouseSearch(where *Where, entities interface{}) {
+	clickHouseSearch(e, where, entities)
+}
+
 func (e *Engine) ClearByIDs(entity Entity, ids ...uint64) {
+	initIfNeeded(e, entity).tableSchema.checkIntegerPrimaryKey()
 	clearByIDs(e, entity, ids...)
 }
 
 func (e *Engine) LoadByID(id uint64, entity Entity, references ...string) (found bool) {
+	initIfNeeded(e, entity).tableSchema.checkIntegerPrimaryKey()
 	return loadByID(e, id, entity, true, references...)
 }
 
+func (e *Engine) LoadByIDForUpdate(id uint64, entity Entity, references ...string) (found bool) {
+	initIfNeeded(e, entity).tableSchema.checkIntegerPrimaryKey()
+	return loadByIDWithLock(e, id, entity, " FOR UPDATE", references...)
+}
+
+func (e *Engine) LoadByIDLockInShareMode(id uint64, entity Entity, references ...string) (found bool) {
+	initIfNeeded(e, entity).tableSchema.checkIntegerPrimaryKey()
+	return loadByIDWithLock(e, id, entity, " LOCK IN SHARE MODE", references...)
+}
+
+func (e *Engine) LockEntity(entity Entity, ttl time.Duration, waitTimeout time.Duration, lockerPool ...string) (lock *Lock, obtained bool) {
+	orm := initIfNeeded(e, entity)
+	id := orm.getPrimaryKey()
+	if id == uint64(0) || id == "" {
+		panic(errors.Errorf("entity '%s' without ID can't be locked", orm.tableSchema.t.String()))
+	}
+	return e.GetLocker(lockerPool...).Obtain("lock:"+orm.tableSchema.getCacheKey(id), ttl, waitTimeout)
+}
+
 func (e *Engine) Load(entity Entity, references ...string) {
 	if e.Loaded(entity) {
 		if len(references) > 0 {
@@ -400,8 +434,8 @@ func (e *Engine) Load(entity Entity, references ...string) {
 		return
 	}
 	orm := initIfNeeded(e, entity)
-	id := orm.GetID()
-	if id > 0 {
+	id := orm.getPrimaryKey()
+	if id != uint64(0) && id != "" {
 		loadByID(e, id, entity, true, references...)
 	}
 }
@@ -414,6 +448,10 @@ func (e *Engine) GetAlters() (alters []Alter) {
 	return getAlters(e)
 }
 
+func (e *Engine) GetClickHouseAlters() (alters []Alter) {
+	return getClickHouseAlters(e)
+}
+
 func (e *Engine) GetElasticIndexAlters() (alters []ElasticIndexAlter) {
 	return getElasticIndexAlters(e)
 }
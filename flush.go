@@ -42,7 +42,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 	insertArguments := make(map[reflect.Type][]interface{})
 	insertBinds := make(map[reflect.Type][]map[string]interface{})
 	insertReflectValues := make(map[reflect.Type][]Entity)
-	deleteBinds := make(map[reflect.Type]map[uint64]map[string]interface{})
+	deleteBinds := make(map[reflect.Type]map[interface{}]map[string]interface{})
 	totalInsert := make(map[reflect.Type]int)
 	localCacheSets := make(map[string]map[string][]interface{})
 	localCacheDeletes := make(map[string]map[string]bool)
@@ -101,19 +101,28 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 		bindLength := len(bind)
 
 		t := orm.tableSchema.t
-		currentID := entity.GetID()
+		currentID := orm.getPrimaryKey()
 		if orm.attributes.delete {
 			if deleteBinds[t] == nil {
-				deleteBinds[t] = make(map[uint64]map[string]interface{})
+				deleteBinds[t] = make(map[interface{}]map[string]interface{})
 			}
 			deleteBinds[t][currentID] = dbData
 		} else if len(dbData) == 0 {
+			if schema.primaryKeyType != "" {
+				if lazy && schema.primaryKeyType == primaryKeyUUIDBinary {
+					panic(errors.NotSupportedf("lazy flush with binary primary key"))
+				}
+				if currentID == "" {
+					currentID = schema.generatePrimaryKey()
+					setPrimaryKey(orm, currentID)
+				}
+			}
 			onUpdate := entity.getORM().attributes.onDuplicateKeyUpdate
 			if onUpdate != nil {
 				if lazy {
 					panic(errors.NotSupportedf("lazy flush on duplicate key"))
 				}
-				if currentID > 0 {
+				if currentID != uint64(0) {
 					bind["ID"] = currentID
 					bindLength++
 				}
@@ -137,9 +146,10 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 				sql += subSQL
 				bindRow = append(bindRow, onUpdate.GetParameters()...)
 				db := schema.GetMysql(engine)
+				engine.checkFlushLock()
 				result := db.Exec(sql, bindRow...)
 				affected := result.RowsAffected()
-				if affected > 0 && currentID == 0 {
+				if affected > 0 && currentID == uint64(0) {
 					lastID := result.LastInsertId()
 					injectBind(entity, bind)
 					entity.getORM().attributes.idElem.SetUint(lastID)
@@ -151,7 +161,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 						logQueues = updateCacheAfterUpdate(dbData, engine, entity, bind, schema, localCacheSets, localCacheDeletes, db, lastID,
 							redisKeysToDelete, dirtyQueues, logQueues)
 					}
-				} else if currentID > 0 {
+				} else if currentID != uint64(0) {
 					_ = loadByID(engine, currentID, entity, false)
 					logQueues = updateCacheForInserted(entity, lazy, currentID, bind, localCacheSets,
 						localCacheDeletes, redisKeysToDelete, dirtyQueues, logQueues)
@@ -174,7 +184,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 				}
 				continue
 			}
-			if currentID > 0 {
+			if currentID != uint64(0) {
 				bind["ID"] = currentID
 				bindLength++
 			}
@@ -209,7 +219,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 		} else {
 			values := make([]interface{}, bindLength+1)
 			if !engine.Loaded(entity) {
-				panic(errors.Errorf("entity is not loaded and can't be updated: %v [%d]", entity.getORM().attributes.elem.Type().String(), currentID))
+				panic(errors.Errorf("entity is not loaded and can't be updated: %v [%v]", entity.getORM().attributes.elem.Type().String(), currentID))
 			}
 			fields := make([]string, bindLength)
 			i := 0
@@ -233,6 +243,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 				if smartUpdate {
 					fillLazyQuery(lazyMap, db.GetPoolCode(), sql, values)
 				} else {
+					engine.checkFlushLock()
 					_ = db.Exec(sql, values...)
 				}
 			}
@@ -278,14 +289,15 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 		if lazy {
 			fillLazyQuery(lazyMap, db.GetPoolCode(), sql, insertArguments[typeOf])
 		} else {
+			engine.checkFlushLock()
 			res := db.Exec(sql, insertArguments[typeOf]...)
 			id = res.LastInsertId()
 		}
 		for key, entity := range insertReflectValues[typeOf] {
 			bind := insertBinds[typeOf][key]
 			injectBind(entity, bind)
-			insertedID := entity.GetID()
-			if insertedID == 0 {
+			insertedID := entity.getORM().getPrimaryKey()
+			if insertedID == uint64(0) {
 				entity.getORM().attributes.idElem.SetUint(id)
 				insertedID = id
 				id = id + db.autoincrement
@@ -294,8 +306,8 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 			logQueues = updateCacheForInserted(entity, lazy, insertedID, bind, localCacheSets, localCacheDeletes,
 				redisKeysToDelete, dirtyQueues, logQueues)
 			localCache, hasLocalCache := schema.GetLocalCache(engine)
-			if hasLocalCache {
-				addLocalCacheSet(localCacheSets, db.GetPoolCode(), localCache.code, schema.getCacheKey(insertedID), buildLocalCacheValue(entity))
+			if hasLocalCache && !schema.hasGenerated {
+				addLocalCacheSet(localCacheSets, db.GetPoolCode(), localCache.code, schema.getCacheKey(insertedID), schema.getLocalCacheValue(buildLocalCacheValue(entity), false))
 			}
 		}
 	}
@@ -343,6 +355,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 					}
 				}
 			}
+			engine.checkFlushLock()
 			_ = db.Exec(sql, ids...)
 		}
 
@@ -350,7 +363,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 		redisCache, hasRedis := schema.GetRedisCache(engine)
 		if hasLocalCache {
 			for id, bind := range deleteBinds {
-				addLocalCacheSet(localCacheSets, db.GetPoolCode(), localCache.code, schema.getCacheKey(id), "nil")
+				addLocalCacheSet(localCacheSets, db.GetPoolCode(), localCache.code, schema.getCacheKey(id), schema.getLocalCacheValue("nil", true))
 				keys := getCacheQueriesKeys(schema, bind, bind, true)
 				addCacheDeletes(localCacheDeletes, localCache.code, keys...)
 			}
@@ -363,12 +376,16 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 			}
 		}
 		for id, bind := range deleteBinds {
-			addDirtyQueues(dirtyQueues, bind, schema, id, "d")
-			logQueues = addToLogQueue(logQueues, schema, id, bind, nil, nil)
+			addDirtyQueues(dirtyQueues, bind, schema, integerKey(id), "d")
+			logQueues = addToLogQueue(logQueues, schema, integerKey(id), bind, nil, nil)
 		}
 	}
+	invalidations := make(map[string][]string)
 	for _, values := range localCacheSets {
 		for cacheCode, keys := range values {
+			for i := 0; i < len(keys); i += 2 {
+				invalidations[cacheCode] = append(invalidations[cacheCode], keys[i].(string))
+			}
 			cache := engine.GetLocalCache(cacheCode)
 			if !isInTransaction {
 				cache.MSet(keys...)
@@ -397,8 +414,10 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 			deletesLocalCache.(map[string][]string)[cacheCode] = keys
 		} else {
 			cache.Remove(keys...)
+			invalidations[cacheCode] = append(invalidations[cacheCode], keys...)
 		}
 	}
+	addLocalCacheInvalidation(engine, isInTransaction, invalidations)
 	for cacheCode, allKeys := range redisKeysToDelete {
 		cache := engine.GetRedis(cacheCode)
 		keys := make([]string, len(allKeys))
@@ -416,7 +435,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 			deletesRedisCache.(map[string][]string)[cacheCode] = keys
 		} else {
 			if !isInTransaction {
-				cache.Del(keys...)
+				deleteRedisKeys(cache, keys)
 			} else {
 				if engine.afterCommitRedisCacheDeletes == nil {
 					engine.afterCommitRedisCacheDeletes = make(map[string][]string)
@@ -452,7 +471,7 @@ func flush(engine *Engine, lazy bool, transaction bool, smart bool, entities ...
 
 func updateCacheAfterUpdate(dbData map[string]interface{}, engine *Engine, entity Entity, bind map[string]interface{},
 	schema *tableSchema, localCacheSets map[string]map[string][]interface{}, localCacheDeletes map[string]map[string]bool,
-	db *DB, currentID uint64, redisKeysToDelete map[string]map[string]bool,
+	db *DB, currentID interface{}, redisKeysToDelete map[string]map[string]bool,
 	dirtyQueues map[string][]*DirtyQueueValue, logQueues []*LogQueueValue) []*LogQueueValue {
 	old := make(map[string]interface{}, len(dbData))
 	for k, v := range dbData {
@@ -462,7 +481,11 @@ func updateCacheAfterUpdate(dbData map[string]interface{}, engine *Engine, entit
 	localCache, hasLocalCache := schema.GetLocalCache(engine)
 	redisCache, hasRedis := schema.GetRedisCache(engine)
 	if hasLocalCache {
-		addLocalCacheSet(localCacheSets, db.GetPoolCode(), localCache.code, schema.getCacheKey(currentID), buildLocalCacheValue(entity))
+		if schema.hasGenerated {
+			addCacheDeletes(localCacheDeletes, localCache.code, schema.getCacheKey(currentID))
+		} else {
+			addLocalCacheSet(localCacheSets, db.GetPoolCode(), localCache.code, schema.getCacheKey(currentID), schema.getLocalCacheValue(buildLocalCacheValue(entity), false))
+		}
 		keys := getCacheQueriesKeys(schema, bind, dbData, false)
 		addCacheDeletes(localCacheDeletes, localCache.code, keys...)
 		keys = getCacheQueriesKeys(schema, bind, old, false)
@@ -475,8 +498,8 @@ func updateCacheAfterUpdate(dbData map[string]interface{}, engine *Engine, entit
 		keys = getCacheQueriesKeys(schema, bind, old, false)
 		addCacheDeletes(redisKeysToDelete, redisCache.code, keys...)
 	}
-	addDirtyQueues(dirtyQueues, bind, schema, currentID, "u")
-	return addToLogQueue(logQueues, schema, currentID, old, bind, entity.getORM().attributes.logMeta)
+	addDirtyQueues(dirtyQueues, bind, schema, integerKey(currentID), "u")
+	return addToLogQueue(logQueues, schema, integerKey(currentID), old, bind, entity.getORM().attributes.logMeta)
 }
 
 func serializeForLazyQueue(lazyMap map[string]interface{}) []byte {
@@ -489,12 +512,25 @@ func injectBind(entity Entity, bind map[string]interface{}) map[string]interface
 	for key, value := range bind {
 		orm.dBData[key] = value
 	}
+	for name, now := range orm.attributes.timestamps {
+		_, has := bind[name]
+		if !has {
+			continue
+		}
+		field := orm.attributes.elem.FieldByName(name)
+		if field.Kind() == reflect.Ptr {
+			field.Set(reflect.ValueOf(&now))
+		} else {
+			field.Set(reflect.ValueOf(now))
+		}
+	}
+	orm.attributes.timestamps = nil
 	orm.attributes.loaded = true
 	return orm.dBData
 }
 
 func createBind(id uint64, tableSchema *tableSchema, t reflect.Type, value reflect.Value,
-	oldData map[string]interface{}, prefix string) (bind map[string]interface{}) {
+	oldData map[string]interface{}, prefix string, timestamps map[string]time.Time) (bind map[string]interface{}) {
 	bind = make(map[string]interface{})
 	var hasOld = len(oldData) > 0
 	for i := 0; i < t.NumField(); i++ {
@@ -510,9 +546,29 @@ func createBind(id uint64, tableSchema *tableSchema, t reflect.Type, value refle
 		if has {
 			continue
 		}
+		_, has = attributes["generated"]
+		if has {
+			continue
+		}
 		fieldTypeString := field.Type().String()
 		required, hasRequired := attributes["required"]
 		isRequired := hasRequired && required == "true"
+		handler, isCustom := tableSchema.columnTypes[name]
+		if isCustom {
+			valueAsString, isNil := handler.Serialize(field)
+			if isNil {
+				if hasOld && (old == "nil" || old == nil) {
+					continue
+				}
+				bind[name] = nil
+				continue
+			}
+			if hasOld && old == valueAsString {
+				continue
+			}
+			bind[name] = valueAsString
+			continue
+		}
 		switch fieldTypeString {
 		case "uint", "uint8", "uint16", "uint32", "uint64":
 			val := field.Uint()
@@ -709,20 +765,14 @@ func createBind(id uint64, tableSchema *tableSchema, t reflect.Type, value refle
 			continue
 		case "time.Time":
 			value := field.Interface().(time.Time)
-			layout := "2006-01-02"
-			var valueAsString string
-			if tableSchema.tags[name]["time"] == "true" {
-				if value.Year() == 1 {
-					valueAsString = "0001-01-01 00:00:00"
-				} else {
-					layout += " 15:04:05"
+			_, hasCurrent := attributes["currentTimestamp"]
+			if !hasOld && hasCurrent && value.IsZero() {
+				value = getCurrentTime(attributes)
+				if prefix == "" {
+					timestamps[name] = value
 				}
-			} else if value.Year() == 1 {
-				valueAsString = "0001-01-01"
-			}
-			if valueAsString == "" {
-				valueAsString = value.Format(layout)
 			}
+			valueAsString := formatTime(tableSchema, name, attributes, value)
 			if hasOld && old == valueAsString {
 				continue
 			}
@@ -730,15 +780,17 @@ func createBind(id uint64, tableSchema *tableSchema, t reflect.Type, value refle
 			continue
 		case "*time.Time":
 			value := field.Interface().(*time.Time)
-			layout := "2006-01-02"
-			var valueAsString string
-			if tableSchema.tags[name]["time"] == "true" {
-				if value != nil {
-					layout += " 15:04:05"
+			_, hasCurrent := attributes["currentTimestamp"]
+			if !hasOld && hasCurrent && value == nil {
+				now := getCurrentTime(attributes)
+				value = &now
+				if prefix == "" {
+					timestamps[name] = now
 				}
 			}
+			var valueAsString string
 			if value != nil {
-				valueAsString = value.Format(layout)
+				valueAsString = formatTime(tableSchema, name, attributes, *value)
 			}
 			if hasOld && (old == valueAsString || (valueAsString == "" && (old == nil || old == "nil"))) {
 				continue
@@ -765,7 +817,7 @@ func createBind(id uint64, tableSchema *tableSchema, t reflect.Type, value refle
 		default:
 			k := field.Kind().String()
 			if k == "struct" {
-				subBind := createBind(0, tableSchema, field.Type(), reflect.ValueOf(field.Interface()), oldData, fieldType.Name)
+				subBind := createBind(0, tableSchema, field.Type(), reflect.ValueOf(field.Interface()), oldData, fieldType.Name, timestamps)
 				for key, value := range subBind {
 					bind[key] = value
 				}
@@ -867,6 +919,14 @@ func getCacheQueriesKeys(schema *tableSchema, bind map[string]interface{}, data
 	return
 }
 
+func deleteRedisKeys(cache *RedisCache, keys []string) {
+	cache.Pipeline(func(p *RedisPipeline) {
+		for _, key := range keys {
+			p.Del(key)
+		}
+	})
+}
+
 func addLocalCacheSet(localCacheSets map[string]map[string][]interface{}, dbCode string, cacheCode string, keys ...interface{}) {
 	if localCacheSets[dbCode] == nil {
 		localCacheSets[dbCode] = make(map[string][]interface{})
@@ -968,7 +1028,7 @@ func convertToError(err error) error {
 	return err
 }
 
-func updateCacheForInserted(entity Entity, lazy bool, id uint64,
+func updateCacheForInserted(entity Entity, lazy bool, id interface{},
 	bind map[string]interface{}, localCacheSets map[string]map[string][]interface{}, localCacheDeletes map[string]map[string]bool,
 	redisKeysToDelete map[string]map[string]bool, dirtyQueues map[string][]*DirtyQueueValue,
 	logQueues []*LogQueueValue) []*LogQueueValue {
@@ -977,8 +1037,8 @@ func updateCacheForInserted(entity Entity, lazy bool, id uint64,
 	localCache, hasLocalCache := schema.GetLocalCache(engine)
 	redisCache, hasRedis := schema.GetRedisCache(engine)
 	if hasLocalCache {
-		if !lazy {
-			addLocalCacheSet(localCacheSets, schema.GetMysql(engine).GetPoolCode(), localCache.code, schema.getCacheKey(id), buildLocalCacheValue(entity))
+		if !lazy && !schema.hasGenerated {
+			addLocalCacheSet(localCacheSets, schema.GetMysql(engine).GetPoolCode(), localCache.code, schema.getCacheKey(id), schema.getLocalCacheValue(buildLocalCacheValue(entity), false))
 		} else {
 			addCacheDeletes(localCacheDeletes, localCache.code, schema.getCacheKey(id))
 		}
@@ -990,11 +1050,40 @@ func updateCacheForInserted(entity Entity, lazy bool, id uint64,
 		keys := getCacheQueriesKeys(schema, bind, bind, true)
 		addCacheDeletes(redisKeysToDelete, redisCache.code, keys...)
 	}
-	addDirtyQueues(dirtyQueues, bind, schema, id, "i")
-	logQueues = addToLogQueue(logQueues, schema, id, nil, bind, entity.getORM().attributes.logMeta)
+	addDirtyQueues(dirtyQueues, bind, schema, integerKey(id), "i")
+	logQueues = addToLogQueue(logQueues, schema, integerKey(id), nil, bind, entity.getORM().attributes.logMeta)
 	return logQueues
 }
 
+func formatTime(tableSchema *tableSchema, name string, attributes map[string]string, value time.Time) string {
+	if !hasTimePart(attributes) {
+		if tableSchema.isRangePartitionColumn(name) {
+			value = value.In(tableSchema.location)
+		}
+		return value.Format("2006-01-02")
+	}
+	layout := "2006-01-02 15:04:05"
+	precision := getTimePrecision(attributes)
+	if precision > 0 {
+		layout += "." + strings.Repeat("0", precision)
+	}
+	if value.Year() == 1 {
+		return time.Time{}.Format(layout)
+	}
+	if tableSchema.hasLocation || tableSchema.isRangePartitionColumn(name) {
+		value = value.In(tableSchema.location)
+	}
+	return value.Format(layout)
+}
+
+func getCurrentTime(attributes map[string]string) time.Time {
+	accuracy := time.Second
+	for i := getTimePrecision(attributes); i > 0; i-- {
+		accuracy /= 10
+	}
+	return time.Now().Truncate(accuracy)
+}
+
 func getDirtyBind(entity Entity) (is bool, bind map[string]interface{}) {
 	orm := entity.getORM()
 	if orm.attributes.delete {
@@ -1002,8 +1091,25 @@ func getDirtyBind(entity Entity) (is bool, bind map[string]interface{}) {
 	}
 	id := orm.GetID()
 	t := orm.attributes.elem.Type()
-	bind = createBind(id, orm.tableSchema, t, orm.attributes.elem, orm.dBData, "")
+	timestamps := make(map[string]time.Time)
+	bind = createBind(id, orm.tableSchema, t, orm.attributes.elem, orm.dBData, "", timestamps)
 	is = id == 0 || len(bind) > 0
+	if orm.tableSchema.primaryKeyType != "" {
+		is = len(orm.dBData) == 0 || len(bind) > 0
+	}
+	if len(bind) > 0 && len(orm.dBData) > 0 {
+		for _, name := range orm.tableSchema.onUpdateTimestamps {
+			_, has := bind[name]
+			if has {
+				continue
+			}
+			attributes := orm.tableSchema.tags[name]
+			now := getCurrentTime(attributes)
+			timestamps[name] = now
+			bind[name] = formatTime(orm.tableSchema, name, attributes, now)
+		}
+	}
+	orm.attributes.timestamps = timestamps
 	return is, bind
 }
 
@@ -1029,6 +1135,7 @@ func (e *Engine) flushTrackedEntities(lazy bool, transaction bool, smart bool) {
 	}()
 	flush(e, lazy, transaction, smart, e.trackedEntities...)
 	if transaction {
+		e.checkFlushLock()
 		for _, db := range dbPools {
 			db.Commit()
 		}
@@ -1044,9 +1151,19 @@ func (e *Engine) flushWithLock(transaction bool, lockerPool string, lockName str
 		panic(errors.Timeoutf("lock wait"))
 	}
 	defer lock.Release()
+	e.flushLock = lock.Watchdog(ttl)
+	defer func() {
+		e.flushLock = nil
+	}()
 	e.flushTrackedEntities(false, transaction, false)
 }
 
+func (e *Engine) checkFlushLock() {
+	if e.flushLock != nil && e.flushLock.Err() != nil {
+		panic(errors.Errorf("flush lock lost"))
+	}
+}
+
 func (e *Engine) flushWithCheck(transaction bool) error {
 	var err error
 	func() {
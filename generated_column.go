@@ -0,0 +1,107 @@
+package orm
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/segmentio/fasthash/fnv1a"
+)
+
+var generatedColumnRegexp = regexp.MustCompile("^(.* GENERATED ALWAYS AS \\()(.*)(\\) (?:VIRTUAL|STORED).* COMMENT 'generated:([0-9]+)')$")
+var checkConstraintRegexp = regexp.MustCompile("^CONSTRAINT `([^`]+)` CHECK ")
+
+func buildGeneratedColumnDefinition(definition string, expression string, attributes map[string]string) string {
+	kind := "VIRTUAL"
+	if attributes["stored"] == "true" {
+		kind = "STORED"
+	}
+	return fmt.Sprintf("%s GENERATED ALWAYS AS (%s) %s COMMENT 'generated:%d'", strings.TrimSuffix(definition, " NULL"),
+		expression, kind, fnv1a.HashString32(expression))
+}
+
+func normalizeGeneratedColumn(line string, expressions map[string]string) string {
+	matches := generatedColumnRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return line
+	}
+	expression, has := expressions[matches[4]]
+	if !has {
+		return line
+	}
+	return matches[1] + expression + matches[3]
+}
+
+func (tableSchema *tableSchema) getGeneratedExpressions() map[string]string {
+	expressions := make(map[string]string)
+	for _, attributes := range tableSchema.tags {
+		expression, has := attributes["generated"]
+		if has {
+			expressions[fmt.Sprintf("%d", fnv1a.HashString32(expression))] = expression
+		}
+	}
+	return expressions
+}
+
+func (tableSchema *tableSchema) getCheckConstraints() map[string]string {
+	checks := make(map[string]string, len(tableSchema.checks))
+	for _, check := range tableSchema.checks {
+		checks[fmt.Sprintf("%s_chk_%d", tableSchema.tableName, fnv1a.HashString32(check))] = check
+	}
+	return checks
+}
+
+func getCheckConstraintsDB(createTableDB string) map[string]string {
+	checks := make(map[string]string)
+	for _, line := range strings.Split(createTableDB, "\n") {
+		line = strings.TrimRight(strings.TrimSpace(line), ",")
+		matches := checkConstraintRegexp.FindStringSubmatch(line)
+		if matches != nil {
+			checks[matches[1]] = line
+		}
+	}
+	return checks
+}
+
+func buildCheckConstraintSQL(name string, check string) string {
+	return fmt.Sprintf("CONSTRAINT `%s` CHECK (%s)", name, check)
+}
+
+func supportsCheckConstraints(pool *DB) bool {
+	return isMySQLVersionAtLeast(pool.version, 8, 0, 16) || isMariaDBVersionAtLeast(pool.version, 10, 2, 1)
+}
+
+func isMariaDB(version string) bool {
+	return strings.Contains(strings.ToLower(version), "mariadb")
+}
+
+func isMySQLVersionAtLeast(version string, major int, minor int, patch int) bool {
+	if isMariaDB(version) {
+		return false
+	}
+	return isVersionAtLeast(version, major, minor, patch)
+}
+
+func isMariaDBVersionAtLeast(version string, major int, minor int, patch int) bool {
+	if !isMariaDB(version) {
+		return false
+	}
+	// replication compatible prefix, for example 5.5.5-10.5.8-MariaDB
+	return isVersionAtLeast(strings.TrimPrefix(version, "5.5.5-"), major, minor, patch)
+}
+
+func isVersionAtLeast(version string, major int, minor int, patch int) bool {
+	parts := strings.SplitN(strings.SplitN(version, "-", 2)[0], ".", 3)
+	if len(parts) < 3 {
+		return false
+	}
+	required := []int{major, minor, patch}
+	for i, part := range parts {
+		number, _ := strconv.Atoi(part)
+		if number != required[i] {
+			return number > required[i]
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package orm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type generatedColumnEntity struct {
+	ORM   `orm:"localCache;check=Price >= 0;check=Price < 1000000 OR Price | 1 = 1"`
+	ID    uint
+	Price int
+	Data  map[string]interface{}
+	Sku   string `orm:"generated=JSON_UNQUOTE(Data->'$.sku');stored;index=Sku"`
+	Total int    `orm:"generated=Price * 2"`
+}
+
+func TestGeneratedColumnDefinition(t *testing.T) {
+	definition := buildGeneratedColumnDefinition("varchar(255)", "JSON_UNQUOTE(Data->'$.sku')", map[string]string{"stored": "true"})
+	assert.Equal(t, "varchar(255) GENERATED ALWAYS AS (JSON_UNQUOTE(Data->'$.sku')) STORED COMMENT 'generated:2383205400'", definition)
+	definition = buildGeneratedColumnDefinition("timestamp NULL", "Created", map[string]string{})
+	assert.Equal(t, "timestamp GENERATED ALWAYS AS (Created) VIRTUAL COMMENT 'generated:165548891'", definition)
+
+	expressions := map[string]string{"2383205400": "JSON_UNQUOTE(Data->'$.sku')"}
+	line := "`Sku` varchar(255) GENERATED ALWAYS AS (json_unquote(json_extract(`Data`,_utf8mb4'$.sku'))) STORED COMMENT 'generated:2383205400'"
+	assert.Equal(t, "`Sku` "+buildGeneratedColumnDefinition("varchar(255)", "JSON_UNQUOTE(Data->'$.sku')", map[string]string{"stored": "true"}),
+		normalizeGeneratedColumn(line, expressions))
+	assert.Equal(t, line, normalizeGeneratedColumn(line, map[string]string{}))
+	assert.Equal(t, "`Name` varchar(255) DEFAULT NULL", normalizeGeneratedColumn("`Name` varchar(255) DEFAULT NULL", expressions))
+
+	createTable := "CREATE TABLE `a` (\n  `ID` int(10) unsigned NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`ID`),\n" +
+		"  CONSTRAINT `a_chk_1` CHECK ((`Price` >= 0)),\n  CONSTRAINT `test:a:Ref` FOREIGN KEY (`Ref`) REFERENCES `b` (`ID`)\n) ENGINE=InnoDB"
+	assert.Equal(t, map[string]string{"a_chk_1": "CONSTRAINT `a_chk_1` CHECK ((`Price` >= 0))"}, getCheckConstraintsDB(createTable))
+
+	schema := &tableSchema{tableName: "a", checks: []string{"Price >= 0"}}
+	for name, check := range schema.getCheckConstraints() {
+		assert.Regexp(t, "^a_chk_[0-9]+$", name)
+		assert.Equal(t, "Price >= 0", check)
+	}
+
+	tags := extractTag(&Registry{}, reflect.TypeOf(generatedColumnEntity{}).Field(0))
+	assert.Equal(t, "Price >= 0;Price < 1000000 OR Price | 1 = 1", tags["ORM"]["check"])
+	assert.True(t, isMySQLVersionAtLeast("8.0.16-7", 8, 0, 16))
+	assert.True(t, isMySQLVersionAtLeast("8.0.21", 8, 0, 16))
+	assert.True(t, isMySQLVersionAtLeast("10.1.0", 8, 0, 16))
+	assert.False(t, isMySQLVersionAtLeast("8.0.15", 8, 0, 16))
+	assert.False(t, isMySQLVersionAtLeast("5.7.25-28", 8, 0, 0))
+	assert.False(t, isMySQLVersionAtLeast("", 8, 0, 0))
+	assert.False(t, isMySQLVersionAtLeast("10.5.8-MariaDB", 8, 0, 0))
+	assert.False(t, isMySQLVersionAtLeast("5.5.5-10.5.8-MariaDB-1:10.5.8+maria~focal", 8, 0, 16))
+	assert.True(t, isMariaDBVersionAtLeast("10.5.8-MariaDB", 10, 2, 1))
+	assert.True(t, isMariaDBVersionAtLeast("5.5.5-10.5.8-MariaDB-1:10.5.8+maria~focal", 10, 2, 1))
+	assert.False(t, isMariaDBVersionAtLeast("10.1.48-MariaDB", 10, 2, 1))
+	assert.False(t, isMariaDBVersionAtLeast("8.0.21", 8, 0, 0))
+	assert.True(t, supportsCheckConstraints(&DB{version: "10.5.8-MariaDB"}))
+	assert.False(t, supportsCheckConstraints(&DB{version: "5.7.25"}))
+}
+
+func TestGeneratedColumns(t *testing.T) {
+	var entity *generatedColumnEntity
+	engine := PrepareTables(t, &Registry{}, entity)
+	alters := engine.GetAlters()
+	assert.Len(t, alters, 0)
+
+	entity = &generatedColumnEntity{Price: 10, Data: map[string]interface{}{"sku": "abc"}}
+	engine.TrackAndFlush(entity)
+	assert.Equal(t, "", entity.Sku)
+
+	loaded := &generatedColumnEntity{}
+	assert.True(t, engine.LoadByID(1, loaded))
+	assert.Equal(t, "abc", loaded.Sku)
+	assert.Equal(t, 20, loaded.Total)
+
+	engine.Track(loaded)
+	assert.False(t, engine.IsDirty(loaded))
+	loaded.Total = 100
+	assert.False(t, engine.IsDirty(loaded))
+	loaded.Price = 15
+	engine.Flush()
+
+	loaded = &generatedColumnEntity{}
+	assert.True(t, engine.LoadByID(1, loaded))
+	assert.Equal(t, 30, loaded.Total)
+
+	var found []*generatedColumnEntity
+	engine.Search(NewWhere("`Sku` = ?", "abc"), nil, &found)
+	assert.Len(t, found, 1)
+}
@@ -115,6 +115,7 @@ func (r *LazyReceiver) handleClearCache(validMap map[string]interface{}, key str
 			if key == "cl" {
 				cache := r.engine.localCache[cacheCode]
 				cache.Remove(stringKeys...)
+				publishLocalCacheInvalidation(r.engine, map[string][]string{cacheCode: stringKeys})
 			} else {
 				cache := r.engine.redis[cacheCode]
 				cache.Del(stringKeys...)
@@ -1,12 +1,19 @@
 package orm
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"time"
+
+	"github.com/juju/errors"
 )
 
-func loadByID(engine *Engine, id uint64, entity Entity, useCache bool, references ...string) (found bool) {
+const recomputeLockTTL = 5 * time.Second
+const recomputeStaleTTL = 60
+
+func loadByID(engine *Engine, id interface{}, entity Entity, useCache bool, references ...string) (found bool) {
 	orm := initIfNeeded(engine, entity)
 	schema := orm.tableSchema
 	var cacheKey string
@@ -34,30 +41,94 @@ func loadByID(engine *Engine, id uint64, entity Entity, useCache bool, reference
 			if row == "nil" {
 				return false
 			}
-			var decoded []string
-			_ = json.Unmarshal([]byte(row), &decoded)
-			fillFromDBRow(id, engine, decoded, entity)
+			fillFromDBRow(id, engine, decodeRedisValue(schema, row), entity)
 			if len(references) > 0 {
 				warmUpReferences(engine, schema, orm.attributes.elem, references, false)
 			}
 			return true
 		}
 	}
+	if useCache && (hasLocalCache || hasRedis) && !schema.GetMysql(engine).inTransaction {
+		fromCache := false
+		row, shared := engine.registry.singleFlight.Do("load:"+cacheKey, func() interface{} {
+			locker, hasLocker := schema.getRecomputeLocker(engine)
+			if hasLocker {
+				stale, hasStale := redisCache.Get(getStaleCacheKey(cacheKey))
+				wait := recomputeLockTTL
+				if hasStale {
+					wait = time.Millisecond
+				}
+				lock, obtained := locker.Obtain("recompute:"+cacheKey, recomputeLockTTL, wait)
+				if !obtained && hasStale {
+					fromCache = true
+					if stale == "nil" {
+						return nil
+					}
+					return decodeRedisValue(schema, stale)
+				}
+				if obtained {
+					defer lock.Release()
+				}
+				row, has := redisCache.Get(cacheKey)
+				if has {
+					fromCache = true
+					if row == "nil" {
+						if hasLocalCache {
+							localCache.Set(cacheKey, schema.getLocalCacheValue("nil", true))
+						}
+						return nil
+					}
+					decoded := decodeRedisValue(schema, row)
+					if hasLocalCache {
+						localCache.Set(cacheKey, schema.getLocalCacheValue(decoded, false))
+					}
+					return decoded
+				}
+			}
+			if !searchRow(false, engine, NewWhere("`ID` = ?", id), entity, nil) {
+				if hasLocalCache {
+					localCache.Set(cacheKey, schema.getLocalCacheValue("nil", true))
+				}
+				if hasRedis {
+					setRedisCacheValue(schema, redisCache, cacheKey, "nil", true, hasLocker)
+				}
+				return nil
+			}
+			value := buildLocalCacheValue(entity)
+			if hasLocalCache {
+				localCache.Set(cacheKey, schema.getLocalCacheValue(value, false))
+			}
+			if hasRedis {
+				setRedisCacheValue(schema, redisCache, cacheKey, buildRedisValue(entity), false, hasLocker)
+			}
+			return value
+		})
+		if row == nil {
+			return false
+		}
+		if shared || fromCache {
+			fillFromDBRow(id, engine, row.([]string), entity)
+		}
+		if len(references) > 0 {
+			warmUpReferences(engine, schema, orm.attributes.elem, references, false)
+		}
+		return true
+	}
 	found = searchRow(false, engine, NewWhere("`ID` = ?", id), entity, nil)
 	if !found {
 		if localCache != nil {
-			localCache.Set(cacheKey, "nil")
+			localCache.Set(cacheKey, schema.getLocalCacheValue("nil", true))
 		}
 		if redisCache != nil {
-			redisCache.Set(cacheKey, "nil", 60)
+			redisCache.Set(cacheKey, "nil", schema.getRedisCacheTTL(true))
 		}
 		return false
 	}
 	if localCache != nil && useCache {
-		localCache.Set(cacheKey, buildLocalCacheValue(entity))
+		localCache.Set(cacheKey, schema.getLocalCacheValue(buildLocalCacheValue(entity), false))
 	}
 	if redisCache != nil && useCache {
-		redisCache.Set(cacheKey, buildRedisValue(entity), 0)
+		redisCache.Set(cacheKey, buildRedisValue(entity), schema.getRedisCacheTTL(false))
 	}
 	if len(references) > 0 {
 		warmUpReferences(engine, schema, orm.attributes.elem, references, false)
@@ -65,11 +136,59 @@ func loadByID(engine *Engine, id uint64, entity Entity, useCache bool, reference
 	return true
 }
 
+func loadByIDWithLock(engine *Engine, id interface{}, entity Entity, lock string, references ...string) (found bool) {
+	orm := initIfNeeded(engine, entity)
+	if !orm.tableSchema.GetMysql(engine).inTransaction {
+		panic(errors.Errorf("loading entity with lock is allowed only in transaction"))
+	}
+	return searchRowWithLock(false, engine, NewWhere("`ID` = ?", id), entity, references, lock)
+}
+
+func setRedisCacheValue(schema *tableSchema, redisCache *RedisCache, cacheKey string, value string, isNil bool, withStale bool) {
+	if !withStale {
+		redisCache.Set(cacheKey, value, schema.getRedisCacheTTL(isNil))
+		return
+	}
+	redisCache.Pipeline(func(p *RedisPipeline) {
+		p.Set(cacheKey, value, schema.getRedisCacheTTL(isNil))
+		p.Set(getStaleCacheKey(cacheKey), value, schema.getRedisCacheStaleTTL(isNil))
+	})
+}
+
+func getStaleCacheKey(cacheKey string) string {
+	return cacheKey + ":stale"
+}
+
 func buildRedisValue(entity Entity) string {
-	encoded, _ := json.Marshal(buildLocalCacheValue(entity))
+	value := buildLocalCacheValue(entity)
+	schema := entity.getORM().tableSchema
+	if len(schema.columnTypes) > 0 {
+		for i, column := range schema.columnNames[1:] {
+			_, isCustom := schema.columnTypes[column]
+			if isCustom && value[i] != "nil" {
+				value[i] = base64.StdEncoding.EncodeToString([]byte(value[i]))
+			}
+		}
+	}
+	encoded, _ := json.Marshal(value)
 	return string(encoded)
 }
 
+func decodeRedisValue(schema *tableSchema, row string) []string {
+	var decoded []string
+	_ = json.Unmarshal([]byte(row), &decoded)
+	if len(schema.columnTypes) > 0 {
+		for i, column := range schema.columnNames[1:] {
+			_, isCustom := schema.columnTypes[column]
+			if isCustom && i < len(decoded) && decoded[i] != "nil" {
+				raw, _ := base64.StdEncoding.DecodeString(decoded[i])
+				decoded[i] = string(raw)
+			}
+		}
+	}
+	return decoded
+}
+
 func buildLocalCacheValue(entity Entity) []string {
 	bind := entity.getORM().dBData
 	columns := entity.getORM().tableSchema.columnNames
@@ -100,7 +219,7 @@ func initIfNeeded(engine *Engine, entity Entity) *ORM {
 		orm.engine = engine
 		orm.tableSchema = tableSchema
 		orm.dBData = make(map[string]interface{}, len(tableSchema.columnNames))
-		orm.attributes = &entityAttributes{nil, false, false, value, elem, elem.Field(1), nil}
+		orm.attributes = &entityAttributes{nil, false, false, value, elem, elem.Field(1), nil, nil}
 	}
 	return orm
 }
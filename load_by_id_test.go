@@ -2,6 +2,7 @@ package orm
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -19,6 +20,18 @@ type loadByIDRedisEntity struct {
 	ID  uint
 }
 
+type loadByIDTTLEntity struct {
+	ORM  `orm:"localCache=default,ttl=1;redisCache=default,ttl=1;nilTTL=1"`
+	ID   uint
+	Name string
+}
+
+type loadByIDRecomputeEntity struct {
+	ORM  `orm:"redisCache;recomputeLock"`
+	ID   uint
+	Name string
+}
+
 type loadByIDNoCacheEntity struct {
 	ORM
 	ID   uint
@@ -107,3 +120,138 @@ func TestLoadById(t *testing.T) {
 		engine.LoadByID(1, entity)
 	})
 }
+
+func TestLoadByIdTTL(t *testing.T) {
+	var entity *loadByIDTTLEntity
+	engine := PrepareTables(t, &Registry{}, entity)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	assert.Equal(t, 1, schema.localCacheTTL)
+	assert.Equal(t, 1, schema.redisCacheTTL)
+	assert.Equal(t, 1, schema.redisCacheNilTTL)
+
+	entity = &loadByIDTTLEntity{}
+	assert.False(t, engine.LoadByID(1, entity))
+	engine.GetMysql().Exec("INSERT INTO `loadByIDTTLEntity` (`ID`, `Name`) VALUES (1, 'a')")
+	assert.False(t, engine.LoadByID(1, entity))
+
+	time.Sleep(time.Millisecond * 1100)
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "a", entity.Name)
+
+	engine.GetMysql().Exec("UPDATE `loadByIDTTLEntity` SET `Name` = 'b' WHERE `ID` = 1")
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "a", entity.Name)
+	time.Sleep(time.Millisecond * 1100)
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "b", entity.Name)
+}
+
+func TestLoadByIdWithLock(t *testing.T) {
+	var entity *loadByIDEntity
+	registry := &Registry{}
+	registry.RegisterLocker("default", "default")
+	engine := PrepareTables(t, registry, entity)
+
+	engine.TrackAndFlush(&loadByIDEntity{Name: "a"})
+
+	entity = &loadByIDEntity{}
+	assert.PanicsWithError(t, "loading entity with lock is allowed only in transaction", func() {
+		engine.LoadByIDForUpdate(1, entity)
+	})
+
+	db := engine.GetMysql()
+	db.Begin()
+	assert.True(t, engine.LoadByIDForUpdate(1, entity))
+	assert.Equal(t, "a", entity.Name)
+	assert.False(t, engine.LoadByIDForUpdate(2, &loadByIDEntity{}))
+	entity.Name = "b"
+	engine.TrackAndFlush(entity)
+	db.Commit()
+
+	db.Begin()
+	entity = &loadByIDEntity{}
+	assert.True(t, engine.LoadByIDLockInShareMode(1, entity))
+	assert.Equal(t, "b", entity.Name)
+	db.Rollback()
+
+	lock, has := engine.LockEntity(entity, time.Second, 0)
+	assert.True(t, has)
+	_, has = engine.LockEntity(entity, time.Second, 0)
+	assert.False(t, has)
+	lock.Release()
+	lock, has = engine.LockEntity(entity, time.Second, 0)
+	assert.True(t, has)
+	lock.Release()
+
+	assert.PanicsWithError(t, "entity 'orm.loadByIDEntity' without ID can't be locked", func() {
+		engine.LockEntity(&loadByIDEntity{}, time.Second, 0)
+	})
+}
+
+func TestLoadByIDRecomputeLock(t *testing.T) {
+	var entity *loadByIDRecomputeEntity
+	registry := &Registry{}
+	registry.RegisterLocker("default", "default")
+	engine := PrepareTables(t, registry, entity)
+	engine.GetMysql().Exec("INSERT INTO `loadByIDRecomputeEntity` (`ID`, `Name`) VALUES (1, 'a'), (2, 'b')")
+
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	lock, has := engine.GetLocker().Obtain("recompute:"+schema.getCacheKey(1), time.Second, 0)
+	assert.True(t, has)
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		engine.GetRedis().Set(schema.getCacheKey(1), "nil", 10)
+		lock.Release()
+	}()
+	assert.False(t, engine.LoadByID(1, &loadByIDRecomputeEntity{}))
+
+	lock, has = engine.GetLocker().Obtain("recompute:"+schema.getCacheKey(2), time.Second, 0)
+	assert.True(t, has)
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		engine.GetRedis().Set(schema.getCacheKey(2), "nil", 10)
+		lock.Release()
+	}()
+	var rows []*loadByIDRecomputeEntity
+	missing := engine.LoadByIDs([]uint64{2, 3}, &rows)
+	assert.Equal(t, []uint64{2, 3}, missing)
+
+	engine.GetRedis().FlushDB()
+	entity = &loadByIDRecomputeEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "a", entity.Name)
+	_, has = engine.GetRedis().Get("recompute:" + schema.getCacheKey(1))
+	assert.False(t, has)
+	_, has = engine.GetRedis().Get(getStaleCacheKey(schema.getCacheKey(1)))
+	assert.True(t, has)
+	rows = nil
+	assert.Len(t, engine.LoadByIDs([]uint64{1, 2}, &rows), 0)
+
+	engine.GetMysql().Exec("UPDATE `loadByIDRecomputeEntity` SET `Name` = 'c'")
+	engine.GetRedis().Del(schema.getCacheKey(1), schema.getCacheKey(2))
+	lock, has = engine.GetLocker().Obtain("recompute:"+schema.getCacheKey(1), time.Second, 0)
+	assert.True(t, has)
+	entity = &loadByIDRecomputeEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "a", entity.Name)
+	rows = nil
+	assert.Len(t, engine.LoadByIDs([]uint64{1, 2}, &rows), 0)
+	assert.Equal(t, "a", rows[0].Name)
+	assert.Equal(t, "b", rows[1].Name)
+	_, has = engine.GetRedis().Get(schema.getCacheKey(1))
+	assert.False(t, has)
+	lock.Release()
+	entity = &loadByIDRecomputeEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "c", entity.Name)
+	rows = nil
+	assert.Len(t, engine.LoadByIDs([]uint64{1, 2}, &rows), 0)
+	assert.Equal(t, "c", rows[1].Name)
+
+	registry = &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterEntity(&loadByIDRecomputeEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "locker pool 'default' not found")
+}
@@ -1,10 +1,11 @@
 package orm
 
 import (
-	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 )
@@ -22,6 +23,7 @@ func tryByIDs(engine *Engine, ids []uint64, entities reflect.Value, references [
 	}
 
 	schema := getTableSchema(engine.registry, t)
+	schema.checkIntegerPrimaryKey()
 	localCache, hasLocalCache := schema.GetLocalCache(engine)
 	redisCache, hasRedis := schema.GetRedisCache(engine)
 	var localCacheKeys []string
@@ -55,14 +57,84 @@ func tryByIDs(engine *Engine, ids []uint64, entities reflect.Value, references [
 		}
 	}
 	l := len(ids)
+	shared := false
+	stale := false
+	locker, hasLocker := schema.getRecomputeLocker(engine)
 	if l > 0 {
-		_ = search(false, engine, NewWhere("`ID` IN ?", ids), NewPager(1, l), false, entities)
-		for i := 0; i < entities.Len(); i++ {
-			e := entities.Index(i).Interface().(Entity)
-			results[schema.getCacheKey(e.GetID())] = e
+		loadRows := func() interface{} {
+			dbIDs := ids
+			if hasLocker {
+				lockKeys := make([]string, len(cacheKeys))
+				staleKeys := make([]string, len(cacheKeys))
+				for i, key := range cacheKeys {
+					lockKeys[i] = "recompute:" + key
+					staleKeys[i] = getStaleCacheKey(key)
+				}
+				staleRows := redisCache.MGet(staleKeys...)
+				hasStale := true
+				for _, row := range staleRows {
+					if row == nil {
+						hasStale = false
+						break
+					}
+				}
+				wait := recomputeLockTTL
+				if hasStale {
+					wait = time.Millisecond
+				}
+				lock, obtained := locker.ObtainMany(lockKeys, recomputeLockTTL, wait)
+				if obtained {
+					defer lock.Release()
+				}
+				rows := redisCache.MGet(cacheKeys...)
+				if !obtained && hasStale {
+					stale = true
+					for i, key := range cacheKeys {
+						if rows[key] == nil {
+							rows[key] = staleRows[staleKeys[i]]
+						}
+					}
+				}
+				missingKeys := getKeysForNils(engine, schema.t, rows, keysMapping, results, true)
+				dbIDs = make([]uint64, len(missingKeys))
+				for k, v := range missingKeys {
+					dbIDs[k] = keysMapping[v]
+				}
+			}
+			if len(dbIDs) > 0 {
+				_ = search(false, engine, NewWhere("`ID` IN ?", dbIDs), NewPager(1, len(dbIDs)), false, entities)
+				for i := 0; i < entities.Len(); i++ {
+					e := entities.Index(i).Interface().(Entity)
+					results[schema.getCacheKey(e.GetID())] = e
+				}
+			}
+			rows := make(map[uint64][]string, len(ids))
+			for _, id := range ids {
+				e := results[keysReversed[id]]
+				if e != nil {
+					rows[id] = buildLocalCacheValue(e)
+				}
+			}
+			return rows
+		}
+		if (hasLocalCache || hasRedis) && !schema.GetMysql(engine).inTransaction {
+			flightKeys := make([]string, len(cacheKeys))
+			copy(flightKeys, cacheKeys)
+			sort.Strings(flightKeys)
+			var rows interface{}
+			rows, shared = engine.registry.singleFlight.Do("ids:"+strings.Join(flightKeys, ","), loadRows)
+			if shared {
+				for id, row := range rows.(map[uint64][]string) {
+					e := reflect.New(t).Interface().(Entity)
+					fillFromDBRow(id, engine, row, e)
+					results[schema.getCacheKey(id)] = e
+				}
+			}
+		} else {
+			loadRows()
 		}
 	}
-	if hasLocalCache {
+	if hasLocalCache && !shared && !stale {
 		l = len(localCacheKeys)
 		if l > 0 {
 			pairs := make([]interface{}, l*2)
@@ -70,37 +142,33 @@ func tryByIDs(engine *Engine, ids []uint64, entities reflect.Value, references [
 			for _, key := range localCacheKeys {
 				pairs[i] = key
 				val := results[key]
-				var toSet interface{}
 				if val == nil {
-					toSet = "nil"
+					pairs[i+1] = schema.getLocalCacheValue("nil", true)
 				} else {
-					toSet = buildLocalCacheValue(val)
+					pairs[i+1] = schema.getLocalCacheValue(buildLocalCacheValue(val), false)
 				}
-				pairs[i+1] = toSet
 				i += 2
 			}
 			localCache.MSet(pairs...)
 		}
 	}
 
-	if hasRedis {
+	if hasRedis && !shared && !stale {
 		l = len(redisCacheKeys)
 		if l > 0 {
-			pairs := make([]interface{}, l*2)
-			i := 0
-			for _, key := range redisCacheKeys {
-				pairs[i] = key
-				val := results[key]
-				var toSet interface{}
-				if val == nil {
-					toSet = "nil"
-				} else {
-					toSet = buildRedisValue(val)
+			redisCache.Pipeline(func(p *RedisPipeline) {
+				for _, key := range redisCacheKeys {
+					val := results[key]
+					value := "nil"
+					if val != nil {
+						value = buildRedisValue(val)
+					}
+					p.Set(key, value, schema.getRedisCacheTTL(val == nil))
+					if hasLocker {
+						p.Set(getStaleCacheKey(key), value, schema.getRedisCacheStaleTTL(val == nil))
+					}
 				}
-				pairs[i+1] = toSet
-				i += 2
-			}
-			redisCache.MSet(pairs...)
+			})
 		}
 	}
 
@@ -135,9 +203,7 @@ func getKeysForNils(engine *Engine, entityType reflect.Type, rows map[string]int
 				results[k] = nil
 			} else if fromRedis {
 				entity := reflect.New(entityType).Interface().(Entity)
-				var decoded []string
-				_ = json.Unmarshal([]byte(v.(string)), &decoded)
-				fillFromDBRow(keysMapping[k], engine, decoded, entity)
+				fillFromDBRow(keysMapping[k], engine, decodeRedisValue(getTableSchema(engine.registry, entityType), v.(string)), entity)
 				results[k] = entity
 			} else {
 				entity := reflect.New(entityType).Interface().(Entity)
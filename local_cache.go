@@ -22,6 +22,11 @@ type LocalCache struct {
 	m      *sync.Mutex
 }
 
+type localCacheExpiringValue struct {
+	value  interface{}
+	expire int64
+}
+
 type ttlValue struct {
 	value interface{}
 	time  int64
@@ -35,9 +40,12 @@ func (c *LocalCache) GetSet(key string, ttlSeconds int, provider GetSetProvider)
 			return ttlVal.value
 		}
 	}
-	userVal := provider()
-	val = ttlValue{value: userVal, time: time.Now().Unix()}
-	c.Set(key, val)
+	userVal, shared := c.engine.registry.singleFlight.Do("local:"+c.code+":"+key, func() interface{} {
+		return provider()
+	})
+	if !shared {
+		c.Set(key, ttlValue{value: userVal, time: time.Now().Unix()})
+	}
 	return userVal
 }
 
@@ -46,7 +54,7 @@ func (c *LocalCache) Get(key string) (value interface{}, ok bool) {
 	defer c.m.Unlock()
 
 	start := time.Now()
-	value, ok = c.lru.Get(key)
+	value, ok = c.get(key)
 	misses := 0
 	if !ok {
 		misses = 1
@@ -65,7 +73,7 @@ func (c *LocalCache) MGet(keys ...string) map[string]interface{} {
 	results := make(map[string]interface{}, len(keys))
 	misses := 0
 	for _, key := range keys {
-		value, ok := c.lru.Get(key)
+		value, ok := c.get(key)
 		if !ok {
 			misses++
 			value = nil
@@ -108,7 +116,7 @@ func (c *LocalCache) HMget(key string, fields ...string) map[string]interface{}
 	start := time.Now()
 	l := len(fields)
 	results := make(map[string]interface{}, l)
-	value, ok := c.lru.Get(key)
+	value, ok := c.get(key)
 	misses := 0
 	for _, field := range fields {
 		if !ok {
@@ -131,14 +139,22 @@ func (c *LocalCache) HMget(key string, fields ...string) map[string]interface{}
 }
 
 func (c *LocalCache) HMset(key string, fields map[string]interface{}) {
+	c.hmset(key, fields, 0)
+}
+
+func (c *LocalCache) hmset(key string, fields map[string]interface{}, ttlSeconds int) {
 	c.m.Lock()
 	defer c.m.Unlock()
 
 	start := time.Now()
-	m, has := c.lru.Get(key)
+	m, has := c.get(key)
 	if !has {
 		m = make(map[string]interface{})
-		c.lru.Add(key, m)
+		if ttlSeconds > 0 {
+			c.lru.Add(key, localCacheExpiringValue{value: m, expire: time.Now().Unix() + int64(ttlSeconds)})
+		} else {
+			c.lru.Add(key, m)
+		}
 	}
 	for k, v := range fields {
 		m.(map[string]interface{})[k] = v
@@ -177,6 +193,22 @@ func (c *LocalCache) Clear() {
 	}
 }
 
+func (c *LocalCache) get(key string) (value interface{}, ok bool) {
+	value, ok = c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	expiring, is := value.(localCacheExpiringValue)
+	if !is {
+		return value, true
+	}
+	if time.Now().Unix() >= expiring.expire {
+		c.lru.Remove(key)
+		return nil, false
+	}
+	return expiring.value, true
+}
+
 func (c *LocalCache) fillLogFields(message string, start time.Time, operation string, misses int, fields map[string]interface{}) {
 	stop := time.Since(start).Microseconds()
 	e := c.engine.queryLoggers[QueryLoggerSourceLocalCache].log.
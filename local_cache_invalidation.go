@@ -0,0 +1,153 @@
+package orm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/juju/errors"
+)
+
+const localCacheInvalidationChannel = "orm_local_cache_invalidation"
+const localCacheInvalidationInstanceKey = "orm_local_cache_instance:"
+const localCacheInvalidationInstanceTTL = 86400
+
+type localCacheInvalidationMessage struct {
+	Source string              `json:"s"`
+	Keys   map[string][]string `json:"k"`
+}
+
+type LocalCacheInvalidationReceiver struct {
+	engine          *Engine
+	disableLoop     bool
+	heartBeat       func()
+	maxLoopDuration time.Duration
+}
+
+func NewLocalCacheInvalidationReceiver(engine *Engine) *LocalCacheInvalidationReceiver {
+	return &LocalCacheInvalidationReceiver{engine: engine}
+}
+
+func (r *LocalCacheInvalidationReceiver) DisableLoop() {
+	r.disableLoop = true
+}
+
+func (r *LocalCacheInvalidationReceiver) SetHeartBeat(beat func()) {
+	r.heartBeat = beat
+}
+
+func (r *LocalCacheInvalidationReceiver) SetMaxLoopDuration(duration time.Duration) {
+	r.maxLoopDuration = duration
+}
+
+func (r *LocalCacheInvalidationReceiver) Digest() {
+	pool := r.engine.registry.localCacheInvalidationPool
+	if pool == "" {
+		panic(errors.Errorf("local cache invalidation is not registered"))
+	}
+	pubSub := r.engine.GetRedis(pool).Subscribe(localCacheInvalidationChannel)
+	defer pubSub.Close()
+	if r.disableLoop {
+		pubSub.DisableLoop()
+	}
+	if r.heartBeat != nil {
+		pubSub.SetHeartBeat(r.heartBeat)
+	}
+	if r.maxLoopDuration > 0 {
+		pubSub.SetMaxLoopDuration(r.maxLoopDuration)
+	} else {
+		pubSub.SetMaxLoopDuration(time.Second)
+	}
+	pubSub.Consume(100, func(items []*redis.Message) {
+		for _, item := range items {
+			var message localCacheInvalidationMessage
+			err := jsoniter.ConfigFastest.UnmarshalFromString(item.Payload, &message)
+			if err != nil || message.Source == r.engine.registry.instanceID {
+				continue
+			}
+			for cacheCode, keys := range message.Keys {
+				cache, has := r.engine.localCache[cacheCode]
+				if has {
+					cache.Remove(keys...)
+				}
+			}
+		}
+	})
+}
+
+func publishLocalCacheInvalidation(engine *Engine, keys map[string][]string) {
+	pool := engine.registry.localCacheInvalidationPool
+	if pool == "" || len(keys) == 0 {
+		return
+	}
+	message := localCacheInvalidationMessage{Source: engine.registry.instanceID, Keys: keys}
+	asString, err := jsoniter.ConfigFastest.MarshalToString(message)
+	checkError(err)
+	engine.GetRedis(pool).Publish(localCacheInvalidationChannel, asString)
+}
+
+func addLocalCacheInvalidation(engine *Engine, isInTransaction bool, keys map[string][]string) {
+	if engine.registry.localCacheInvalidationPool == "" || len(keys) == 0 {
+		return
+	}
+	if !isInTransaction {
+		publishLocalCacheInvalidation(engine, keys)
+		return
+	}
+	if engine.afterCommitLocalCacheInvalidations == nil {
+		engine.afterCommitLocalCacheInvalidations = make(map[string][]string)
+	}
+	for cacheCode, values := range keys {
+		engine.afterCommitLocalCacheInvalidations[cacheCode] = append(engine.afterCommitLocalCacheInvalidations[cacheCode], values...)
+	}
+}
+
+func registerLocalCacheInvalidationInstance(client redis.UniversalClient) (string, error) {
+	for {
+		id := generateRandomID()
+		added, err := client.SetNX(context.Background(), localCacheInvalidationInstanceKey+id, 1,
+			localCacheInvalidationInstanceTTL*time.Second).Result()
+		if err != nil {
+			return "", err
+		}
+		if added {
+			return id, nil
+		}
+	}
+}
+
+func (r *validatedRegistry) startLocalCacheInvalidation() {
+	engine := r.createEngine()
+	redisCache := engine.GetRedis(r.localCacheInvalidationPool)
+	receiver := NewLocalCacheInvalidationReceiver(engine)
+	receiver.SetHeartBeat(func() {
+		redisCache.Expire(localCacheInvalidationInstanceKey+r.instanceID, localCacheInvalidationInstanceTTL)
+	})
+	for {
+		func() {
+			defer func() {
+				if rec := recover(); rec != nil {
+					err, is := rec.(error)
+					if !is {
+						err = fmt.Errorf("%v", rec)
+					}
+					engine.Log().Error(err, nil)
+					engine.DataDog().RegisterAPMError(err)
+				}
+			}()
+			receiver.Digest()
+		}()
+		time.Sleep(time.Second)
+	}
+}
+
+func generateRandomID() string {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	checkError(err)
+	return hex.EncodeToString(id)
+}
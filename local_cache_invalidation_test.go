@@ -0,0 +1,115 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type localCacheInvalidationEntity struct {
+	ORM  `orm:"localCache"`
+	ID   uint
+	Name string
+}
+
+func TestLocalCacheInvalidation(t *testing.T) {
+	var entity *localCacheInvalidationEntity
+	registry := &Registry{}
+	registry.RegisterLocalCacheInvalidation()
+	engine := PrepareTables(t, registry, entity)
+
+	registryOther := &Registry{}
+	registryOther.RegisterLocalCacheInvalidation()
+	engineOther := PrepareTables(t, registryOther, entity)
+
+	e := &localCacheInvalidationEntity{Name: "John"}
+	engine.TrackAndFlush(e)
+	e2 := &localCacheInvalidationEntity{Name: "Tom"}
+	engine.TrackAndFlush(e2)
+
+	loaded := &localCacheInvalidationEntity{}
+	assert.True(t, engineOther.LoadByID(1, loaded))
+	assert.True(t, engineOther.LoadByID(2, loaded))
+	schema := engineOther.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	localCache, _ := schema.GetLocalCache(engineOther)
+	_, has := localCache.Get(schema.getCacheKey(1))
+	assert.True(t, has)
+
+	digest := func(action func()) {
+		done := make(chan bool)
+		receiver := NewLocalCacheInvalidationReceiver(engineOther)
+		receiver.DisableLoop()
+		receiver.SetMaxLoopDuration(time.Millisecond * 100)
+		go func() {
+			receiver.Digest()
+			done <- true
+		}()
+		time.Sleep(time.Millisecond * 100)
+		action()
+		<-done
+	}
+
+	digest(func() {
+		e.Name = "John 2"
+		engine.TrackAndFlush(e)
+	})
+	_, has = localCache.Get(schema.getCacheKey(1))
+	assert.False(t, has)
+	_, has = localCache.Get(schema.getCacheKey(2))
+	assert.True(t, has)
+	assert.True(t, engineOther.LoadByID(1, loaded))
+	assert.Equal(t, "John 2", loaded.Name)
+
+	digest(func() {
+		engine.ClearByIDs(entity, 2)
+	})
+	_, has = localCache.Get(schema.getCacheKey(2))
+	assert.False(t, has)
+
+	assert.True(t, engineOther.LoadByID(1, loaded))
+	digest(func() {
+		engine.GetMysql().Begin()
+		e.Name = "John 3"
+		engine.TrackAndFlush(e)
+		_, has = localCache.Get(schema.getCacheKey(1))
+		assert.True(t, has)
+		engine.GetMysql().Commit()
+	})
+	_, has = localCache.Get(schema.getCacheKey(1))
+	assert.False(t, has)
+
+	assert.True(t, engineOther.LoadByID(2, loaded))
+	digest(func() {
+		engine.GetMysql().Begin()
+		engine.ClearByIDs(entity, 2)
+		assert.Len(t, engine.afterCommitLocalCacheInvalidations["default"], 1)
+		_, has = localCache.Get(schema.getCacheKey(2))
+		assert.True(t, has)
+		engine.GetMysql().Commit()
+	})
+	_, has = localCache.Get(schema.getCacheKey(2))
+	assert.False(t, has)
+
+	instanceID := engine.GetRegistry().(*validatedRegistry).instanceID
+	registry = &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterLocalCacheInvalidation()
+	validated, err := registry.Validate()
+	assert.NoError(t, err)
+	instanceIDOther := validated.(*validatedRegistry).instanceID
+	assert.NotEqual(t, instanceID, instanceIDOther)
+	assert.Equal(t, int64(1), validated.CreateEngine().GetRedis().Exists(localCacheInvalidationInstanceKey+instanceIDOther))
+
+	registry = &Registry{}
+	registry.RegisterLocalCacheInvalidation("invalid")
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "redis pool 'invalid' for local cache invalidation not found")
+
+	registry = &Registry{}
+	validated, err = registry.Validate()
+	assert.NoError(t, err)
+	assert.PanicsWithError(t, "local cache invalidation is not registered", func() {
+		NewLocalCacheInvalidationReceiver(validated.CreateEngine()).Digest()
+	})
+}
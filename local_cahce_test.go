@@ -2,6 +2,7 @@ package orm
 
 import (
 	"testing"
+	"time"
 
 	apexLog "github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
@@ -34,6 +35,18 @@ func TestLocalCache(t *testing.T) {
 	assert.False(t, has)
 	assert.Nil(t, val)
 
+	c.Set("test_expired", localCacheExpiringValue{value: "hello", expire: time.Now().Unix() - 1})
+	val, has = c.Get("test_expired")
+	assert.False(t, has)
+	assert.Nil(t, val)
+	c.Set("test_not_expired", localCacheExpiringValue{value: "hello", expire: time.Now().Unix() + 10})
+	val, has = c.Get("test_not_expired")
+	assert.True(t, has)
+	assert.Equal(t, "hello", val)
+	c.hmset("test_hm_expired", map[string]interface{}{"a": "b"}, 10)
+	assert.Equal(t, map[string]interface{}{"a": "b"}, c.HMget("test_hm_expired", "a"))
+	c.Remove("test_not_expired", "test_hm_expired")
+
 	c.Set("test_get", "hello")
 	val, has = c.Get("test_get")
 	assert.True(t, has)
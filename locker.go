@@ -2,27 +2,79 @@ package orm
 
 import (
 	"context"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/juju/errors"
 
 	"github.com/bsm/redislock"
+	"github.com/go-redis/redis/v8"
 )
 
 const counterRedisLockObtain = "redis.lockObtain"
 const counterRedisLockRelease = "redis.lockRelease"
 const counterRedisLockTTL = "redis.lockTTL"
+const counterRedisLockRefresh = "redis.lockRefresh"
+
+const lockFencingTTL = 24 * time.Hour
+
+var lockObtainScript = redis.NewScript(`redis.replicate_commands()
+if redis.call("set", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	if redis.call("exists", KEYS[2]) == 0 then
+		local now = redis.call("time")
+		redis.call("set", KEYS[2], now[1] .. string.format("%06d", tonumber(now[2])))
+	end
+	local token = redis.call("incr", KEYS[2])
+	redis.call("pexpire", KEYS[2], ARGV[3])
+	return token
+end
+return 0`)
 
 type lockerClient interface {
-	Obtain(key string, ttl time.Duration, opt *redislock.Options) (*redislock.Lock, error)
+	Obtain(key string, ttl time.Duration, opt *redislock.Options) (*redislock.Lock, uint64, error)
+	RunScript(script *redis.Script, keys []string, args ...interface{}) (interface{}, error)
 }
 
 type standardLockerClient struct {
-	client *redislock.Client
+	redis redis.UniversalClient
 }
 
-func (l *standardLockerClient) Obtain(key string, ttl time.Duration, opt *redislock.Options) (*redislock.Lock, error) {
-	return l.client.Obtain(context.Background(), key, ttl, opt)
+type fencingRedisClient struct {
+	redis.UniversalClient
+	token uint64
+}
+
+func (c *fencingRedisClient) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	fencingTTL := lockFencingTTL
+	if expiration > fencingTTL {
+		fencingTTL = expiration
+	}
+	res, err := lockObtainScript.Run(ctx, c.UniversalClient, []string{key, getFencingKey(key)}, value, expiration.Milliseconds(),
+		fencingTTL.Milliseconds()).Int64()
+	if err != nil || res == 0 {
+		return redis.NewBoolResult(false, err)
+	}
+	c.token = uint64(res)
+	return redis.NewBoolResult(true, nil)
+}
+
+func (l *standardLockerClient) Obtain(key string, ttl time.Duration, opt *redislock.Options) (*redislock.Lock, uint64, error) {
+	client := &fencingRedisClient{UniversalClient: l.redis}
+	lock, err := redislock.New(client).Obtain(context.Background(), key, ttl, opt)
+	return lock, client.token, err
+}
+
+func getFencingKey(key string) string {
+	if strings.Contains(key, "{") {
+		return key + ":fencing"
+	}
+	return "{" + key + "}:fencing"
+}
+
+func (l *standardLockerClient) RunScript(script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(context.Background(), l.redis, keys, args...).Result()
 }
 
 type Locker struct {
@@ -43,7 +95,7 @@ func (l *Locker) Obtain(key string, ttl time.Duration, waitTimeout time.Duration
 	max := int(waitTimeout / maxInterval)
 	options := &redislock.Options{RetryStrategy: redislock.LimitRetry(redislock.ExponentialBackoff(minInterval, maxInterval), max)}
 	start := time.Now()
-	redisLock, err := l.locker.Obtain(key, ttl, options)
+	redisLock, token, err := l.locker.Obtain(key, ttl, options)
 	if err != nil {
 		if err == redislock.ErrNotObtained {
 			return nil, false
@@ -55,19 +107,163 @@ func (l *Locker) Obtain(key string, ttl time.Duration, waitTimeout time.Duration
 	checkError(err)
 	l.engine.dataDog.incrementCounter(counterRedisAll, 1)
 	l.engine.dataDog.incrementCounter(counterRedisLockObtain, 1)
-	return &Lock{lock: redisLock, locker: l, key: key, has: true, engine: l.engine}, true
+	return &Lock{lock: redisLock, locker: l, key: key, has: true, engine: l.engine, token: token}, true
+}
+
+func (l *Locker) ObtainMany(keys []string, ttl time.Duration, waitTimeout time.Duration) (lock *MultiLock, obtained bool) {
+	if ttl == 0 {
+		panic(errors.NotValidf("ttl"))
+	}
+	if waitTimeout == 0 {
+		waitTimeout = ttl
+	}
+	unique := make(map[string]bool, len(keys))
+	sorted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !unique[key] {
+			unique[key] = true
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+	deadline := time.Now().Add(waitTimeout)
+	lock = &MultiLock{locks: make([]*Lock, 0, len(sorted))}
+	for _, key := range sorted {
+		wait := time.Until(deadline)
+		if wait < time.Millisecond {
+			wait = time.Millisecond
+		}
+		single, has := l.Obtain(key, ttl, wait)
+		if !has {
+			lock.Release()
+			return nil, false
+		}
+		lock.locks = append(lock.locks, single)
+	}
+	return lock, true
+}
+
+type MultiLock struct {
+	locks []*Lock
+}
+
+func (l *MultiLock) Locks() []*Lock {
+	return l.locks
+}
+
+func (l *MultiLock) Refresh(ttl time.Duration) bool {
+	valid := true
+	for _, lock := range l.locks {
+		if !lock.Refresh(ttl) {
+			valid = false
+		}
+	}
+	return valid
+}
+
+func (l *MultiLock) Release() {
+	for i := len(l.locks) - 1; i >= 0; i-- {
+		l.locks[i].Release()
+	}
 }
 
 type Lock struct {
-	lock   *redislock.Lock
-	key    string
-	locker *Locker
-	has    bool
-	engine *Engine
+	lock         *redislock.Lock
+	key          string
+	locker       *Locker
+	has          bool
+	engine       *Engine
+	token        uint64
+	stopWatchdog context.CancelFunc
+	mutex        sync.Mutex
+}
+
+func (l *Lock) FencingToken() uint64 {
+	return l.token
+}
+
+func (l *Lock) Refresh(ttl time.Duration) bool {
+	if ttl == 0 {
+		panic(errors.NotValidf("ttl"))
+	}
+	if !l.isObtained() {
+		return false
+	}
+	start := time.Now()
+	err := l.lock.Refresh(context.Background(), ttl, nil)
+	if err == redislock.ErrNotObtained {
+		err = nil
+		l.setObtained(false)
+	}
+	if l.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		l.locker.fillLogFields("[ORM][LOCKER][REFRESH]", start, l.key, "refresh lock", err)
+	}
+	l.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	l.engine.dataDog.incrementCounter(counterRedisLockRefresh, 1)
+	checkError(err)
+	return l.isObtained()
+}
+
+func (l *Lock) Watchdog(ttl time.Duration) context.Context {
+	if ttl == 0 {
+		panic(errors.NotValidf("ttl"))
+	}
+	if l.stopWatchdog != nil {
+		l.stopWatchdog()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	l.stopWatchdog = cancel
+	if !l.isObtained() {
+		cancel()
+		return ctx
+	}
+	go func() {
+		defer cancel()
+		interval := ttl / 2
+		if interval <= 0 {
+			interval = ttl
+		}
+		lastRefresh := time.Now()
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				start := time.Now()
+				err := l.lock.Refresh(ctx, ttl, nil)
+				if err == nil {
+					lastRefresh = start
+					timer.Reset(interval)
+					continue
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				if l.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+					l.locker.fillLogFields("[ORM][LOCKER][WATCHDOG]", start, l.key, "refresh lock", err)
+				}
+				if err == redislock.ErrNotObtained {
+					l.setObtained(false)
+					return
+				}
+				if time.Since(lastRefresh) >= ttl {
+					return
+				}
+				timer.Reset(interval / 4)
+			}
+		}
+	}()
+	return ctx
 }
 
 func (l *Lock) Release() {
-	if !l.has {
+	if l.stopWatchdog != nil {
+		l.stopWatchdog()
+		l.stopWatchdog = nil
+	}
+	if !l.isObtained() {
 		return
 	}
 	start := time.Now()
@@ -77,7 +273,19 @@ func (l *Lock) Release() {
 	}
 	l.engine.dataDog.incrementCounter(counterRedisAll, 1)
 	l.engine.dataDog.incrementCounter(counterRedisLockRelease, 1)
-	l.has = false
+	l.setObtained(false)
+}
+
+func (l *Lock) isObtained() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.has
+}
+
+func (l *Lock) setObtained(has bool) {
+	l.mutex.Lock()
+	l.has = has
+	l.mutex.Unlock()
 }
 
 func (l *Lock) TTL() time.Duration {
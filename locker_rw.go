@@ -0,0 +1,142 @@
+package orm
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/juju/errors"
+)
+
+const lockerWriteIntentTTL = time.Second
+
+var lockerReadScript = redis.NewScript(`
+redis.replicate_commands()
+if redis.call("EXISTS", KEYS[1]) == 1 or redis.call("EXISTS", KEYS[3]) == 1 then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", now)
+redis.call("ZADD", KEYS[2], now + tonumber(ARGV[2]), ARGV[1])
+if redis.call("PTTL", KEYS[2]) < tonumber(ARGV[2]) then
+	redis.call("PEXPIRE", KEYS[2], ARGV[2])
+end
+return 1`)
+
+var lockerWriteScript = redis.NewScript(`
+redis.replicate_commands()
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local intent = redis.call("GET", KEYS[3])
+if intent and intent ~= ARGV[1] then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+redis.call("ZREMRANGEBYSCORE", KEYS[2], "-inf", now)
+if redis.call("ZCARD", KEYS[2]) > 0 then
+	redis.call("SET", KEYS[3], ARGV[1], "PX", ARGV[3])
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+redis.call("DEL", KEYS[3])
+return 1`)
+
+var lockerCancelWriteIntentScript = redis.NewScript(`
+if redis.call("GET", KEYS[3]) == ARGV[1] then
+	return redis.call("DEL", KEYS[3])
+end
+return 0`)
+
+var lockerReleaseReadScript = redis.NewScript(`return redis.call("ZREM", KEYS[2], ARGV[1])`)
+
+var lockerReleaseWriteScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+type RWLock struct {
+	locker *Locker
+	key    string
+	token  string
+	write  bool
+	has    bool
+}
+
+func (l *Locker) ObtainRead(key string, ttl time.Duration, waitTimeout time.Duration) (lock *RWLock, obtained bool) {
+	return l.obtainRW(key, ttl, waitTimeout, false)
+}
+
+func (l *Locker) ObtainWrite(key string, ttl time.Duration, waitTimeout time.Duration) (lock *RWLock, obtained bool) {
+	return l.obtainRW(key, ttl, waitTimeout, true)
+}
+
+func (l *Locker) obtainRW(key string, ttl time.Duration, waitTimeout time.Duration, write bool) (lock *RWLock, obtained bool) {
+	if ttl < time.Millisecond {
+		panic(errors.NotValidf("ttl"))
+	}
+	if waitTimeout == 0 {
+		waitTimeout = ttl
+	}
+	script := lockerReadScript
+	message := "[ORM][LOCKER][OBTAIN_READ]"
+	if write {
+		script = lockerWriteScript
+		message = "[ORM][LOCKER][OBTAIN_WRITE]"
+	}
+	token := generateRandomID()
+	keys := getRWLockKeys(key)
+	deadline := time.Now().Add(waitTimeout)
+	interval := 16 * time.Millisecond
+	start := time.Now()
+	for {
+		res, err := l.locker.RunScript(script, keys, token, ttl.Milliseconds(), lockerWriteIntentTTL.Milliseconds())
+		if err != nil || res == int64(1) {
+			if l.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+				l.fillLogFields(message, start, key, "obtain lock", err)
+			}
+			checkError(err)
+			l.engine.dataDog.incrementCounter(counterRedisAll, 1)
+			l.engine.dataDog.incrementCounter(counterRedisLockObtain, 1)
+			return &RWLock{locker: l, key: key, token: token, write: write, has: true}, true
+		}
+		if time.Now().Add(interval).After(deadline) {
+			if write {
+				_, err = l.locker.RunScript(lockerCancelWriteIntentScript, keys, token)
+				checkError(err)
+			}
+			return nil, false
+		}
+		time.Sleep(interval)
+		if interval < 256*time.Millisecond {
+			interval *= 2
+		}
+	}
+}
+
+func (l *RWLock) Release() {
+	if !l.has {
+		return
+	}
+	script := lockerReleaseReadScript
+	message := "[ORM][LOCKER][RELEASE_READ]"
+	if l.write {
+		script = lockerReleaseWriteScript
+		message = "[ORM][LOCKER][RELEASE_WRITE]"
+	}
+	start := time.Now()
+	_, err := l.locker.locker.RunScript(script, getRWLockKeys(l.key), l.token)
+	engine := l.locker.engine
+	if engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		l.locker.fillLogFields(message, start, l.key, "release lock", err)
+	}
+	engine.dataDog.incrementCounter(counterRedisAll, 1)
+	engine.dataDog.incrementCounter(counterRedisLockRelease, 1)
+	l.has = false
+}
+
+func getRWLockKeys(key string) []string {
+	return []string{"{" + key + "}:write", "{" + key + "}:read", "{" + key + "}:intent"}
+}
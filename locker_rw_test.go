@@ -0,0 +1,72 @@
+package orm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockerReadWrite(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterLocker("default", "default")
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	engine.GetRedis().FlushDB()
+	l := engine.GetLocker()
+
+	read1, has := l.ObtainRead("test_rw", time.Second, 0)
+	assert.True(t, has)
+	read2, has := l.ObtainRead("test_rw", time.Second, 0)
+	assert.True(t, has)
+	_, has = l.ObtainWrite("test_rw", time.Second, time.Millisecond*50)
+	assert.False(t, has)
+	read1.Release()
+	read1.Release()
+	_, has = l.ObtainWrite("test_rw", time.Second, time.Millisecond*50)
+	assert.False(t, has)
+	read2.Release()
+
+	write, has := l.ObtainWrite("test_rw", time.Second, 0)
+	assert.True(t, has)
+	_, has = l.ObtainRead("test_rw", time.Second, time.Millisecond*50)
+	assert.False(t, has)
+	_, has = l.ObtainWrite("test_rw", time.Second, time.Millisecond*50)
+	assert.False(t, has)
+	write.Release()
+	read1, has = l.ObtainRead("test_rw", time.Millisecond*100, 0)
+	assert.True(t, has)
+
+	time.Sleep(time.Millisecond * 150)
+	write, has = l.ObtainWrite("test_rw", time.Second, time.Millisecond)
+	assert.True(t, has)
+	read1.Release()
+	_, has = l.ObtainRead("test_rw", time.Second, time.Millisecond)
+	assert.False(t, has)
+	write.Release()
+
+	read1, has = l.ObtainRead("test_rw", time.Second, 0)
+	assert.True(t, has)
+	obtained := make(chan bool)
+	go func() {
+		write, has := l.ObtainWrite("test_rw", time.Second, time.Second)
+		if has {
+			write.Release()
+		}
+		obtained <- has
+	}()
+	time.Sleep(time.Millisecond * 50)
+	_, has = l.ObtainRead("test_rw", time.Second, time.Millisecond)
+	assert.False(t, has)
+	read1.Release()
+	assert.True(t, <-obtained)
+	read1, has = l.ObtainRead("test_rw", time.Second, time.Millisecond)
+	assert.True(t, has)
+	read1.Release()
+
+	assert.PanicsWithError(t, "ttl not valid", func() {
+		l.ObtainRead("test_rw", 0, 0)
+	})
+}
@@ -1,6 +1,7 @@
 package orm
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -41,6 +42,79 @@ func TestLocker(t *testing.T) {
 		_, _ = l.Obtain("test_key", 0, time.Millisecond)
 	})
 
+	lock, has = l.Obtain("test_refresh", time.Millisecond*500, 0)
+	assert.True(t, has)
+	token := lock.FencingToken()
+	assert.Greater(t, token, uint64(0))
+	fencing, _ := engine.GetRedis().Get("{test_refresh}:fencing")
+	assert.Equal(t, fmt.Sprintf("%d", token), fencing)
+	fencingTTL, has := engine.GetRedis().TTL("{test_refresh}:fencing")
+	assert.True(t, has)
+	assert.Greater(t, fencingTTL.Nanoseconds(), time.Hour.Nanoseconds())
+	assert.True(t, lock.Refresh(time.Second*2))
+	assert.Greater(t, lock.TTL().Milliseconds(), int64(1000))
+	assert.PanicsWithError(t, "ttl not valid", func() {
+		lock.Refresh(0)
+	})
+	lock.Release()
+	assert.False(t, lock.Refresh(time.Second))
+
+	engine.GetRedis().Del("{test_refresh}:fencing")
+	lock, has = l.Obtain("test_refresh", time.Millisecond*100, 0)
+	assert.True(t, has)
+	assert.Greater(t, lock.FencingToken(), token)
+	engine.GetRedis().Del("test_refresh")
+	assert.False(t, lock.Refresh(time.Second))
+
+	lock, has = l.Obtain("test_watchdog", time.Millisecond*200, 0)
+	assert.True(t, has)
+	ctx := lock.Watchdog(time.Millisecond * 200)
+	time.Sleep(time.Millisecond * 500)
+	assert.Nil(t, ctx.Err())
+	_, has = l.Obtain("test_watchdog", time.Second, time.Millisecond)
+	assert.False(t, has)
+	engine.GetRedis().Del("test_watchdog")
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		assert.Fail(t, "watchdog should detect lost lock")
+	}
+	assert.False(t, lock.isObtained())
+	assert.PanicsWithError(t, "flush lock lost", func() {
+		engine.flushLock = ctx
+		defer func() {
+			engine.flushLock = nil
+		}()
+		engine.checkFlushLock()
+	})
+
+	lock, has = l.Obtain("test_watchdog", time.Millisecond*200, 0)
+	assert.True(t, has)
+	ctx = lock.Watchdog(time.Millisecond * 200)
+	lock.Release()
+	<-ctx.Done()
+	_, has = l.Obtain("test_watchdog", time.Second, time.Millisecond)
+	assert.True(t, has)
+
+	other, has := l.Obtain("test_many_b", time.Second, 0)
+	assert.True(t, has)
+	_, has = l.ObtainMany([]string{"test_many_c", "test_many_b", "test_many_a"}, time.Second, time.Millisecond*50)
+	assert.False(t, has)
+	_, has = l.Obtain("test_many_a", time.Second, time.Millisecond)
+	assert.True(t, has)
+	engine.GetRedis().Del("test_many_a")
+	other.Release()
+	many, has := l.ObtainMany([]string{"test_many_c", "test_many_b", "test_many_a", "test_many_b"}, time.Second, 0)
+	assert.True(t, has)
+	assert.Len(t, many.Locks(), 3)
+	assert.Equal(t, "test_many_a", many.Locks()[0].key)
+	assert.True(t, many.Refresh(time.Second*2))
+	_, has = l.Obtain("test_many_c", time.Second, time.Millisecond)
+	assert.False(t, has)
+	many.Release()
+	_, has = l.Obtain("test_many_c", time.Second, time.Millisecond)
+	assert.True(t, has)
+
 	registry = &Registry{}
 	registry.RegisterRedis("localhost:6389", 15)
 	registry.RegisterLocker("default", "default")
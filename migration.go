@@ -0,0 +1,290 @@
+package orm
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const migrationsTableName = "_orm_migrations"
+const migrationVersionFormat = "20060102150405"
+const migrationLockTTL = time.Minute
+const migrationLockWait = time.Minute
+const migrationRollbackMissing = "-- rollback not generated for:"
+
+type Migration struct {
+	Version   string
+	Pool      string
+	File      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+func (e *Engine) GenerateMigrations(dir string) (files []string) {
+	alters := e.GetAlters()
+	files = make([]string, 0)
+	if len(alters) == 0 {
+		return files
+	}
+	pools := make([]string, 0)
+	grouped := make(map[string][]Alter)
+	for _, alter := range alters {
+		_, has := grouped[alter.Pool]
+		if !has {
+			pools = append(pools, alter.Pool)
+		}
+		grouped[alter.Pool] = append(grouped[alter.Pool], alter)
+	}
+	sort.Strings(pools)
+	version := getNextMigrationVersion(dir)
+	for _, pool := range pools {
+		up := ""
+		down := ""
+		for i, alter := range grouped[pool] {
+			if !alter.Safe {
+				up += "-- unsafe: data can be lost\n"
+			}
+			up += alter.SQL + "\n\n"
+			down = buildMigrationDownSQL(grouped[pool][len(grouped[pool])-1-i]) + "\n\n" + down
+		}
+		down = strings.TrimRight(down, "\n") + "\n"
+		name := filepath.Join(dir, version+"_"+pool)
+		if _, err := os.Stat(name + ".up.sql"); err == nil {
+			panic(errors.AlreadyExistsf("migration '%s'", filepath.Base(name)))
+		}
+		checkError(ioutil.WriteFile(name+".up.sql", []byte(strings.TrimRight(up, "\n")+"\n"), 0644))
+		checkError(ioutil.WriteFile(name+".down.sql", []byte(down), 0644))
+		files = append(files, name+".up.sql", name+".down.sql")
+	}
+	return files
+}
+
+func (e *Engine) MigrationStatus(dir string) []*Migration {
+	migrations := readMigrations(e, dir)
+	applied := make(map[string]map[string]time.Time)
+	for _, migration := range migrations {
+		appliedInPool, has := applied[migration.Pool]
+		if !has {
+			appliedInPool = getAppliedMigrations(e.GetMysql(migration.Pool))
+			applied[migration.Pool] = appliedInPool
+		}
+		appliedAt, has := appliedInPool[migration.Version]
+		if has {
+			migration.Applied = true
+			migration.AppliedAt = &appliedAt
+		}
+	}
+	return migrations
+}
+
+func (e *Engine) Migrate(dir string, lockerPool ...string) (applied []*Migration) {
+	applied = make([]*Migration, 0)
+	pools := make([]string, 0)
+	pending := make(map[string][]*Migration)
+	for _, migration := range e.MigrationStatus(dir) {
+		if migration.Applied {
+			continue
+		}
+		_, has := pending[migration.Pool]
+		if !has {
+			pools = append(pools, migration.Pool)
+		}
+		pending[migration.Pool] = append(pending[migration.Pool], migration)
+	}
+	sort.Strings(pools)
+	for _, pool := range pools {
+		func() {
+			lock, ctx := obtainMigrationLock(e, pool, lockerPool...)
+			defer lock.Release()
+			db := e.GetMysql(pool)
+			appliedInPool := getAppliedMigrations(db)
+			for _, migration := range pending[pool] {
+				_, has := appliedInPool[migration.Version]
+				if has {
+					continue
+				}
+				now := time.Now().UTC()
+				runMigrationFile(ctx, pool, db, migration.File, "INSERT INTO `"+migrationsTableName+"` (`version`, `applied_at`) VALUES (?, ?)",
+					migration.Version, now.Format("2006-01-02 15:04:05"))
+				migration.Applied = true
+				migration.AppliedAt = &now
+				applied = append(applied, migration)
+			}
+		}()
+	}
+	return applied
+}
+
+func (e *Engine) MigrateDown(dir string, steps int, lockerPool ...string) (reverted []*Migration) {
+	reverted = make([]*Migration, 0)
+	migrations := e.MigrationStatus(dir)
+	for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := migrations[i]
+		if !migration.Applied {
+			continue
+		}
+		func() {
+			lock, ctx := obtainMigrationLock(e, migration.Pool, lockerPool...)
+			defer lock.Release()
+			db := e.GetMysql(migration.Pool)
+			_, has := getAppliedMigrations(db)[migration.Version]
+			if !has {
+				return
+			}
+			downFile := strings.TrimSuffix(migration.File, ".up.sql") + ".down.sql"
+			content, err := ioutil.ReadFile(downFile)
+			checkError(err)
+			if strings.Contains(string(content), migrationRollbackMissing) || len(splitSQLStatements(string(content))) == 0 {
+				panic(errors.NotImplementedf("rollback in migration '%s'", filepath.Base(downFile)))
+			}
+			runMigrationFile(ctx, migration.Pool, db, downFile, "DELETE FROM `"+migrationsTableName+"` WHERE `version` = ?", migration.Version)
+			migration.Applied = false
+			migration.AppliedAt = nil
+			reverted = append(reverted, migration)
+		}()
+	}
+	return reverted
+}
+
+func obtainMigrationLock(engine *Engine, pool string, lockerPool ...string) (*Lock, context.Context) {
+	lock, obtained := engine.GetLocker(lockerPool...).Obtain(migrationsTableName+":"+pool, migrationLockTTL, migrationLockWait)
+	if !obtained {
+		panic(errors.Errorf("migrations lock for pool '%s' not obtained", pool))
+	}
+	return lock, lock.Watchdog(migrationLockTTL)
+}
+
+func checkMigrationLock(ctx context.Context, pool string) {
+	if ctx.Err() != nil {
+		panic(errors.Errorf("migrations lock for pool '%s' lost", pool))
+	}
+}
+
+func readMigrations(engine *Engine, dir string) []*Migration {
+	files, err := ioutil.ReadDir(dir)
+	checkError(err)
+	migrations := make([]*Migration, 0)
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".up.sql") {
+			continue
+		}
+		version := strings.TrimSuffix(file.Name(), ".up.sql")
+		parts := strings.SplitN(version, "_", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			panic(errors.NotValidf("migration file name '%s'", file.Name()))
+		}
+		_, has := engine.registry.sqlClients[parts[1]]
+		if !has {
+			panic(errors.NotFoundf("mysql pool '%s' in migration '%s'", parts[1], file.Name()))
+		}
+		migrations = append(migrations, &Migration{Version: version, Pool: parts[1], File: filepath.Join(dir, file.Name())})
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations
+}
+
+func getAppliedMigrations(db *DB) map[string]time.Time {
+	db.Exec("CREATE TABLE IF NOT EXISTS `" + migrationsTableName + "` (\n  `version` varchar(255) NOT NULL,\n" +
+		"  `applied_at` datetime NOT NULL,\n  PRIMARY KEY (`version`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;")
+	results, def := db.Query("SELECT `version`, DATE_FORMAT(`applied_at`, '%Y-%m-%d %H:%i:%s') FROM `" + migrationsTableName + "`")
+	defer def()
+	applied := make(map[string]time.Time)
+	for results.Next() {
+		var version, appliedAt string
+		results.Scan(&version, &appliedAt)
+		applied[version], _ = time.ParseInLocation("2006-01-02 15:04:05", appliedAt, time.UTC)
+	}
+	def()
+	return applied
+}
+
+func runMigrationFile(ctx context.Context, pool string, db *DB, file string, history string, args ...interface{}) {
+	content, err := ioutil.ReadFile(file)
+	checkError(err)
+	for _, query := range splitSQLStatements(string(content)) {
+		checkMigrationLock(ctx, pool)
+		db.Exec(query)
+	}
+	db.Exec(history, args...)
+}
+
+func getNextMigrationVersion(dir string) string {
+	version := time.Now().UTC().Truncate(time.Second)
+	files, err := ioutil.ReadDir(dir)
+	checkError(err)
+	for _, file := range files {
+		existing, err := time.Parse(migrationVersionFormat, strings.SplitN(file.Name(), "_", 2)[0])
+		if err == nil && !existing.Before(version) {
+			version = existing.Add(time.Second)
+		}
+	}
+	return version.Format(migrationVersionFormat)
+}
+
+func buildMigrationDownSQL(alter Alter) string {
+	if alter.rollback != "" {
+		return alter.rollback
+	}
+	return migrationRollbackMissing + "\n-- " + strings.Replace(alter.SQL, "\n", "\n-- ", -1)
+}
+
+func splitSQLStatements(content string) []string {
+	statements := make([]string, 0)
+	var current strings.Builder
+	var quote byte
+	for i := 0; i < len(content); i++ {
+		char := content[i]
+		if quote != 0 {
+			current.WriteByte(char)
+			if char == '\\' && i+1 < len(content) {
+				i++
+				current.WriteByte(content[i])
+			} else if char == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch {
+		case char == '\'' || char == '"' || char == '`':
+			quote = char
+			current.WriteByte(char)
+		case char == '#' || (char == '-' && strings.HasPrefix(content[i:], "--")):
+			end := strings.Index(content[i:], "\n")
+			if end == -1 {
+				i = len(content)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+		case char == '/' && strings.HasPrefix(content[i:], "/*"):
+			end := strings.Index(content[i+2:], "*/")
+			if end == -1 {
+				i = len(content)
+			} else {
+				i += end + 3
+				current.WriteByte(' ')
+			}
+		case char == ';':
+			statement := strings.TrimSpace(current.String())
+			if statement != "" {
+				statements = append(statements, statement)
+			}
+			current.Reset()
+		default:
+			current.WriteByte(char)
+		}
+	}
+	statement := strings.TrimSpace(current.String())
+	if statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,97 @@
+package orm
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type migrationEntity struct {
+	ORM
+	ID   uint
+	Name string
+}
+
+func TestMigrations(t *testing.T) {
+	var entity *migrationEntity
+	registry := &Registry{}
+	registry.RegisterLocker("default", "default")
+	engine := PrepareTables(t, registry, entity)
+	engine.GetMysql().Exec("DROP TABLE IF EXISTS `_orm_migrations`")
+	engine.GetRegistry().GetTableSchemaForEntity(entity).DropTable(engine)
+
+	dir := t.TempDir()
+	files := engine.GenerateMigrations(dir)
+	assert.Len(t, files, 2)
+	up, err := ioutil.ReadFile(files[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "CREATE TABLE `test`.`migrationEntity` (\n  `ID` int(10) unsigned NOT NULL AUTO_INCREMENT,\n  `Name` varchar(255) DEFAULT NULL,\n  PRIMARY KEY (`ID`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;\n", string(up))
+	down, err := ioutil.ReadFile(files[1])
+	assert.Nil(t, err)
+	assert.Equal(t, "DROP TABLE IF EXISTS `test`.`migrationEntity`;\n", string(down))
+
+	status := engine.MigrationStatus(dir)
+	assert.Len(t, status, 1)
+	assert.Equal(t, "default", status[0].Pool)
+	assert.False(t, status[0].Applied)
+	assert.Nil(t, status[0].AppliedAt)
+
+	applied := engine.Migrate(dir)
+	assert.Len(t, applied, 1)
+	assert.Equal(t, status[0].Version, applied[0].Version)
+	assert.Len(t, engine.GetAlters(), 0)
+	assert.Len(t, engine.Migrate(dir), 0)
+
+	status = engine.MigrationStatus(dir)
+	assert.True(t, status[0].Applied)
+	assert.NotNil(t, status[0].AppliedAt)
+
+	reverted := engine.MigrateDown(dir, 1)
+	assert.Len(t, reverted, 1)
+	assert.Len(t, engine.GetAlters(), 1)
+	assert.False(t, engine.MigrationStatus(dir)[0].Applied)
+	assert.Len(t, engine.MigrateDown(dir, 1), 0)
+
+	assert.Len(t, engine.Migrate(dir), 1)
+	assert.Len(t, engine.GetAlters(), 0)
+
+	engine.GetMysql().Exec("ALTER TABLE `migrationEntity` DROP COLUMN `Name`, ADD INDEX `Extra` (`ID`)")
+	files = engine.GenerateMigrations(dir)
+	assert.Len(t, files, 2)
+	down, err = ioutil.ReadFile(files[1])
+	assert.Nil(t, err)
+	assert.Equal(t, "ALTER TABLE `test`.`migrationEntity`\n    DROP COLUMN `Name`,\n    ADD INDEX `Extra` (`ID`);\n", string(down))
+	assert.Len(t, engine.Migrate(dir), 1)
+	assert.Len(t, engine.GetAlters(), 0)
+	assert.Len(t, engine.MigrateDown(dir, 1), 1)
+	assert.Len(t, engine.GetAlters(), 1)
+	assert.Len(t, engine.Migrate(dir), 1)
+
+	assert.Nil(t, ioutil.WriteFile(files[1], []byte(migrationRollbackMissing+"\n-- DROP TABLE `migrationEntity`;\n"), 0644))
+	assert.PanicsWithError(t, "rollback in migration '"+filepath.Base(files[1])+"' not implemented", func() {
+		engine.MigrateDown(dir, 1)
+	})
+
+	assert.Nil(t, ioutil.WriteFile(dir+"/20200101000000_missing.up.sql", []byte("SELECT 1;"), 0644))
+	assert.PanicsWithError(t, "mysql pool 'missing' in migration '20200101000000_missing.up.sql' not found", func() {
+		engine.MigrationStatus(dir)
+	})
+}
+
+func TestNextMigrationVersion(t *testing.T) {
+	dir := t.TempDir()
+	future := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, future.Format(migrationVersionFormat)+"_default.up.sql"), []byte("SELECT 1;"), 0644))
+	assert.Equal(t, future.Add(time.Second).Format(migrationVersionFormat), getNextMigrationVersion(dir))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "README.md"), []byte(""), 0644))
+	assert.Equal(t, future.Add(time.Second).Format(migrationVersionFormat), getNextMigrationVersion(dir))
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	statements := splitSQLStatements("-- comment;\nALTER TABLE `a;b`\n    ADD COLUMN `c` varchar(10) DEFAULT 'x;\\'y';/*CHANGED ORDER*/\n\n" +
+		"# other;\nDROP TABLE `d`;\n/* only comment; */\n")
+	assert.Equal(t, []string{"ALTER TABLE `a;b`\n    ADD COLUMN `c` varchar(10) DEFAULT 'x;\\'y'", "DROP TABLE `d`"}, statements)
+}
@@ -0,0 +1,256 @@
+package orm
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	apexLog "github.com/apex/log"
+	"github.com/go-sql-driver/mysql"
+	"github.com/juju/errors"
+)
+
+const onlineSchemaChangeDefaultChunkSize = 1000
+const onlineSchemaChangeForeignKeyPrefix = "_osc:"
+
+var onlineSchemaChangeRenameRegexp = regexp.MustCompile("CHANGE COLUMN `([^`]+)` `([^`]+)`")
+
+type OnlineSchemaChangeOptions struct {
+	ChunkSize int
+	Sleep     time.Duration
+}
+
+func (tableSchema *tableSchema) UpdateSchemaOnline(engine *Engine, options *OnlineSchemaChangeOptions) {
+	if tableSchema.primaryKeyType != "" || len(tableSchema.primaryKey) > 1 {
+		panic(errors.NotSupportedf("online schema change in %s without integer primary key", tableSchema.t.String()))
+	}
+	pool := tableSchema.GetMysql(engine)
+	restoreOnlineForeignKeys(engine, pool, tableSchema.tableName)
+	has, alters := tableSchema.GetSchemaChanges(engine)
+	if !has {
+		return
+	}
+	prefix := fmt.Sprintf("ALTER TABLE `%s`.`%s`\n", pool.GetDatabaseName(), tableSchema.tableName)
+	online := make([]Alter, 0)
+	for _, alter := range alters {
+		if strings.HasPrefix(alter.SQL, prefix) && !strings.Contains(alter.SQL, "FOREIGN KEY") &&
+			!isTableEmpty(pool.client, tableSchema.tableName) {
+			online = append(online, alter)
+			continue
+		}
+		if !strings.Contains(alter.SQL, "ADD CONSTRAINT") {
+			pool.Exec(alter.SQL)
+		}
+	}
+	for _, alter := range online {
+		if !execAlterInPlace(pool, alter.SQL) {
+			runOnlineAlter(engine, pool, tableSchema.tableName, alter.SQL[len(prefix):], options)
+		}
+	}
+	tableSchema.UpdateSchema(engine)
+}
+
+func execAlterInPlace(pool *DB, alterSQL string) bool {
+	if strings.Contains(alterSQL, "\n    PARTITION BY ") || strings.Contains(alterSQL, "\n    REMOVE PARTITIONING;") {
+		return false
+	}
+	query := alterSQL[:strings.LastIndex(alterSQL, ";")] + ",\n    ALGORITHM=INPLACE, LOCK=NONE;"
+	start := time.Now()
+	_, err := pool.client.Exec(query)
+	if pool.engine.queryLoggers[QueryLoggerSourceDB] != nil {
+		pool.fillLogFields("[ORM][MYSQL][EXEC]", start, "exec", query, nil, err)
+	}
+	pool.engine.dataDog.incrementCounter(counterDBAll, 1)
+	pool.engine.dataDog.incrementCounter(counterDBExec, 1)
+	sqlErr, isSQLError := errors.Cause(err).(*mysql.MySQLError)
+	if isSQLError && (sqlErr.Number == 1845 || sqlErr.Number == 1846) {
+		return false
+	}
+	if err != nil {
+		panic(convertToError(err))
+	}
+	return true
+}
+
+func runOnlineAlter(engine *Engine, pool *DB, tableName string, alterBody string, options *OnlineSchemaChangeOptions) {
+	chunkSize := onlineSchemaChangeDefaultChunkSize
+	var sleep time.Duration
+	if options != nil {
+		if options.ChunkSize > 0 {
+			chunkSize = options.ChunkSize
+		}
+		sleep = options.Sleep
+	}
+	var references int
+	pool.QueryRow(NewWhere("SELECT COUNT(*) FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE WHERE REFERENCED_TABLE_SCHEMA = ? "+
+		"AND REFERENCED_TABLE_NAME = ?", pool.GetDatabaseName(), tableName), &references)
+	if references > 0 {
+		panic(errors.Errorf("online schema change not possible, table '%s' is referenced by foreign keys", tableName))
+	}
+
+	shadowTable := "_" + tableName + "_new"
+	oldTable := "_" + tableName + "_old"
+	triggerPrefix := "_" + tableName + "_osc"
+	logFields := apexLog.Fields{"table": tableName, "pool": pool.GetPoolCode()}
+	engine.Log().Info("online schema change started", logFields)
+
+	dropTriggers := func() {
+		for _, suffix := range []string{"ins", "upd", "del"} {
+			pool.Exec(fmt.Sprintf("DROP TRIGGER IF EXISTS `%s_%s`", triggerPrefix, suffix))
+		}
+	}
+	pool.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", shadowTable))
+	pool.Exec(fmt.Sprintf("CREATE TABLE `%s` LIKE `%s`", shadowTable, tableName))
+	swapped := false
+	defer func() {
+		if !swapped {
+			dropTriggers()
+			pool.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", shadowTable))
+		}
+	}()
+	pool.Exec(fmt.Sprintf("ALTER TABLE `%s`\n%s", shadowTable, alterBody))
+	var skip, createTableDB string
+	pool.QueryRow(NewWhere(fmt.Sprintf("SHOW CREATE TABLE `%s`", tableName)), &skip, &createTableDB)
+	foreignKeys := getForeignKeys(engine, createTableDB, tableName, pool.GetPoolCode())
+	foreignKeyNames := make([]string, 0, len(foreignKeys))
+	for name := range foreignKeys {
+		foreignKeyNames = append(foreignKeyNames, name)
+	}
+	sort.Strings(foreignKeyNames)
+	if len(foreignKeyNames) > 0 {
+		shadowForeignKeys := make([]string, len(foreignKeyNames))
+		for i, name := range foreignKeyNames {
+			shadowForeignKeys[i] = buildCreateForeignKeySQL(onlineSchemaChangeForeignKeyPrefix+name, foreignKeys[name])
+		}
+		pool.Exec(fmt.Sprintf("ALTER TABLE `%s`\n%s", shadowTable, strings.Join(shadowForeignKeys, ",\n")))
+	}
+
+	renamed := make(map[string]string)
+	for _, match := range onlineSchemaChangeRenameRegexp.FindAllStringSubmatch(alterBody, -1) {
+		renamed[match[1]] = match[2]
+	}
+	shadowColumns := make(map[string]bool)
+	for _, column := range getTableColumns(pool, shadowTable) {
+		shadowColumns[column] = true
+	}
+	columnsFrom := make([]string, 0)
+	columnsTo := make([]string, 0)
+	columnsNew := make([]string, 0)
+	for _, column := range getTableColumns(pool, tableName) {
+		target := column
+		newName, has := renamed[column]
+		if has {
+			target = newName
+		}
+		if !shadowColumns[target] {
+			continue
+		}
+		columnsFrom = append(columnsFrom, "`"+column+"`")
+		columnsTo = append(columnsTo, "`"+target+"`")
+		columnsNew = append(columnsNew, "NEW.`"+column+"`")
+	}
+	from := strings.Join(columnsFrom, ", ")
+	to := strings.Join(columnsTo, ", ")
+
+	dropTriggers()
+	pool.Exec(fmt.Sprintf("CREATE TRIGGER `%s_ins` AFTER INSERT ON `%s` FOR EACH ROW REPLACE INTO `%s` (%s) VALUES (%s)",
+		triggerPrefix, tableName, shadowTable, to, strings.Join(columnsNew, ", ")))
+	pool.Exec(fmt.Sprintf("CREATE TRIGGER `%s_upd` AFTER UPDATE ON `%s` FOR EACH ROW REPLACE INTO `%s` (%s) VALUES (%s)",
+		triggerPrefix, tableName, shadowTable, to, strings.Join(columnsNew, ", ")))
+	pool.Exec(fmt.Sprintf("CREATE TRIGGER `%s_del` AFTER DELETE ON `%s` FOR EACH ROW DELETE FROM `%s` WHERE `ID` = OLD.`ID`",
+		triggerPrefix, tableName, shadowTable))
+
+	var total uint64
+	pool.QueryRow(NewWhere("SELECT IFNULL(TABLE_ROWS, 0) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		pool.GetDatabaseName(), tableName), &total)
+	copied := uint64(0)
+	lastID := int64(0)
+	for {
+		var maxID sql.NullInt64
+		/* #nosec */
+		pool.QueryRow(NewWhere(fmt.Sprintf("SELECT MAX(`ID`) FROM (SELECT `ID` FROM `%s` WHERE `ID` > ? ORDER BY `ID` LIMIT %d) AS `chunk`",
+			tableName, chunkSize), lastID), &maxID)
+		if !maxID.Valid {
+			break
+		}
+		/* #nosec */
+		res := pool.Exec(fmt.Sprintf("INSERT IGNORE INTO `%s` (%s) SELECT %s FROM `%s` WHERE `ID` > ? AND `ID` <= ? LOCK IN SHARE MODE",
+			shadowTable, to, from, tableName), lastID, maxID.Int64)
+		copied += res.RowsAffected()
+		lastID = maxID.Int64
+		engine.Log().Info("online schema change progress", apexLog.Fields{"table": tableName, "pool": pool.GetPoolCode(),
+			"copied": copied, "total": total})
+		if sleep > 0 {
+			time.Sleep(sleep)
+		}
+	}
+
+	pool.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", oldTable))
+	pool.Exec(fmt.Sprintf("RENAME TABLE `%s` TO `%s`, `%s` TO `%s`", tableName, oldTable, shadowTable, tableName))
+	swapped = true
+	dropTriggers()
+	restoreOnlineForeignKeys(engine, pool, tableName)
+	pool.Exec(fmt.Sprintf("DROP TABLE IF EXISTS `%s`", oldTable))
+	engine.Log().Info("online schema change finished", apexLog.Fields{"table": tableName, "pool": pool.GetPoolCode(), "copied": copied})
+}
+
+func restoreOnlineForeignKeys(engine *Engine, pool *DB, tableName string) {
+	var skip, createTableDB string
+	if !pool.QueryRow(NewWhere(fmt.Sprintf("SHOW TABLES LIKE '%s'", tableName)), &skip) {
+		return
+	}
+	pool.QueryRow(NewWhere(fmt.Sprintf("SHOW CREATE TABLE `%s`", tableName)), &skip, &createTableDB)
+	foreignKeys := getForeignKeys(engine, createTableDB, tableName, pool.GetPoolCode())
+	names := make([]string, 0)
+	for name := range foreignKeys {
+		if strings.HasPrefix(name, onlineSchemaChangeForeignKeyPrefix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return
+	}
+	sort.Strings(names)
+	oldTable := "_" + tableName + "_old"
+	if pool.QueryRow(NewWhere(fmt.Sprintf("SHOW TABLES LIKE '%s'", oldTable)), &skip) {
+		dropForeignKeys, has := getDropForeignKeysAlter(engine, oldTable, pool.GetPoolCode())
+		if has {
+			pool.Exec(dropForeignKeys.SQL)
+		}
+	}
+	renamed := make([]string, 0, len(names)*2)
+	for _, name := range names {
+		renamed = append(renamed, fmt.Sprintf("DROP FOREIGN KEY `%s`", name),
+			buildCreateForeignKeySQL(strings.TrimPrefix(name, onlineSchemaChangeForeignKeyPrefix), foreignKeys[name]))
+	}
+	pool.Begin()
+	committed := false
+	defer func() {
+		if !committed {
+			pool.Exec("SET foreign_key_checks = 1")
+			pool.Rollback()
+		}
+	}()
+	pool.Exec("SET foreign_key_checks = 0")
+	pool.Exec(fmt.Sprintf("ALTER TABLE `%s`\n%s", tableName, strings.Join(renamed, ",\n")))
+	pool.Exec("SET foreign_key_checks = 1")
+	pool.Commit()
+	committed = true
+}
+
+func getTableColumns(pool *DB, tableName string) []string {
+	results, def := pool.Query("SELECT `COLUMN_NAME` FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? "+
+		"AND `EXTRA` NOT LIKE '%GENERATED' ORDER BY `ORDINAL_POSITION`", pool.GetDatabaseName(), tableName)
+	defer def()
+	columns := make([]string, 0)
+	for results.Next() {
+		var column string
+		results.Scan(&column)
+		columns = append(columns, column)
+	}
+	def()
+	return columns
+}
@@ -0,0 +1,129 @@
+package orm
+
+import (
+	"testing"
+
+	log2 "github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+type onlineSchemaEntity struct {
+	ORM
+	ID   uint
+	Name string `orm:"required"`
+	Age  uint8
+}
+
+func TestUpdateSchemaOnline(t *testing.T) {
+	var entity *onlineSchemaEntity
+	engine := PrepareTables(t, &Registry{}, entity)
+	for i := 1; i <= 25; i++ {
+		engine.Track(&onlineSchemaEntity{Name: "name", Age: uint8(i)})
+	}
+	engine.Flush()
+
+	engine.GetMysql().Exec("ALTER TABLE `onlineSchemaEntity` CHANGE COLUMN `Age` `Age` int(11) NOT NULL DEFAULT '0', ADD COLUMN `Old` int(11) DEFAULT NULL")
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity)
+	has, _ := schema.GetSchemaChanges(engine)
+	assert.True(t, has)
+
+	logger := memory.New()
+	engine.EnableLogger(log2.InfoLevel, logger)
+	schema.UpdateSchemaOnline(engine, &OnlineSchemaChangeOptions{ChunkSize: 10})
+	has, _ = schema.GetSchemaChanges(engine)
+	assert.False(t, has)
+
+	assert.Len(t, logger.Entries, 5)
+	assert.Equal(t, "online schema change started", logger.Entries[0].Message)
+	assert.Equal(t, "online schema change progress", logger.Entries[1].Message)
+	assert.Equal(t, uint64(10), logger.Entries[1].Fields["copied"])
+	assert.Equal(t, uint64(25), logger.Entries[3].Fields["copied"])
+	assert.Equal(t, "online schema change finished", logger.Entries[4].Message)
+
+	var entities []*onlineSchemaEntity
+	engine.Search(NewWhere("1 ORDER BY `ID`"), nil, &entities)
+	assert.Len(t, entities, 25)
+	assert.Equal(t, uint8(25), entities[24].Age)
+
+	var skip string
+	assert.False(t, engine.GetMysql().QueryRow(NewWhere("SHOW TABLES LIKE '_onlineSchemaEntity_new'"), &skip))
+	assert.False(t, engine.GetMysql().QueryRow(NewWhere("SHOW TABLES LIKE '_onlineSchemaEntity_old'"), &skip))
+	var triggers int
+	engine.GetMysql().QueryRow(NewWhere("SELECT COUNT(*) FROM INFORMATION_SCHEMA.TRIGGERS WHERE TRIGGER_SCHEMA = 'test'"), &triggers)
+	assert.Equal(t, 0, triggers)
+}
+
+type onlineSchemaRefEntity struct {
+	ORM
+	ID     uint
+	Name   string
+	Parent *onlineSchemaEntity
+}
+
+func TestUpdateSchemaOnlineInPlaceAndForeignKeys(t *testing.T) {
+	var entity *onlineSchemaEntity
+	var refEntity *onlineSchemaRefEntity
+	engine := PrepareTables(t, &Registry{}, entity, refEntity)
+	parent := &onlineSchemaEntity{Name: "parent"}
+	engine.TrackAndFlush(parent)
+	for i := 1; i <= 5; i++ {
+		engine.Track(&onlineSchemaRefEntity{Name: "name", Parent: parent})
+	}
+	engine.Flush()
+	schema := engine.GetRegistry().GetTableSchemaForEntity(refEntity)
+	logger := memory.New()
+	engine.EnableLogger(log2.InfoLevel, logger)
+
+	engine.GetMysql().Exec("ALTER TABLE `onlineSchemaRefEntity` ADD INDEX `Extra` (`Name`)")
+	schema.UpdateSchemaOnline(engine, nil)
+	has, _ := schema.GetSchemaChanges(engine)
+	assert.False(t, has)
+	assert.Len(t, logger.Entries, 0)
+
+	engine.GetMysql().Exec("ALTER TABLE `onlineSchemaRefEntity` CHANGE COLUMN `Name` `Name` text")
+	schema.UpdateSchemaOnline(engine, nil)
+	has, _ = schema.GetSchemaChanges(engine)
+	assert.False(t, has)
+	assert.Equal(t, "online schema change started", logger.Entries[0].Message)
+	var name string
+	engine.GetMysql().QueryRow(NewWhere("SELECT `CONSTRAINT_NAME` FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS "+
+		"WHERE `CONSTRAINT_SCHEMA` = 'test' AND `TABLE_NAME` = 'onlineSchemaRefEntity'"), &name)
+	assert.Equal(t, "test:onlineSchemaRefEntity:Parent", name)
+	var entities []*onlineSchemaRefEntity
+	engine.Search(NewWhere("1"), nil, &entities)
+	assert.Len(t, entities, 5)
+}
+
+type onlineSchemaPartitionedEntity struct {
+	ORM  `orm:"partition=hash,ID,4"`
+	ID   uint
+	Name string
+}
+
+func TestUpdateSchemaOnlinePartitionedAndForeignKeyRepair(t *testing.T) {
+	var entity *onlineSchemaEntity
+	var refEntity *onlineSchemaRefEntity
+	var hashEntity *onlineSchemaPartitionedEntity
+	engine := PrepareTables(t, &Registry{}, entity, refEntity, hashEntity)
+	engine.TrackAndFlush(&onlineSchemaPartitionedEntity{Name: "a"}, &onlineSchemaPartitionedEntity{Name: "b"})
+	engine.GetMysql().Exec("ALTER TABLE `onlineSchemaPartitionedEntity` CHANGE COLUMN `Name` `Name` text, REMOVE PARTITIONING")
+	schema := engine.GetRegistry().GetTableSchemaForEntity(hashEntity)
+	schema.UpdateSchemaOnline(engine, nil)
+	has, _ := schema.GetSchemaChanges(engine)
+	assert.False(t, has)
+
+	parent := &onlineSchemaEntity{Name: "parent"}
+	engine.TrackAndFlush(parent, &onlineSchemaRefEntity{Name: "a", Parent: parent})
+	engine.GetMysql().Exec("ALTER TABLE `onlineSchemaRefEntity` DROP FOREIGN KEY `test:onlineSchemaRefEntity:Parent`")
+	engine.GetMysql().Exec("ALTER TABLE `onlineSchemaRefEntity` ADD CONSTRAINT `_osc:test:onlineSchemaRefEntity:Parent` " +
+		"FOREIGN KEY (`Parent`) REFERENCES `test`.`onlineSchemaEntity` (`ID`) ON DELETE RESTRICT")
+	schema = engine.GetRegistry().GetTableSchemaForEntity(refEntity)
+	schema.UpdateSchemaOnline(engine, nil)
+	has, _ = schema.GetSchemaChanges(engine)
+	assert.False(t, has)
+	var name string
+	engine.GetMysql().QueryRow(NewWhere("SELECT `CONSTRAINT_NAME` FROM INFORMATION_SCHEMA.REFERENTIAL_CONSTRAINTS "+
+		"WHERE `CONSTRAINT_SCHEMA` = 'test' AND `TABLE_NAME` = 'onlineSchemaRefEntity'"), &name)
+	assert.Equal(t, "test:onlineSchemaRefEntity:Parent", name)
+}
@@ -24,6 +24,7 @@ type entityAttributes struct {
 	elem                 reflect.Value
 	idElem               reflect.Value
 	logMeta              map[string]interface{}
+	timestamps           map[string]time.Time
 }
 
 type ORM struct {
@@ -38,7 +39,7 @@ func (orm *ORM) getORM() *ORM {
 }
 
 func (orm *ORM) GetID() uint64 {
-	if orm.attributes == nil {
+	if orm.attributes == nil || orm.tableSchema.primaryKeyType != "" {
 		return 0
 	}
 	return orm.attributes.idElem.Uint()
@@ -0,0 +1,317 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const partitionsAhead = 2
+
+var partitionByRegexp = regexp.MustCompile("PARTITION BY (RANGE|LIST|HASH|KEY)\\s*(?:COLUMNS)?\\s*\\(([^)]*)\\)")
+var partitionsCountRegexp = regexp.MustCompile("PARTITIONS ([0-9]+)")
+var partitionValuesRegexp = regexp.MustCompile("PARTITION `?(\\w+)`? VALUES IN \\(([^)]*)\\)")
+var partitionRangeRegexp = regexp.MustCompile("PARTITION `?p([0-9]+)`? VALUES LESS THAN")
+
+type partitioning struct {
+	Type     string
+	Column   string
+	Interval string
+	Count    int
+	Values   [][]string
+}
+
+func parsePartitioning(entityType reflect.Type, tags map[string]map[string]string, primaryKey []string) (*partitioning, error) {
+	definition, has := tags["ORM"]["partition"]
+	if !has {
+		return nil, nil
+	}
+	parts := strings.Split(definition, ",")
+	if len(parts) < 3 {
+		return nil, errors.NotValidf("partition '%s' in %s", definition, entityType.String())
+	}
+	p := &partitioning{Type: strings.ToUpper(parts[0]), Column: parts[1]}
+	field, has := entityType.FieldByName(p.Column)
+	if !has {
+		return nil, errors.NotFoundf("partition column '%s' in %s", p.Column, entityType.String())
+	}
+	switch p.Type {
+	case "RANGE":
+		p.Interval = parts[2]
+		if p.Interval != "day" && p.Interval != "month" && p.Interval != "year" {
+			return nil, errors.NotValidf("partition interval '%s' in %s", p.Interval, entityType.String())
+		}
+		_, isTimestamp := tags[p.Column]["timestamp"]
+		if field.Type.String() != "time.Time" || isTimestamp {
+			return nil, errors.NotSupportedf("range partition on column %s in %s", p.Column, entityType.String())
+		}
+	case "HASH", "KEY":
+		count, err := strconv.Atoi(parts[2])
+		if err != nil || count < 1 {
+			return nil, errors.NotValidf("partitions count '%s' in %s", parts[2], entityType.String())
+		}
+		p.Count = count
+	case "LIST":
+		for _, values := range parts[2:] {
+			p.Values = append(p.Values, strings.Fields(values))
+		}
+	default:
+		return nil, errors.NotValidf("partition type '%s' in %s", parts[0], entityType.String())
+	}
+	if primaryKey[0] != "ID" {
+		return nil, errors.NotSupportedf("partitioning in %s with primary key without ID column", entityType.String())
+	}
+	inPrimaryKey := false
+	for _, column := range primaryKey {
+		if column == p.Column {
+			inPrimaryKey = true
+		}
+	}
+	if !inPrimaryKey {
+		return nil, errors.NotValidf("partition column %s not in primary key of %s", p.Column, entityType.String())
+	}
+	uniqueIndexes := make(map[string][]string)
+	for name, values := range tags {
+		_, hasRef := values["ref"]
+		if hasRef {
+			return nil, errors.NotSupportedf("reference %s in partitioned %s", name, entityType.String())
+		}
+		unique, hasUnique := values["unique"]
+		if !hasUnique || name == "ORM" {
+			continue
+		}
+		for _, indexName := range strings.Split(unique, ",") {
+			indexName = strings.Split(indexName, ":")[0]
+			uniqueIndexes[indexName] = append(uniqueIndexes[indexName], name)
+		}
+	}
+	unique, hasUnique := tags["ORM"]["unique"]
+	if hasUnique {
+		for _, index := range strings.Split(unique, "|") {
+			def := strings.Split(index, ":")
+			if len(def) > 1 {
+				uniqueIndexes[def[0]] = append(uniqueIndexes[def[0]], strings.Split(def[1], ",")...)
+			}
+		}
+	}
+	for indexName, columns := range uniqueIndexes {
+		hasColumn := false
+		for _, column := range columns {
+			if column == p.Column {
+				hasColumn = true
+			}
+		}
+		if !hasColumn {
+			return nil, errors.NotValidf("partition column %s not in unique index %s of %s", p.Column, indexName, entityType.String())
+		}
+	}
+	return p, nil
+}
+
+func (tableSchema *tableSchema) isRangePartitionColumn(name string) bool {
+	p := tableSchema.partitioning
+	return p != nil && p.Type == "RANGE" && p.Column == name
+}
+
+func isPartitioned(registry *Registry, entityType reflect.Type) bool {
+	if entityType == nil {
+		return false
+	}
+	_, has := extractTag(registry, entityType.Field(0))["ORM"]["partition"]
+	return has
+}
+
+func (p *partitioning) buildSQL(location *time.Location) string {
+	switch p.Type {
+	case "HASH", "KEY":
+		return fmt.Sprintf("PARTITION BY %s(`%s`) PARTITIONS %d", p.Type, p.Column, p.Count)
+	case "LIST":
+		partitions := make([]string, len(p.Values))
+		for i, values := range p.Values {
+			partitions[i] = fmt.Sprintf("PARTITION p%d VALUES IN (%s)", i, buildPartitionValues(values))
+		}
+		return fmt.Sprintf("PARTITION BY LIST COLUMNS(`%s`) (%s)", p.Column, strings.Join(partitions, ", "))
+	}
+	current := p.periodStart(time.Now().In(location))
+	return fmt.Sprintf("PARTITION BY RANGE COLUMNS(`%s`) (%s)", p.Column, p.buildRangePartitions(current, p.addPeriods(current, partitionsAhead)))
+}
+
+func (p *partitioning) buildRangePartitions(from time.Time, to time.Time) string {
+	partitions := make([]string, 0)
+	for start := from; !start.After(to); start = p.addPeriods(start, 1) {
+		partitions = append(partitions, fmt.Sprintf("PARTITION p%s VALUES LESS THAN ('%s')", start.Format(p.layout()),
+			p.addPeriods(start, 1).Format("2006-01-02")))
+	}
+	partitions = append(partitions, "PARTITION pmax VALUES LESS THAN (MAXVALUE)")
+	return strings.Join(partitions, ", ")
+}
+
+func (p *partitioning) signature() string {
+	signature := fmt.Sprintf("%s:`%s`", p.Type, p.Column)
+	switch p.Type {
+	case "HASH", "KEY":
+		signature += fmt.Sprintf(":%d", p.Count)
+	case "LIST":
+		for i, values := range p.Values {
+			signature += fmt.Sprintf(":p%d=%s", i, buildPartitionValues(values))
+		}
+	case "RANGE":
+		signature += ":" + p.Interval
+	}
+	return signature
+}
+
+func buildPartitionValues(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		_, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			value = "'" + strings.Replace(value, "'", "\\'", -1) + "'"
+		}
+		quoted[i] = value
+	}
+	return strings.Join(quoted, ",")
+}
+
+func getPartitionSQLDB(createTableDB string) string {
+	start := strings.Index(createTableDB, "PARTITION BY ")
+	if start == -1 {
+		return "REMOVE PARTITIONING"
+	}
+	partitionSQL := createTableDB[start:]
+	end := strings.LastIndex(partitionSQL, "*/")
+	if end != -1 {
+		partitionSQL = partitionSQL[:end]
+	}
+	return strings.TrimSpace(partitionSQL)
+}
+
+func getPartitioningDB(createTableDB string) string {
+	matches := partitionByRegexp.FindStringSubmatch(createTableDB)
+	if matches == nil {
+		return ""
+	}
+	signature := fmt.Sprintf("%s:%s", matches[1], strings.TrimSpace(matches[2]))
+	switch matches[1] {
+	case "HASH", "KEY":
+		count := "1"
+		countMatches := partitionsCountRegexp.FindStringSubmatch(createTableDB)
+		if countMatches != nil {
+			count = countMatches[1]
+		}
+		signature += ":" + count
+	case "LIST":
+		for _, values := range partitionValuesRegexp.FindAllStringSubmatch(createTableDB, -1) {
+			signature += fmt.Sprintf(":%s=%s", values[1], strings.Replace(values[2], " ", "", -1))
+		}
+	case "RANGE":
+		interval := ""
+		rangeMatches := partitionRangeRegexp.FindStringSubmatch(createTableDB)
+		if rangeMatches != nil {
+			switch len(rangeMatches[1]) {
+			case 8:
+				interval = "day"
+			case 6:
+				interval = "month"
+			case 4:
+				interval = "year"
+			}
+		}
+		signature += ":" + interval
+	}
+	return signature
+}
+
+func (p *partitioning) getRotation(names []string, now time.Time, keep int) (from time.Time, to time.Time, dropped []string, err error) {
+	current := p.periodStart(now)
+	oldest := p.addPeriods(current, 1-keep)
+	var last time.Time
+	dropped = make([]string, 0)
+	for _, name := range names {
+		if name == "pmax" {
+			continue
+		}
+		start, err := time.ParseInLocation(p.layout(), strings.TrimPrefix(name, "p"), now.Location())
+		if err != nil {
+			return from, to, nil, errors.NotValidf("partition name '%s'", name)
+		}
+		if start.Before(oldest) {
+			dropped = append(dropped, name)
+		}
+		if start.After(last) {
+			last = start
+		}
+	}
+	from = oldest
+	if !last.IsZero() && !p.addPeriods(last, 1).Before(oldest) {
+		from = p.addPeriods(last, 1)
+	}
+	return from, p.addPeriods(current, partitionsAhead), dropped, nil
+}
+
+func (p *partitioning) layout() string {
+	switch p.Interval {
+	case "day":
+		return "20060102"
+	case "month":
+		return "200601"
+	}
+	return "2006"
+}
+
+func (p *partitioning) periodStart(t time.Time) time.Time {
+	switch p.Interval {
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+}
+
+func (p *partitioning) addPeriods(t time.Time, periods int) time.Time {
+	switch p.Interval {
+	case "day":
+		return t.AddDate(0, 0, periods)
+	case "month":
+		return t.AddDate(0, periods, 0)
+	}
+	return t.AddDate(periods, 0, 0)
+}
+
+func (e *Engine) RotatePartitions(entity Entity, keep int) {
+	schema := initIfNeeded(e, entity).tableSchema
+	p := schema.partitioning
+	if p == nil || p.Type != "RANGE" {
+		panic(errors.NotSupportedf("rotating partitions in %s without range partitioning", schema.t.String()))
+	}
+	if keep < 1 {
+		panic(errors.NotValidf("keep %d", keep))
+	}
+	pool := schema.GetMysql(e)
+	names := make([]string, 0)
+	results, def := pool.Query("SELECT `PARTITION_NAME` FROM INFORMATION_SCHEMA.PARTITIONS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? "+
+		"AND `PARTITION_NAME` IS NOT NULL ORDER BY `PARTITION_ORDINAL_POSITION`", pool.GetDatabaseName(), schema.tableName)
+	defer def()
+	for results.Next() {
+		var name string
+		results.Scan(&name)
+		names = append(names, name)
+	}
+	def()
+	from, to, dropped, err := p.getRotation(names, time.Now().In(schema.location), keep)
+	checkError(err)
+	if !from.After(to) {
+		pool.Exec(fmt.Sprintf("ALTER TABLE `%s`.`%s` REORGANIZE PARTITION pmax INTO (%s)", pool.GetDatabaseName(), schema.tableName,
+			p.buildRangePartitions(from, to)))
+	}
+	if len(dropped) > 0 {
+		pool.Exec(fmt.Sprintf("ALTER TABLE `%s`.`%s` DROP PARTITION %s", pool.GetDatabaseName(), schema.tableName,
+			strings.Join(dropped, ",")))
+	}
+}
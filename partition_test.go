@@ -0,0 +1,214 @@
+package orm
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type partitionRangeEntity struct {
+	ORM     `orm:"primaryKey=ID,Created;partition=range,Created,month"`
+	ID      uint
+	Name    string
+	Created time.Time
+}
+
+type partitionHashEntity struct {
+	ORM    `orm:"primaryKey=ID,UserID;partition=hash,UserID,4"`
+	ID     uint
+	UserID uint   `orm:"unique=UserName"`
+	Name   string `orm:"unique=UserName:2"`
+}
+
+type partitionPointerEntity struct {
+	ORM     `orm:"primaryKey=ID,Created;partition=range,Created,month"`
+	ID      uint
+	Created *time.Time
+}
+
+type partitionInvalidEntity struct {
+	ORM     `orm:"partition=range,Created,month"`
+	ID      uint
+	Created time.Time
+}
+
+func TestPartitionDefinition(t *testing.T) {
+	p, err := parsePartitioning(reflect.TypeOf(partitionHashEntity{}), map[string]map[string]string{
+		"ORM": {"partition": "hash,UserID,4"}, "UserID": {"unique": "UserName"}, "Name": {"unique": "UserName:2"}}, []string{"ID", "UserID"})
+	assert.NoError(t, err)
+	assert.Equal(t, "PARTITION BY HASH(`UserID`) PARTITIONS 4", p.buildSQL(time.UTC))
+	assert.Equal(t, p.signature(), getPartitioningDB("CREATE TABLE `a` (\n) ENGINE=InnoDB\n/*!50100 PARTITION BY HASH (`UserID`)\nPARTITIONS 4 */"))
+
+	_, err = parsePartitioning(reflect.TypeOf(partitionHashEntity{}), map[string]map[string]string{
+		"ORM": {"partition": "hash,UserID,4"}, "Name": {"unique": "Name"}}, []string{"ID", "UserID"})
+	assert.EqualError(t, err, "partition column UserID not in unique index Name of orm.partitionHashEntity not valid")
+	_, err = parsePartitioning(reflect.TypeOf(partitionHashEntity{}), map[string]map[string]string{
+		"ORM": {"partition": "hash,UserID,4"}}, []string{"ID"})
+	assert.EqualError(t, err, "partition column UserID not in primary key of orm.partitionHashEntity not valid")
+	_, err = parsePartitioning(reflect.TypeOf(partitionRangeEntity{}), map[string]map[string]string{
+		"ORM": {"partition": "range,Created,week"}}, []string{"ID", "Created"})
+	assert.EqualError(t, err, "partition interval 'week' in orm.partitionRangeEntity not valid")
+	_, err = parsePartitioning(reflect.TypeOf(partitionPointerEntity{}), map[string]map[string]string{
+		"ORM": {"partition": "range,Created,month"}}, []string{"ID", "Created"})
+	assert.EqualError(t, err, "range partition on column Created in orm.partitionPointerEntity not supported")
+
+	p = &partitioning{Type: "LIST", Column: "Region", Values: [][]string{{"1", "2"}, {"3"}}}
+	assert.Equal(t, "PARTITION BY LIST COLUMNS(`Region`) (PARTITION p0 VALUES IN (1,2), PARTITION p1 VALUES IN (3))", p.buildSQL(time.UTC))
+	assert.Equal(t, p.signature(), getPartitioningDB("/*!50500 PARTITION BY LIST  COLUMNS(`Region`)\n"+
+		"(PARTITION p0 VALUES IN (1,2) ENGINE = InnoDB,\n PARTITION p1 VALUES IN (3) ENGINE = InnoDB) */"))
+
+	p = &partitioning{Type: "RANGE", Column: "Created", Interval: "month"}
+	from := time.Date(2020, 11, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "PARTITION p202011 VALUES LESS THAN ('2020-12-01'), PARTITION p202012 VALUES LESS THAN ('2021-01-01'), "+
+		"PARTITION pmax VALUES LESS THAN (MAXVALUE)", p.buildRangePartitions(from, p.addPeriods(from, 1)))
+	assert.Equal(t, from, p.periodStart(time.Date(2020, 11, 19, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, p.signature(), getPartitioningDB("/*!50500 PARTITION BY RANGE  COLUMNS(`Created`)\n"+
+		"(PARTITION p202011 VALUES LESS THAN ('2020-12-01') ENGINE = InnoDB,\n PARTITION pmax VALUES LESS THAN (MAXVALUE) ENGINE = InnoDB) */"))
+	p.Interval = "day"
+	assert.NotEqual(t, p.signature(), getPartitioningDB("/*!50500 PARTITION BY RANGE  COLUMNS(`Created`)\n"+
+		"(PARTITION p202011 VALUES LESS THAN ('2020-12-01') ENGINE = InnoDB,\n PARTITION pmax VALUES LESS THAN (MAXVALUE) ENGINE = InnoDB) */"))
+	assert.Equal(t, "", getPartitioningDB("CREATE TABLE `a` (\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"))
+
+	p = &partitioning{Type: "LIST", Column: "Region", Values: [][]string{{"eu", "us"}, {"1.5"}}}
+	assert.Equal(t, "PARTITION BY LIST COLUMNS(`Region`) (PARTITION p0 VALUES IN ('eu','us'), PARTITION p1 VALUES IN (1.5))", p.buildSQL(time.UTC))
+	assert.Equal(t, p.signature(), getPartitioningDB("/*!50500 PARTITION BY LIST  COLUMNS(`Region`)\n"+
+		"(PARTITION p0 VALUES IN ('eu','us') ENGINE = InnoDB,\n PARTITION p1 VALUES IN (1.5) ENGINE = InnoDB) */"))
+}
+
+func TestPartitionRangeBuild(t *testing.T) {
+	p := &partitioning{Type: "RANGE", Column: "Created", Interval: "day"}
+	from := time.Date(2020, 12, 30, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "PARTITION p20201230 VALUES LESS THAN ('2020-12-31'), PARTITION p20201231 VALUES LESS THAN ('2021-01-01'), "+
+		"PARTITION p20210101 VALUES LESS THAN ('2021-01-02'), PARTITION pmax VALUES LESS THAN (MAXVALUE)", p.buildRangePartitions(from, p.addPeriods(from, 2)))
+	p.Interval = "year"
+	assert.Equal(t, "PARTITION p2020 VALUES LESS THAN ('2021-01-01'), PARTITION pmax VALUES LESS THAN (MAXVALUE)",
+		p.buildRangePartitions(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "PARTITION pmax VALUES LESS THAN (MAXVALUE)",
+		p.buildRangePartitions(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	createTable := "CREATE TABLE `partitionRangeEntity` (\n  `ID` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `Name` varchar(255) DEFAULT NULL,\n  `Created` date NOT NULL,\n  PRIMARY KEY (`ID`,`Created`)\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=2 DEFAULT CHARSET=utf8mb4\n" +
+		"/*!50500 PARTITION BY RANGE  COLUMNS(`Created`)\n" +
+		"(PARTITION p202011 VALUES LESS THAN ('2020-12-01') ENGINE = InnoDB,\n" +
+		" PARTITION p202012 VALUES LESS THAN ('2021-01-01') ENGINE = InnoDB,\n" +
+		" PARTITION p202101 VALUES LESS THAN ('2021-02-01') ENGINE = InnoDB,\n" +
+		" PARTITION pmax VALUES LESS THAN (MAXVALUE) ENGINE = InnoDB) */"
+	assert.Equal(t, "RANGE:`Created`:month", getPartitioningDB(createTable))
+	p = &partitioning{Type: "RANGE", Column: "Created", Interval: "month"}
+	assert.Equal(t, p.signature(), getPartitioningDB(createTable))
+	p.Interval = "year"
+	assert.NotEqual(t, p.signature(), getPartitioningDB(createTable))
+	createTable = "CREATE TABLE `partitionHashEntity` (\n  `ID` int(10) unsigned NOT NULL AUTO_INCREMENT,\n" +
+		"  `UserID` int(10) unsigned NOT NULL DEFAULT '0',\n  PRIMARY KEY (`ID`,`UserID`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4\n/*!50100 PARTITION BY HASH (`UserID`)\nPARTITIONS 4 */"
+	assert.Equal(t, "HASH:`UserID`:4", getPartitioningDB(createTable))
+}
+
+func TestPartitionRotation(t *testing.T) {
+	p := &partitioning{Type: "RANGE", Column: "Created", Interval: "month"}
+	now := time.Date(2020, 11, 19, 10, 0, 0, 0, time.UTC)
+	month := func(year int, month time.Month) time.Time {
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	from, to, dropped, err := p.getRotation([]string{"p202011", "p202012", "p202101", "pmax"}, now, 3)
+	assert.NoError(t, err)
+	assert.True(t, from.After(to))
+	assert.Len(t, dropped, 0)
+
+	from, to, dropped, err = p.getRotation([]string{"p202009", "p202010", "p202011", "p202012", "p202101", "pmax"}, now, 3)
+	assert.NoError(t, err)
+	assert.Len(t, dropped, 0)
+	assert.True(t, from.After(to))
+	_, _, dropped, _ = p.getRotation([]string{"p202009", "p202010", "p202011", "p202012", "p202101", "pmax"}, now, 2)
+	assert.Equal(t, []string{"p202009"}, dropped)
+
+	from, to, dropped, err = p.getRotation([]string{"p202011", "p202012", "p202101", "pmax"}, time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, month(2021, 2), from)
+	assert.Equal(t, month(2021, 4), to)
+	assert.Equal(t, []string{"p202011"}, dropped)
+
+	from, to, dropped, err = p.getRotation([]string{"p202001", "p202002", "pmax"}, now, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, month(2020, 9), from)
+	assert.Equal(t, month(2021, 1), to)
+	assert.Equal(t, []string{"p202001", "p202002"}, dropped)
+
+	from, _, _, err = p.getRotation([]string{"pmax"}, now, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, month(2020, 11), from)
+
+	_, _, _, err = p.getRotation([]string{"p0", "pmax"}, now, 1)
+	assert.EqualError(t, err, "partition name 'p0' not valid")
+}
+
+func TestPartitioning(t *testing.T) {
+	var entity *partitionRangeEntity
+	var entityHash *partitionHashEntity
+	engine := PrepareTables(t, &Registry{}, entity, entityHash)
+	alters := engine.GetAlters()
+	assert.Len(t, alters, 0)
+
+	now := time.Now()
+	engine.TrackAndFlush(&partitionRangeEntity{Name: "a", Created: now}, &partitionHashEntity{UserID: 10, Name: "b"})
+	loaded := &partitionRangeEntity{}
+	assert.True(t, engine.LoadByID(1, loaded))
+	assert.Equal(t, "a", loaded.Name)
+	loadedHash := &partitionHashEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loadedHash, 1, 10))
+	assert.Equal(t, "b", loadedHash.Name)
+
+	pool := engine.GetMysql()
+	var partitions int
+	query := "SELECT COUNT(*) FROM INFORMATION_SCHEMA.PARTITIONS WHERE TABLE_SCHEMA = 'test' AND TABLE_NAME = ?"
+	pool.QueryRow(NewWhere(query, "partitionRangeEntity"), &partitions)
+	assert.Equal(t, partitionsAhead+2, partitions)
+	pool.QueryRow(NewWhere(query, "partitionHashEntity"), &partitions)
+	assert.Equal(t, 4, partitions)
+
+	p := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema).partitioning
+	current := p.periodStart(now)
+	old := p.addPeriods(current, -5)
+	pool.Exec(fmt.Sprintf("ALTER TABLE `partitionRangeEntity` REORGANIZE PARTITION p%s INTO "+
+		"(PARTITION p%s VALUES LESS THAN ('%s'), PARTITION p%s VALUES LESS THAN ('%s'))", current.Format("200601"),
+		old.Format("200601"), current.Format("2006-01-02"), current.Format("200601"), p.addPeriods(current, 1).Format("2006-01-02")))
+	pool.Exec(fmt.Sprintf("ALTER TABLE `partitionRangeEntity` DROP PARTITION p%s", p.addPeriods(current, partitionsAhead).Format("200601")))
+	pool.QueryRow(NewWhere(query, "partitionRangeEntity"), &partitions)
+	assert.Equal(t, partitionsAhead+2, partitions)
+	engine.RotatePartitions(loaded, 3)
+	pool.QueryRow(NewWhere(query, "partitionRangeEntity"), &partitions)
+	assert.Equal(t, partitionsAhead+2, partitions)
+	var name string
+	pool.QueryRow(NewWhere("SELECT `PARTITION_NAME` FROM INFORMATION_SCHEMA.PARTITIONS WHERE TABLE_SCHEMA = 'test' "+
+		"AND TABLE_NAME = 'partitionRangeEntity' ORDER BY `PARTITION_ORDINAL_POSITION` LIMIT 1"), &name)
+	assert.Equal(t, "p"+current.Format("200601"), name)
+	assert.True(t, engine.LoadByID(1, &partitionRangeEntity{}))
+	assert.Len(t, engine.GetAlters(), 0)
+
+	assert.Panics(t, func() {
+		engine.RotatePartitions(loadedHash, 3)
+	})
+
+	pool.Exec("ALTER TABLE `partitionHashEntity` REMOVE PARTITIONING")
+	alters = engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.False(t, alters[0].Safe)
+	assert.Contains(t, alters[0].SQL, "PARTITION BY HASH")
+	pool.Exec("DELETE FROM `partitionHashEntity`")
+	alters = engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.True(t, alters[0].Safe)
+	pool.Exec(alters[0].SQL)
+	assert.Len(t, engine.GetAlters(), 0)
+
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterEntity(&partitionInvalidEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "partition column Created not in primary key of orm.partitionInvalidEntity not valid")
+}
@@ -0,0 +1,274 @@
+package orm
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+)
+
+const primaryKeyUUID = "uuid"
+const primaryKeyULID = "ulid"
+const primaryKeyUUIDBinary = "uuidBinary"
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func NewUUID() string {
+	b := newUUIDBytes()
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func NewULID() string {
+	var b [16]byte
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	for i := 0; i < 6; i++ {
+		b[i] = byte(ms >> (40 - 8*uint(i)))
+	}
+	_, err := rand.Read(b[6:])
+	checkError(err)
+	result := make([]byte, 26)
+	// 128 bits encoded as 26 base32 characters, first character holds 3 bits
+	bits := uint(0)
+	buffer := uint64(0)
+	j := 25
+	for i := 15; i >= 0; i-- {
+		buffer |= uint64(b[i]) << bits
+		bits += 8
+		for bits >= 5 && j >= 0 {
+			result[j] = crockfordAlphabet[buffer&31]
+			buffer >>= 5
+			bits -= 5
+			j--
+		}
+	}
+	if j == 0 {
+		result[0] = crockfordAlphabet[buffer&31]
+	}
+	return string(result)
+}
+
+func newUUIDBytes() []byte {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	checkError(err)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return b
+}
+
+func (e *Engine) LoadByPrimaryKey(entity Entity, key ...interface{}) (found bool) {
+	orm := initIfNeeded(e, entity)
+	schema := orm.tableSchema
+	if len(key) != len(schema.primaryKey) {
+		panic(errors.Errorf("primary key in %s has %d columns, %d values provided", schema.t.String(), len(schema.primaryKey), len(key)))
+	}
+	if len(schema.primaryKey) > 1 || schema.primaryKey[0] != "ID" {
+		values := make([]interface{}, len(key))
+		for i := range key {
+			values[i] = key[i]
+			asBytes, isBytes := key[i].([]byte)
+			if isBytes {
+				values[i] = string(asBytes)
+			}
+		}
+		return loadByCompositeKey(e, entity, values)
+	}
+	id := normalizePrimaryKey(schema, key[0])
+	if id == uint64(0) || id == "" {
+		return false
+	}
+	return loadByID(e, id, entity, true)
+}
+
+func loadByCompositeKey(engine *Engine, entity Entity, key []interface{}) bool {
+	orm := entity.getORM()
+	schema := orm.tableSchema
+	conditions := make([]string, len(key))
+	for i, column := range schema.primaryKey {
+		conditions[i] = fmt.Sprintf("`%s` = ?", column)
+	}
+	where := NewWhere(strings.Join(conditions, " AND "), key...)
+	localCache, hasLocalCache := schema.GetLocalCache(engine)
+	redisCache, hasRedis := schema.GetRedisCache(engine)
+	if !hasLocalCache && !hasRedis {
+		return searchRow(false, engine, where, entity, nil)
+	}
+	// cache keeps only ID of the row, entity is loaded by ID and checked if it still has the same key
+	cacheKey := schema.getCompositeKeyCacheKey(key)
+	var id string
+	has := false
+	if hasLocalCache {
+		var value interface{}
+		value, has = localCache.Get(cacheKey)
+		if has {
+			id = value.(string)
+		}
+	}
+	if !has && hasRedis {
+		id, has = redisCache.Get(cacheKey)
+	}
+	if has && loadByID(engine, parsePrimaryKey(schema, id), entity, true) && schema.hasCompositeKey(orm.attributes.elem, key) {
+		return true
+	}
+	if !searchRow(false, engine, where, entity, nil) {
+		return false
+	}
+	id = fmt.Sprintf("%v", orm.getPrimaryKey())
+	if hasLocalCache {
+		localCache.Set(cacheKey, schema.getLocalCacheValue(id, false))
+	}
+	if hasRedis {
+		redisCache.Set(cacheKey, id, schema.getRedisCacheTTL(false))
+	}
+	return true
+}
+
+func (tableSchema *tableSchema) getCompositeKeyCacheKey(key []interface{}) string {
+	values := make([]string, len(key))
+	for i, value := range key {
+		values[i] = fmt.Sprintf("%v", value)
+	}
+	return tableSchema.cachePrefix + ":" + tableSchema.columnsStamp + ":pk:" + hex.EncodeToString([]byte(strings.Join(values, "\x00")))
+}
+
+func (tableSchema *tableSchema) hasCompositeKey(elem reflect.Value, key []interface{}) bool {
+	for i, column := range tableSchema.primaryKey {
+		value := elem.FieldByName(column).Interface()
+		asBytes, isBytes := value.([]byte)
+		if isBytes {
+			value = string(asBytes)
+		}
+		if fmt.Sprintf("%v", value) != fmt.Sprintf("%v", key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (tableSchema *tableSchema) checkIntegerPrimaryKey() {
+	if tableSchema.primaryKeyType != "" {
+		panic(errors.NotSupportedf("uint64 ID in %s with %s primary key", tableSchema.t.String(), tableSchema.primaryKeyType))
+	}
+}
+
+func getPrimaryKeyType(entityType reflect.Type, tags map[string]string) (string, error) {
+	idField := entityType.Field(1)
+	_, isUUID := tags[primaryKeyUUID]
+	_, isULID := tags[primaryKeyULID]
+	switch idField.Type.String() {
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		if isUUID || isULID {
+			return "", errors.Errorf("uuid and ulid primary key in %s requires string ID", entityType.String())
+		}
+		return "", nil
+	case "string":
+		if isUUID {
+			return primaryKeyUUID, nil
+		} else if isULID {
+			return primaryKeyULID, nil
+		}
+	case "[]uint8":
+		if isUUID {
+			return primaryKeyUUIDBinary, nil
+		}
+	}
+	return "", errors.Errorf("ID in %s must be uint, string with uuid or ulid tag or []byte with uuid tag", entityType.String())
+}
+
+func hasIntegerPrimaryKey(entityType reflect.Type) bool {
+	if entityType.NumField() < 2 {
+		return false
+	}
+	switch entityType.Field(1).Type.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func (tableSchema *tableSchema) getPrimaryKeyDefinition() string {
+	switch tableSchema.primaryKeyType {
+	case primaryKeyUUID:
+		return "`ID` char(36) NOT NULL"
+	case primaryKeyULID:
+		return "`ID` char(26) NOT NULL"
+	case primaryKeyUUIDBinary:
+		return "`ID` binary(16) NOT NULL"
+	}
+	return ""
+}
+
+func (tableSchema *tableSchema) generatePrimaryKey() string {
+	switch tableSchema.primaryKeyType {
+	case primaryKeyUUID:
+		return NewUUID()
+	case primaryKeyULID:
+		return NewULID()
+	}
+	return string(newUUIDBytes())
+}
+
+func (orm *ORM) getPrimaryKey() interface{} {
+	if orm.tableSchema.primaryKeyType == "" {
+		return orm.GetID()
+	}
+	if orm.tableSchema.primaryKeyType == primaryKeyUUIDBinary {
+		return string(orm.attributes.idElem.Bytes())
+	}
+	return orm.attributes.idElem.String()
+}
+
+func setPrimaryKey(orm *ORM, id interface{}) {
+	switch v := id.(type) {
+	case uint64:
+		orm.attributes.idElem.SetUint(v)
+	case string:
+		if orm.tableSchema.primaryKeyType == primaryKeyUUIDBinary {
+			orm.attributes.idElem.SetBytes([]byte(v))
+		} else {
+			orm.attributes.idElem.SetString(v)
+		}
+	}
+}
+
+func parsePrimaryKey(tableSchema *tableSchema, value string) interface{} {
+	if tableSchema.primaryKeyType != "" {
+		return value
+	}
+	id, _ := strconv.ParseUint(value, 10, 64)
+	return id
+}
+
+func normalizePrimaryKey(tableSchema *tableSchema, key interface{}) interface{} {
+	if tableSchema.primaryKeyType == "" {
+		return convertStringToUint(fmt.Sprintf("%v", key))
+	}
+	asBytes, isBytes := key.([]byte)
+	if isBytes {
+		return string(asBytes)
+	}
+	return fmt.Sprintf("%v", key)
+}
+
+func integerKey(id interface{}) uint64 {
+	asUint, _ := id.(uint64)
+	return asUint
+}
+
+func formatPrimaryKey(tableSchema *tableSchema, id interface{}) string {
+	switch v := id.(type) {
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case string:
+		if tableSchema.primaryKeyType == primaryKeyUUIDBinary {
+			return hex.EncodeToString([]byte(v))
+		}
+		return v
+	}
+	return fmt.Sprintf("%v", id)
+}
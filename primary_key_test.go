@@ -0,0 +1,195 @@
+package orm
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type uuidEntity struct {
+	ORM  `orm:"localCache;redisCache"`
+	ID   string `orm:"uuid"`
+	Name string `orm:"unique=Name"`
+}
+
+type ulidEntity struct {
+	ORM  `orm:"redisCache"`
+	ID   string `orm:"ulid"`
+	Name string
+}
+
+type binaryUUIDEntity struct {
+	ORM  `orm:"localCache"`
+	ID   []byte `orm:"uuid"`
+	Name string
+}
+
+type compositeKeyEntity struct {
+	ORM     `orm:"primaryKey=UserID,GroupID;localCache"`
+	ID      uint
+	UserID  uint
+	GroupID uint
+	Role    string
+}
+
+type invalidUUIDEntity struct {
+	ORM `orm:"log"`
+	ID  string `orm:"uuid"`
+}
+
+func TestNewUUIDAndULID(t *testing.T) {
+	uuid := NewUUID()
+	assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), uuid)
+	assert.NotEqual(t, uuid, NewUUID())
+
+	ulid := NewULID()
+	assert.Regexp(t, regexp.MustCompile("^[0-7][0-9A-HJKMNP-TV-Z]{25}$"), ulid)
+	assert.NotEqual(t, ulid, NewULID())
+	assert.True(t, ulid[0:10] <= NewULID()[0:10])
+}
+
+func TestPrimaryKeyUUID(t *testing.T) {
+	var entity *uuidEntity
+	var entityULID *ulidEntity
+	var entityBinary *binaryUUIDEntity
+	engine := PrepareTables(t, &Registry{}, entity, entityULID, entityBinary)
+
+	entity = &uuidEntity{Name: "a"}
+	engine.TrackAndFlush(entity)
+	assert.Len(t, entity.ID, 36)
+	assert.Equal(t, uint64(0), entity.GetID())
+	assert.True(t, engine.Loaded(entity))
+
+	withKey := &uuidEntity{ID: NewUUID(), Name: "b"}
+	engine.TrackAndFlush(withKey)
+
+	loaded := &uuidEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loaded, entity.ID))
+	assert.Equal(t, entity.ID, loaded.ID)
+	assert.Equal(t, "a", loaded.Name)
+	assert.False(t, engine.LoadByPrimaryKey(&uuidEntity{}, NewUUID()))
+
+	loaded.Name = "a2"
+	engine.TrackAndFlush(loaded)
+	loaded = &uuidEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loaded, entity.ID))
+	assert.Equal(t, "a2", loaded.Name)
+
+	var all []*uuidEntity
+	engine.Search(NewWhere("1 ORDER BY `Name`"), nil, &all)
+	assert.Len(t, all, 2)
+	assert.Equal(t, entity.ID, all[0].ID)
+	assert.Equal(t, withKey.ID, all[1].ID)
+
+	engine.MarkToDelete(loaded)
+	engine.Flush()
+	assert.False(t, engine.LoadByPrimaryKey(&uuidEntity{}, entity.ID))
+
+	entityULID = &ulidEntity{Name: "c"}
+	engine.TrackAndFlush(entityULID)
+	assert.Len(t, entityULID.ID, 26)
+	loadedULID := &ulidEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loadedULID, entityULID.ID))
+	assert.Equal(t, "c", loadedULID.Name)
+
+	entityULID = &ulidEntity{Name: "lazy"}
+	engine.Track(entityULID)
+	engine.FlushLazy()
+	assert.Len(t, entityULID.ID, 26)
+	receiver := NewLazyReceiver(engine)
+	receiver.DisableLoop()
+	receiver.Digest()
+	loadedULID = &ulidEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loadedULID, entityULID.ID))
+	assert.Equal(t, "lazy", loadedULID.Name)
+
+	schemaULID := engine.GetRegistry().GetTableSchemaForEntity(entityULID).(*tableSchema)
+	entityULID = &ulidEntity{Name: "lazy redis"}
+	engine.Track(entityULID)
+	engine.FlushLazy()
+	cacheKey := schemaULID.getCacheKey(entityULID.ID)
+	assert.False(t, engine.LoadByPrimaryKey(&ulidEntity{}, entityULID.ID))
+	cached, has := engine.GetRedis().Get(cacheKey)
+	assert.True(t, has)
+	assert.Equal(t, "nil", cached)
+	receiver.Digest()
+	_, has = engine.GetRedis().Get(cacheKey)
+	assert.False(t, has)
+	loadedULID = &ulidEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loadedULID, entityULID.ID))
+	assert.Equal(t, "lazy redis", loadedULID.Name)
+	_, has = engine.GetRedis().Get(cacheKey)
+	assert.True(t, has)
+
+	assert.PanicsWithError(t, "uint64 ID in orm.ulidEntity with ulid primary key not supported", func() {
+		engine.LoadByID(1, &ulidEntity{})
+	})
+	assert.PanicsWithError(t, "uint64 ID in orm.ulidEntity with ulid primary key not supported", func() {
+		var rows []*ulidEntity
+		engine.LoadByIDs([]uint64{1}, &rows)
+	})
+	assert.PanicsWithError(t, "uint64 ID in orm.ulidEntity with ulid primary key not supported", func() {
+		engine.SearchIDs(NewWhere("1"), nil, &ulidEntity{})
+	})
+	assert.PanicsWithError(t, "uint64 ID in orm.ulidEntity with ulid primary key not supported", func() {
+		engine.ClearByIDs(&ulidEntity{}, 1)
+	})
+
+	entityBinary = &binaryUUIDEntity{Name: "d"}
+	engine.TrackAndFlush(entityBinary)
+	assert.Len(t, entityBinary.ID, 16)
+	loadedBinary := &binaryUUIDEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loadedBinary, entityBinary.ID))
+	assert.Equal(t, entityBinary.ID, loadedBinary.ID)
+	assert.Equal(t, "d", loadedBinary.Name)
+
+	assert.PanicsWithError(t, "lazy flush with binary primary key not supported", func() {
+		engine.Track(&binaryUUIDEntity{Name: "e"})
+		engine.FlushLazy()
+	})
+}
+
+func TestPrimaryKeyComposite(t *testing.T) {
+	var entity *compositeKeyEntity
+	engine := PrepareTables(t, &Registry{}, entity)
+
+	engine.TrackAndFlush(&compositeKeyEntity{UserID: 1, GroupID: 2, Role: "admin"}, &compositeKeyEntity{UserID: 1, GroupID: 3, Role: "user"})
+
+	loaded := &compositeKeyEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loaded, 1, 3))
+	assert.Equal(t, "user", loaded.Role)
+	assert.True(t, loaded.GetID() > 0)
+	assert.False(t, engine.LoadByPrimaryKey(&compositeKeyEntity{}, 2, 3))
+	assert.Panics(t, func() {
+		engine.LoadByPrimaryKey(&compositeKeyEntity{}, 1)
+	})
+
+	loaded.Role = "owner"
+	engine.TrackAndFlush(loaded)
+	loadedByID := &compositeKeyEntity{}
+	assert.True(t, engine.LoadByID(loaded.GetID(), loadedByID))
+	assert.Equal(t, "owner", loadedByID.Role)
+
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	cached, has := engine.GetLocalCache().Get(schema.getCompositeKeyCacheKey([]interface{}{1, 3}))
+	assert.True(t, has)
+	assert.Equal(t, strconv.FormatUint(loaded.GetID(), 10), cached)
+	loaded = &compositeKeyEntity{}
+	assert.True(t, engine.LoadByPrimaryKey(loaded, 1, 3))
+	assert.Equal(t, "owner", loaded.Role)
+	loaded.GroupID = 4
+	engine.TrackAndFlush(loaded)
+	assert.False(t, engine.LoadByPrimaryKey(&compositeKeyEntity{}, 1, 3))
+	assert.True(t, engine.LoadByPrimaryKey(&compositeKeyEntity{}, 1, 4))
+
+	alters := engine.GetAlters()
+	assert.Len(t, alters, 0)
+
+	registry := &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterEntity(&invalidUUIDEntity{})
+	_, err := registry.Validate()
+	assert.EqualError(t, err, "log in orm.invalidUUIDEntity with uuid primary key not supported")
+}
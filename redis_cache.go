@@ -2,9 +2,12 @@ package orm
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
+	"github.com/juju/errors"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/go-redis/redis_rate/v9"
@@ -13,6 +16,7 @@ import (
 const counterRedisAll = "redis.all"
 const counterRedisKeysSet = "redis.keysSet"
 const counterRedisKeysGet = "redis.keysGet"
+const RedisNoExpiration time.Duration = -1
 
 type redisClient interface {
 	Get(key string) (string, error)
@@ -42,6 +46,21 @@ type redisClient interface {
 	MGet(keys ...string) ([]interface{}, error)
 	Set(key string, value interface{}, expiration time.Duration) error
 	MSet(pairs ...interface{}) error
+	Pipelined(fn func(pipeline redis.Pipeliner) error, tx bool) ([]redis.Cmder, error)
+	Expire(key string, expiration time.Duration) (bool, error)
+	TTL(key string) (time.Duration, error)
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+	GetSet(key string, value interface{}) (string, error)
+	IncrBy(key string, incr int64) (int64, error)
+	HIncrBy(key, field string, incr int64) (int64, error)
+	Exists(keys ...string) (int64, error)
+	ZRem(key string, members ...interface{}) (int64, error)
+	ZRangeByScore(key string, opt *redis.ZRangeBy) ([]string, error)
+	SMembers(key string) ([]string, error)
+	SIsMember(key string, member interface{}) (bool, error)
+	HDel(key string, fields ...string) (int64, error)
+	Scan(cursor uint64, match string, count int64) ([]string, uint64, error)
+	RunScript(script *redis.Script, keys []string, args ...interface{}) (interface{}, error)
 	Del(keys ...string) error
 	PSubscribe(channels ...string) *redis.PubSub
 	Subscribe(channels ...string) *redis.PubSub
@@ -51,8 +70,7 @@ type redisClient interface {
 }
 
 type standardRedisClient struct {
-	client *redis.Client
-	ring   *redis.Ring
+	client redis.UniversalClient
 }
 
 type PubSub struct {
@@ -156,236 +174,276 @@ func (p *PubSub) String() string {
 }
 
 func (c *standardRedisClient) Get(key string) (string, error) {
-	if c.ring != nil {
-		return c.ring.Get(c.ring.Context(), key).Result()
-	}
 	return c.client.Get(c.client.Context(), key).Result()
 }
 
 func (c *standardRedisClient) LRange(key string, start, stop int64) ([]string, error) {
-	if c.ring != nil {
-		return c.ring.LRange(c.ring.Context(), key, start, stop).Result()
-	}
 	return c.client.LRange(c.client.Context(), key, start, stop).Result()
 }
 
 func (c *standardRedisClient) HMGet(key string, fields ...string) ([]interface{}, error) {
-	if c.ring != nil {
-		return c.ring.HMGet(c.ring.Context(), key, fields...).Result()
-	}
 	return c.client.HMGet(c.client.Context(), key, fields...).Result()
 }
 
 func (c *standardRedisClient) HGetAll(key string) (map[string]string, error) {
-	if c.ring != nil {
-		return c.ring.HGetAll(c.ring.Context(), key).Result()
-	}
 	return c.client.HGetAll(c.client.Context(), key).Result()
 }
 
 func (c *standardRedisClient) LPush(key string, values ...interface{}) (int64, error) {
-	if c.ring != nil {
-		return c.ring.LPush(c.ring.Context(), key, values...).Result()
-	}
 	return c.client.LPush(c.client.Context(), key, values...).Result()
 }
 
 func (c *standardRedisClient) RPush(key string, values ...interface{}) (int64, error) {
-	if c.ring != nil {
-		return c.ring.RPush(c.ring.Context(), key, values...).Result()
-	}
 	return c.client.RPush(c.client.Context(), key, values...).Result()
 }
 
 func (c *standardRedisClient) RPop(key string) (string, error) {
-	if c.ring != nil {
-		return c.ring.RPop(c.ring.Context(), key).Result()
-	}
 	return c.client.RPop(c.client.Context(), key).Result()
 }
 
 func (c *standardRedisClient) LSet(key string, index int64, value interface{}) (string, error) {
-	if c.ring != nil {
-		return c.ring.LSet(c.ring.Context(), key, index, value).Result()
-	}
 	return c.client.LSet(c.client.Context(), key, index, value).Result()
 }
 
 func (c *standardRedisClient) LRem(key string, count int64, value interface{}) (int64, error) {
-	if c.ring != nil {
-		return c.ring.LRem(c.ring.Context(), key, count, value).Result()
-	}
 	return c.client.LRem(c.client.Context(), key, count, value).Result()
 }
 
 func (c *standardRedisClient) LTrim(key string, start, stop int64) (string, error) {
-	if c.ring != nil {
-		return c.ring.LTrim(c.ring.Context(), key, start, stop).Result()
-	}
 	return c.client.LTrim(c.client.Context(), key, start, stop).Result()
 }
 
 func (c *standardRedisClient) ZCard(key string) (int64, error) {
-	if c.ring != nil {
-		return c.ring.ZCard(c.ring.Context(), key).Result()
-	}
 	return c.client.ZCard(c.client.Context(), key).Result()
 }
 
 func (c *standardRedisClient) SCard(key string) (int64, error) {
-	if c.ring != nil {
-		return c.ring.SCard(c.ring.Context(), key).Result()
-	}
 	return c.client.SCard(c.client.Context(), key).Result()
 }
 
 func (c *standardRedisClient) ZCount(key string, min, max string) (int64, error) {
-	if c.ring != nil {
-		return c.ring.ZCount(c.ring.Context(), key, min, max).Result()
-	}
 	return c.client.ZCount(c.client.Context(), key, min, max).Result()
 }
 
 func (c *standardRedisClient) ZScore(key, member string) (float64, error) {
-	if c.ring != nil {
-		return c.ring.ZScore(c.ring.Context(), key, member).Result()
-	}
 	return c.client.ZScore(c.client.Context(), key, member).Result()
 }
 
 func (c *standardRedisClient) ZRevRange(key string, start, stop int64) ([]string, error) {
-	if c.ring != nil {
-		return c.ring.ZRevRange(c.ring.Context(), key, start, stop).Result()
-	}
 	return c.client.ZRevRange(c.client.Context(), key, start, stop).Result()
 }
 
 func (c *standardRedisClient) ZRangeWithScores(key string, start, stop int64) ([]redis.Z, error) {
-	if c.ring != nil {
-		return c.ring.ZRangeWithScores(c.ring.Context(), key, start, stop).Result()
-	}
 	return c.client.ZRangeWithScores(c.client.Context(), key, start, stop).Result()
 }
 
 func (c *standardRedisClient) ZRevRangeWithScores(key string, start, stop int64) ([]redis.Z, error) {
-	if c.ring != nil {
-		return c.ring.ZRevRangeWithScores(c.ring.Context(), key, start, stop).Result()
-	}
 	return c.client.ZRevRangeWithScores(c.client.Context(), key, start, stop).Result()
 }
 
 func (c *standardRedisClient) SPop(key string) (string, error) {
-	if c.ring != nil {
-		return c.ring.SPop(c.ring.Context(), key).Result()
-	}
 	return c.client.SPop(c.client.Context(), key).Result()
 }
 
 func (c *standardRedisClient) SPopN(key string, max int64) ([]string, error) {
-	if c.ring != nil {
-		return c.ring.SPopN(c.ring.Context(), key, max).Result()
-	}
 	return c.client.SPopN(c.client.Context(), key, max).Result()
 }
 
 func (c *standardRedisClient) LLen(key string) (int64, error) {
-	if c.ring != nil {
-		return c.ring.LLen(c.ring.Context(), key).Result()
-	}
 	return c.client.LLen(c.client.Context(), key).Result()
 }
 
 func (c *standardRedisClient) ZAdd(key string, members ...*redis.Z) (int64, error) {
-	if c.ring != nil {
-		return c.ring.ZAdd(c.ring.Context(), key, members...).Result()
-	}
 	return c.client.ZAdd(c.client.Context(), key, members...).Result()
 }
 
 func (c *standardRedisClient) SAdd(key string, members ...interface{}) (int64, error) {
-	if c.ring != nil {
-		return c.ring.SAdd(c.ring.Context(), key, members...).Result()
-	}
 	return c.client.SAdd(c.client.Context(), key, members...).Result()
 }
 
 func (c *standardRedisClient) HMSet(key string, fields map[string]interface{}) (bool, error) {
-	if c.ring != nil {
-		return c.ring.HMSet(c.ring.Context(), key, fields).Result()
-	}
 	return c.client.HMSet(c.client.Context(), key, fields).Result()
 }
 
 func (c *standardRedisClient) HSet(key string, field string, value interface{}) (int64, error) {
-	if c.ring != nil {
-		return c.ring.HSet(c.ring.Context(), key, field, value).Result()
-	}
 	return c.client.HSet(c.client.Context(), key, field, value).Result()
 }
 
 func (c *standardRedisClient) MGet(keys ...string) ([]interface{}, error) {
-	if c.ring != nil {
-		return c.ring.MGet(c.ring.Context(), keys...).Result()
+	if len(keys) < 2 || !c.isMultiNode() {
+		return c.client.MGet(c.client.Context(), keys...).Result()
+	}
+	ctx := c.client.Context()
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipeline redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipeline.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(keys))
+	for i, cmd := range cmds {
+		if cmd.Err() == nil {
+			values[i] = cmd.Val()
+		}
 	}
-	return c.client.MGet(c.client.Context(), keys...).Result()
+	return values, nil
 }
 
 func (c *standardRedisClient) Set(key string, value interface{}, expiration time.Duration) error {
-	if c.ring != nil {
-		return c.ring.Set(c.ring.Context(), key, value, expiration).Err()
-	}
 	return c.client.Set(c.client.Context(), key, value, expiration).Err()
 }
 
 func (c *standardRedisClient) MSet(pairs ...interface{}) error {
-	if c.ring != nil {
-		return c.ring.MSet(c.ring.Context(), pairs...).Err()
+	if len(pairs) < 4 || !c.isMultiNode() {
+		return c.client.MSet(c.client.Context(), pairs...).Err()
 	}
-	return c.client.MSet(c.client.Context(), pairs...).Err()
+	ctx := c.client.Context()
+	_, err := c.client.Pipelined(ctx, func(pipeline redis.Pipeliner) error {
+		for i := 0; i+1 < len(pairs); i += 2 {
+			pipeline.Set(ctx, fmt.Sprintf("%v", pairs[i]), pairs[i+1], 0)
+		}
+		return nil
+	})
+	return err
+}
+
+func (c *standardRedisClient) Pipelined(fn func(pipeline redis.Pipeliner) error, tx bool) ([]redis.Cmder, error) {
+	if tx {
+		return c.client.TxPipelined(c.client.Context(), fn)
+	}
+	return c.client.Pipelined(c.client.Context(), fn)
+}
+
+func (c *standardRedisClient) Expire(key string, expiration time.Duration) (bool, error) {
+	return c.client.Expire(c.client.Context(), key, expiration).Result()
+}
+
+func (c *standardRedisClient) TTL(key string) (time.Duration, error) {
+	return c.client.TTL(c.client.Context(), key).Result()
+}
+
+func (c *standardRedisClient) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return c.client.SetNX(c.client.Context(), key, value, expiration).Result()
+}
+
+func (c *standardRedisClient) GetSet(key string, value interface{}) (string, error) {
+	return c.client.GetSet(c.client.Context(), key, value).Result()
+}
+
+func (c *standardRedisClient) IncrBy(key string, incr int64) (int64, error) {
+	return c.client.IncrBy(c.client.Context(), key, incr).Result()
+}
+
+func (c *standardRedisClient) HIncrBy(key, field string, incr int64) (int64, error) {
+	return c.client.HIncrBy(c.client.Context(), key, field, incr).Result()
+}
+
+func (c *standardRedisClient) Exists(keys ...string) (int64, error) {
+	if len(keys) < 2 || !c.isMultiNode() {
+		return c.client.Exists(c.client.Context(), keys...).Result()
+	}
+	ctx := c.client.Context()
+	cmds := make([]*redis.IntCmd, len(keys))
+	_, err := c.client.Pipelined(ctx, func(pipeline redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipeline.Exists(ctx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	total := int64(0)
+	for _, cmd := range cmds {
+		total += cmd.Val()
+	}
+	return total, nil
+}
+
+func (c *standardRedisClient) ZRem(key string, members ...interface{}) (int64, error) {
+	return c.client.ZRem(c.client.Context(), key, members...).Result()
+}
+
+func (c *standardRedisClient) ZRangeByScore(key string, opt *redis.ZRangeBy) ([]string, error) {
+	return c.client.ZRangeByScore(c.client.Context(), key, opt).Result()
+}
+
+func (c *standardRedisClient) SMembers(key string) ([]string, error) {
+	return c.client.SMembers(c.client.Context(), key).Result()
+}
+
+func (c *standardRedisClient) SIsMember(key string, member interface{}) (bool, error) {
+	return c.client.SIsMember(c.client.Context(), key, member).Result()
+}
+
+func (c *standardRedisClient) HDel(key string, fields ...string) (int64, error) {
+	return c.client.HDel(c.client.Context(), key, fields...).Result()
+}
+
+func (c *standardRedisClient) Scan(cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return c.client.Scan(c.client.Context(), cursor, match, count).Result()
 }
 
 func (c *standardRedisClient) Del(keys ...string) error {
-	if c.ring != nil {
-		return c.ring.Del(c.ring.Context(), keys...).Err()
+	if len(keys) < 2 || !c.isMultiNode() {
+		return c.client.Del(c.client.Context(), keys...).Err()
 	}
-	return c.client.Del(c.client.Context(), keys...).Err()
+	ctx := c.client.Context()
+	_, err := c.client.Pipelined(ctx, func(pipeline redis.Pipeliner) error {
+		for _, key := range keys {
+			pipeline.Del(ctx, key)
+		}
+		return nil
+	})
+	return err
 }
 
 func (c *standardRedisClient) PSubscribe(channels ...string) *redis.PubSub {
-	if c.ring != nil {
-		return c.ring.PSubscribe(c.ring.Context(), channels...)
-	}
 	return c.client.PSubscribe(c.client.Context(), channels...)
 }
 
 func (c *standardRedisClient) Subscribe(channels ...string) *redis.PubSub {
-	if c.ring != nil {
-		return c.ring.Subscribe(c.ring.Context(), channels...)
-	}
 	return c.client.Subscribe(c.client.Context(), channels...)
 }
 
 func (c *standardRedisClient) Publish(channel string, message interface{}) error {
-	if c.ring != nil {
-		return c.ring.Publish(c.ring.Context(), channel, message).Err()
-	}
 	return c.client.Publish(c.client.Context(), channel, message).Err()
 }
 
 func (c *standardRedisClient) FlushDB() error {
-	if c.ring != nil {
-		return c.ring.FlushDB(c.ring.Context()).Err()
-	}
-	return c.client.FlushDB(c.client.Context()).Err()
+	return forEachRedisNode(c.client, func(ctx context.Context, client *redis.Client) error {
+		return client.FlushDB(ctx).Err()
+	})
 }
 
 func (c *standardRedisClient) Context() context.Context {
-	if c.ring != nil {
-		return c.ring.Context()
-	}
 	return c.client.Context()
 }
 
+func (c *standardRedisClient) isMultiNode() bool {
+	switch c.client.(type) {
+	case *redis.Ring, *redis.ClusterClient:
+		return true
+	}
+	return false
+}
+
+func forEachRedisNode(client redis.UniversalClient, fn func(ctx context.Context, client *redis.Client) error) error {
+	switch c := client.(type) {
+	case *redis.Ring:
+		return c.ForEachShard(c.Context(), fn)
+	case *redis.ClusterClient:
+		return c.ForEachMaster(c.Context(), fn)
+	case *redis.Client:
+		return fn(c.Context(), c)
+	}
+	return nil
+}
+
 type RedisCache struct {
 	engine  *Engine
 	code    string
@@ -397,12 +455,7 @@ type GetSetProvider func() interface{}
 
 func (r *RedisCache) RateLimit(key string, limit redis_rate.Limit) bool {
 	if r.limiter == nil {
-		c := r.client.(*standardRedisClient)
-		if c.client != nil {
-			r.limiter = redis_rate.NewLimiter(c.client)
-		} else {
-			r.limiter = redis_rate.NewLimiter(c.ring)
-		}
+		r.limiter = redis_rate.NewLimiter(r.client.(*standardRedisClient).client)
 	}
 	start := time.Now()
 	res, err := r.limiter.Allow(r.client.Context(), key, limit)
@@ -419,9 +472,12 @@ func (r *RedisCache) RateLimit(key string, limit redis_rate.Limit) bool {
 func (r *RedisCache) GetSet(key string, ttlSeconds int, provider GetSetProvider) interface{} {
 	val, has := r.Get(key)
 	if !has {
-		userVal := provider()
-		encoded, _ := jsoniter.ConfigFastest.Marshal(userVal)
-		r.Set(key, string(encoded), ttlSeconds)
+		userVal, _ := r.engine.registry.singleFlight.Do("redis:"+r.code+":"+key, func() interface{} {
+			userVal := provider()
+			encoded, _ := jsoniter.ConfigFastest.Marshal(userVal)
+			r.Set(key, string(encoded), ttlSeconds)
+			return userVal
+		})
 		return userVal
 	}
 	var data interface{}
@@ -429,6 +485,54 @@ func (r *RedisCache) GetSet(key string, ttlSeconds int, provider GetSetProvider)
 	return data
 }
 
+type recomputeValue struct {
+	Expire int64       `json:"e"`
+	Value  interface{} `json:"v"`
+}
+
+func (r *RedisCache) GetSetWithRecomputeLock(key string, ttlSeconds int, staleSeconds int, locker *Locker, provider GetSetProvider) interface{} {
+	if ttlSeconds <= 0 {
+		panic(errors.NotValidf("ttl"))
+	}
+	lockKey := "recompute:" + key
+	lockTTL := time.Duration(ttlSeconds) * time.Second
+	val, has := r.Get(key)
+	if has {
+		var data recomputeValue
+		_ = jsoniter.ConfigFastest.Unmarshal([]byte(val), &data)
+		if time.Now().Unix() < data.Expire {
+			return data.Value
+		}
+		lock, obtained := locker.Obtain(lockKey, lockTTL, time.Millisecond)
+		if !obtained {
+			return data.Value
+		}
+		defer lock.Release()
+		return r.setRecomputeValue(key, ttlSeconds, staleSeconds, provider)
+	}
+	userVal, _ := r.engine.registry.singleFlight.Do("recompute:"+r.code+":"+key, func() interface{} {
+		lock, obtained := locker.Obtain(lockKey, lockTTL, lockTTL)
+		if obtained {
+			defer lock.Release()
+			val, has := r.Get(key)
+			if has {
+				var data recomputeValue
+				_ = jsoniter.ConfigFastest.Unmarshal([]byte(val), &data)
+				return data.Value
+			}
+		}
+		return r.setRecomputeValue(key, ttlSeconds, staleSeconds, provider)
+	})
+	return userVal
+}
+
+func (r *RedisCache) setRecomputeValue(key string, ttlSeconds int, staleSeconds int, provider GetSetProvider) interface{} {
+	userVal := provider()
+	encoded, _ := jsoniter.ConfigFastest.Marshal(recomputeValue{Expire: time.Now().Unix() + int64(ttlSeconds), Value: userVal})
+	r.Set(key, string(encoded), ttlSeconds+staleSeconds)
+	return userVal
+}
+
 func (r *RedisCache) Get(key string) (value string, has bool) {
 	start := time.Now()
 	val, err := r.client.Get(key)
@@ -589,6 +693,35 @@ func (r *RedisCache) HMset(key string, fields map[string]interface{}) {
 	checkError(err)
 }
 
+var hmsetWithTTLScript = redis.NewScript(`local created = redis.call("EXISTS", KEYS[1]) == 0
+redis.call("HMSET", KEYS[1], unpack(ARGV, 2))
+if created then
+	redis.call("EXPIRE", KEYS[1], ARGV[1])
+	return 1
+end
+return 0`)
+
+func (r *RedisCache) hmset(key string, fields map[string]interface{}, ttlSeconds int) {
+	if ttlSeconds <= 0 {
+		r.HMset(key, fields)
+		return
+	}
+	start := time.Now()
+	args := make([]interface{}, 1, len(fields)*2+1)
+	args[0] = ttlSeconds
+	for field, value := range fields {
+		args = append(args, field, value)
+	}
+	_, err := r.client.RunScript(hmsetWithTTLScript, []string{key}, args...)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][HMSET]", start, "hmset", -1, len(fields),
+			map[string]interface{}{"Key": key, "fields": fields, "ttl": ttlSeconds}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, uint(len(fields)))
+	checkError(err)
+}
+
 func (r *RedisCache) HSet(key string, field string, value interface{}) {
 	start := time.Now()
 	_, err := r.client.HSet(key, field, value)
@@ -739,6 +872,19 @@ func (r *RedisCache) MSet(pairs ...interface{}) {
 	checkError(err)
 }
 
+func (r *RedisCache) Expire(key string, ttlSeconds int) bool {
+	start := time.Now()
+	res, err := r.client.Expire(key, time.Duration(ttlSeconds)*time.Second)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][EXPIRE]", start, "expire", -1, 1,
+			map[string]interface{}{"Key": key, "ttl": ttlSeconds}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, 1)
+	checkError(err)
+	return res
+}
+
 func (r *RedisCache) MGet(keys ...string) map[string]interface{} {
 	start := time.Now()
 	val, err := r.client.MGet(keys...)
@@ -828,6 +974,214 @@ func (r *RedisCache) Del(keys ...string) {
 	checkError(err)
 }
 
+func (r *RedisCache) TTL(key string) (ttl time.Duration, has bool) {
+	start := time.Now()
+	val, err := r.client.TTL(key)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][TTL]", start, "ttl", -1, 1,
+			map[string]interface{}{"Key": key}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysGet, 1)
+	checkError(err)
+	if val == -2 {
+		return 0, false
+	}
+	if val < 0 {
+		return RedisNoExpiration, true
+	}
+	return val, true
+}
+
+func (r *RedisCache) SetNX(key string, value interface{}, ttlSeconds int) bool {
+	start := time.Now()
+	val, err := r.client.SetNX(key, value, time.Duration(ttlSeconds)*time.Second)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][SETNX]", start, "setnx", -1, 1,
+			map[string]interface{}{"Key": key, "value": value, "ttl": ttlSeconds}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) GetAndSet(key string, value interface{}) (old string, has bool) {
+	start := time.Now()
+	val, err := r.client.GetSet(key, value)
+	has = true
+	if err == redis.Nil {
+		err = nil
+		has = false
+	}
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		misses := 0
+		if !has {
+			misses = 1
+		}
+		r.fillLogFields("[ORM][REDIS][GETSET]", start, "getset", misses, 1,
+			map[string]interface{}{"Key": key, "value": value}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, 1)
+	checkError(err)
+	return val, has
+}
+
+func (r *RedisCache) Incr(key string) int64 {
+	return r.IncrBy(key, 1)
+}
+
+func (r *RedisCache) IncrBy(key string, incr int64) int64 {
+	start := time.Now()
+	val, err := r.client.IncrBy(key, incr)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][INCRBY]", start, "incrby", -1, 1,
+			map[string]interface{}{"Key": key, "incr": incr}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) HIncrBy(key, field string, incr int64) int64 {
+	start := time.Now()
+	val, err := r.client.HIncrBy(key, field, incr)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][HINCRBY]", start, "hincrby", -1, 1,
+			map[string]interface{}{"Key": key, "field": field, "incr": incr}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) Exists(keys ...string) int64 {
+	start := time.Now()
+	val, err := r.client.Exists(keys...)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][EXISTS]", start, "exists", len(keys)-int(val), len(keys),
+			map[string]interface{}{"Keys": keys}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysGet, uint(len(keys)))
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) ZRem(key string, members ...interface{}) int64 {
+	start := time.Now()
+	val, err := r.client.ZRem(key, members...)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][ZREM]", start, "zrem", -1, 1,
+			map[string]interface{}{"Key": key, "members": members}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) ZRangeByScore(key string, opt *redis.ZRangeBy) []string {
+	start := time.Now()
+	val, err := r.client.ZRangeByScore(key, opt)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][ZRANGEBYSCORE]", start, "zrangebyscore", -1, 1,
+			map[string]interface{}{"Key": key, "min": opt.Min, "max": opt.Max, "offset": opt.Offset, "count": opt.Count}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysGet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) SMembers(key string) []string {
+	start := time.Now()
+	val, err := r.client.SMembers(key)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][SMEMBERS]", start, "smembers", -1, 1,
+			map[string]interface{}{"Key": key}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysGet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) SIsMember(key string, member interface{}) bool {
+	start := time.Now()
+	val, err := r.client.SIsMember(key, member)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][SISMEMBER]", start, "sismember", -1, 1,
+			map[string]interface{}{"Key": key, "member": member}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysGet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) HDel(key string, fields ...string) int64 {
+	start := time.Now()
+	val, err := r.client.HDel(key, fields...)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][HDEL]", start, "hdel", -1, 1,
+			map[string]interface{}{"Key": key, "fields": fields}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysSet, 1)
+	checkError(err)
+	return val
+}
+
+func (r *RedisCache) Scan(cursor uint64, match string, count int64) (keys []string, nextCursor uint64) {
+	if r.client.(*standardRedisClient).isMultiNode() {
+		panic(errors.NotSupportedf("scan with cursor in redis ring and cluster, use ScanAll"))
+	}
+	start := time.Now()
+	keys, nextCursor, err := r.client.Scan(cursor, match, count)
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][SCAN]", start, "scan", -1, len(keys),
+			map[string]interface{}{"cursor": cursor, "match": match, "count": count}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisKeysGet, 1)
+	checkError(err)
+	return keys, nextCursor
+}
+
+func (r *RedisCache) ScanAll(match string, count int64, handler func(keys []string)) {
+	var mutex sync.Mutex
+	err := forEachRedisNode(r.client.(*standardRedisClient).client, func(ctx context.Context, client *redis.Client) error {
+		cursor := uint64(0)
+		for {
+			start := time.Now()
+			keys, nextCursor, err := client.Scan(ctx, cursor, match, count).Result()
+			if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+				r.fillLogFields("[ORM][REDIS][SCAN]", start, "scan", -1, len(keys),
+					map[string]interface{}{"cursor": cursor, "match": match, "count": count, "node": client.Options().Addr}, err)
+			}
+			r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+			r.engine.dataDog.incrementCounter(counterRedisKeysGet, 1)
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				mutex.Lock()
+				handler(keys)
+				mutex.Unlock()
+			}
+			if nextCursor == 0 {
+				return nil
+			}
+			cursor = nextCursor
+		}
+	})
+	checkError(err)
+}
+
 func (r *RedisCache) PSubscribe(channels ...string) *PubSub {
 	start := time.Now()
 	pubSub := r.client.PSubscribe(channels...)
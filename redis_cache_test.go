@@ -2,6 +2,7 @@ package orm
 
 import (
 	"testing"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 
@@ -36,6 +37,52 @@ func TestRedis(t *testing.T) {
 	})
 }
 
+func TestRedisGetSetWithRecomputeLock(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterLocker("default", "default")
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+	locker := engine.GetLocker()
+
+	calls := 0
+	provider := func() interface{} {
+		calls++
+		return "hello"
+	}
+	val := r.GetSetWithRecomputeLock("test_recompute", 1, 10, locker, provider)
+	assert.Equal(t, "hello", val)
+	assert.Equal(t, 1, calls)
+	val = r.GetSetWithRecomputeLock("test_recompute", 1, 10, locker, provider)
+	assert.Equal(t, "hello", val)
+	assert.Equal(t, 1, calls)
+
+	time.Sleep(time.Millisecond * 1100)
+	lock, has := locker.Obtain("recompute:test_recompute", time.Second, 0)
+	assert.True(t, has)
+	val = r.GetSetWithRecomputeLock("test_recompute", 1, 10, locker, func() interface{} {
+		calls++
+		return "hello2"
+	})
+	assert.Equal(t, "hello", val)
+	assert.Equal(t, 1, calls)
+	lock.Release()
+
+	val = r.GetSetWithRecomputeLock("test_recompute", 1, 10, locker, func() interface{} {
+		calls++
+		return "hello2"
+	})
+	assert.Equal(t, "hello2", val)
+	assert.Equal(t, 2, calls)
+
+	assert.PanicsWithError(t, "ttl not valid", func() {
+		r.GetSetWithRecomputeLock("test_recompute", 0, 10, locker, provider)
+	})
+}
+
 func TestRedisRing(t *testing.T) {
 	registry := &Registry{}
 	registry.RegisterRedisRing([]string{"localhost:6381"}, 15)
@@ -47,6 +94,77 @@ func TestRedisRing(t *testing.T) {
 	testRedis(t, engine)
 }
 
+type redisClusterEntity struct {
+	ORM  `orm:"redisCache=cluster"`
+	ID   uint
+	Name string
+}
+
+type redisSentinelEntity struct {
+	ORM  `orm:"redisCache=sentinel"`
+	ID   uint
+	Name string
+}
+
+func TestRedisCluster(t *testing.T) {
+	var entity *redisClusterEntity
+	registry := &Registry{}
+	registry.RegisterRedisCluster([]string{"localhost:7000", "localhost:7001", "localhost:7002"}, "cluster")
+	engine := PrepareTables(t, registry, entity)
+	r := engine.GetRedis("cluster")
+	r.FlushDB()
+	testRedisMultiKey(t, r)
+
+	engine.TrackAndFlush(&redisClusterEntity{Name: "a"}, &redisClusterEntity{Name: "b"}, &redisClusterEntity{Name: "c"})
+	var rows []*redisClusterEntity
+	assert.Equal(t, []uint64{4}, engine.LoadByIDs([]uint64{1, 2, 3, 4}, &rows))
+	assert.Len(t, rows, 3)
+	schema := engine.GetRegistry().GetTableSchemaForEntity(entity).(*tableSchema)
+	assert.Equal(t, int64(4), r.Exists(schema.getCacheKey(uint64(1)), schema.getCacheKey(uint64(2)),
+		schema.getCacheKey(uint64(3)), schema.getCacheKey(uint64(4))))
+	rows[0].Name = "a2"
+	rows[2].Name = "c2"
+	engine.TrackAndFlush(rows[0], rows[2])
+	assert.Equal(t, int64(2), r.Exists(schema.getCacheKey(uint64(1)), schema.getCacheKey(uint64(2)),
+		schema.getCacheKey(uint64(3)), schema.getCacheKey(uint64(4))))
+	engine.LoadByIDs([]uint64{1, 2, 3}, &rows)
+	assert.Equal(t, "a2", rows[0].Name)
+	assert.Equal(t, "b", rows[1].Name)
+	assert.Equal(t, "c2", rows[2].Name)
+}
+
+func TestRedisSentinel(t *testing.T) {
+	var entity *redisSentinelEntity
+	registry := &Registry{}
+	registry.RegisterRedisSentinel("mymaster", 13, []string{"localhost:26381"}, "sentinel")
+	engine := PrepareTables(t, registry, entity)
+	r := engine.GetRedis("sentinel")
+	r.FlushDB()
+	testRedisMultiKey(t, r)
+
+	engine.TrackAndFlush(&redisSentinelEntity{Name: "a"}, &redisSentinelEntity{Name: "b"})
+	var rows []*redisSentinelEntity
+	assert.Equal(t, []uint64{3}, engine.LoadByIDs([]uint64{1, 2, 3}, &rows))
+	rows[1].Name = "b2"
+	engine.TrackAndFlush(rows[1])
+	engine.LoadByIDs([]uint64{1, 2}, &rows)
+	assert.Equal(t, "a", rows[0].Name)
+	assert.Equal(t, "b2", rows[1].Name)
+}
+
+func testRedisMultiKey(t *testing.T, r *RedisCache) {
+	keys := []string{"test_a", "test_b", "test_c", "test_d", "test_e"}
+	r.MSet("test_a", "1", "test_b", "2", "test_c", "3", "test_d", "4", "test_e", "5")
+	values := r.MGet(append(keys, "test_missing")...)
+	assert.Len(t, values, 6)
+	assert.Equal(t, "1", values["test_a"])
+	assert.Equal(t, "5", values["test_e"])
+	assert.Nil(t, values["test_missing"])
+	assert.Equal(t, int64(5), r.Exists(keys...))
+	r.Del(keys...)
+	assert.Equal(t, int64(0), r.Exists(keys...))
+}
+
 func testRedis(t *testing.T, engine *Engine) {
 	r := engine.GetRedis()
 
@@ -150,4 +268,78 @@ func testRedis(t *testing.T, engine *Engine) {
 	val, has = r.SPop("test_s")
 	assert.Equal(t, "", val)
 	assert.False(t, has)
+
+	ttl, has := r.TTL("test_ttl")
+	assert.False(t, has)
+	assert.Equal(t, time.Duration(0), ttl)
+	assert.True(t, r.SetNX("test_ttl", "a", 10))
+	assert.False(t, r.SetNX("test_ttl", "b", 10))
+	ttl, has = r.TTL("test_ttl")
+	assert.True(t, has)
+	assert.Greater(t, ttl.Seconds(), float64(8))
+	assert.True(t, r.Expire("test_ttl", 20))
+	assert.False(t, r.Expire("test_ttl_missing", 20))
+	r.Set("test_no_ttl", "a", 0)
+	ttl, has = r.TTL("test_no_ttl")
+	assert.True(t, has)
+	assert.Equal(t, RedisNoExpiration, ttl)
+	r.hmset("test_hmset_ttl", map[string]interface{}{"a": "1"}, 100)
+	ttl, _ = r.TTL("test_hmset_ttl")
+	assert.Greater(t, ttl.Seconds(), float64(90))
+	r.Expire("test_hmset_ttl", 10)
+	r.hmset("test_hmset_ttl", map[string]interface{}{"b": "2"}, 100)
+	ttl, _ = r.TTL("test_hmset_ttl")
+	assert.LessOrEqual(t, ttl.Seconds(), float64(10))
+	assert.Equal(t, map[string]string{"a": "1", "b": "2"}, r.HGetAll("test_hmset_ttl"))
+
+	val, has = r.GetAndSet("test_get_and_set", "a")
+	assert.False(t, has)
+	assert.Equal(t, "", val)
+	val, has = r.GetAndSet("test_get_and_set", "b")
+	assert.True(t, has)
+	assert.Equal(t, "a", val)
+
+	assert.Equal(t, int64(1), r.Incr("test_incr"))
+	assert.Equal(t, int64(11), r.IncrBy("test_incr", 10))
+	assert.Equal(t, int64(5), r.HIncrBy("test_h_incr", "a", 5))
+	assert.Equal(t, int64(3), r.HIncrBy("test_h_incr", "a", -2))
+	assert.Equal(t, int64(2), r.Exists("test_incr", "test_h_incr", "test_missing"))
+	assert.Equal(t, int64(1), r.HDel("test_h_incr", "a", "b"))
+	assert.Equal(t, int64(0), r.Exists("test_h_incr"))
+
+	r.ZAdd("test_z_score", &redis.Z{Member: "a", Score: 1}, &redis.Z{Member: "b", Score: 2}, &redis.Z{Member: "c", Score: 3})
+	assert.Equal(t, []string{"b", "c"}, r.ZRangeByScore("test_z_score", &redis.ZRangeBy{Min: "2", Max: "+inf"}))
+	assert.Equal(t, []string{"a"}, r.ZRangeByScore("test_z_score", &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: 1}))
+	assert.Equal(t, int64(2), r.ZRem("test_z_score", "a", "b", "d"))
+	assert.Equal(t, int64(1), r.ZCard("test_z_score"))
+
+	r.SAdd("test_members", "a", "b")
+	assert.ElementsMatch(t, []string{"a", "b"}, r.SMembers("test_members"))
+	assert.True(t, r.SIsMember("test_members", "a"))
+	assert.False(t, r.SIsMember("test_members", "c"))
+
+	r.FlushDB()
+	r.MSet("scan_1", "a", "scan_2", "b", "other", "c")
+	found := make([]string, 0)
+	r.ScanAll("scan_*", 10, func(keys []string) {
+		found = append(found, keys...)
+	})
+	assert.ElementsMatch(t, []string{"scan_1", "scan_2"}, found)
+	if r.client.(*standardRedisClient).isMultiNode() {
+		assert.PanicsWithError(t, "scan with cursor in redis ring and cluster, use ScanAll not supported", func() {
+			r.Scan(0, "scan_*", 10)
+		})
+		return
+	}
+	found = make([]string, 0)
+	cursor := uint64(0)
+	for {
+		var keys []string
+		keys, cursor = r.Scan(cursor, "scan_*", 10)
+		found = append(found, keys...)
+		if cursor == 0 {
+			break
+		}
+	}
+	assert.ElementsMatch(t, []string{"scan_1", "scan_2"}, found)
 }
@@ -0,0 +1,139 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const counterRedisPipeline = "redis.pipeline"
+
+type RedisPipeline struct {
+	ctx      context.Context
+	pipeline redis.Pipeliner
+	commands []string
+	keys     int
+}
+
+func (r *RedisCache) Pipeline(fn func(p *RedisPipeline)) {
+	r.runPipeline(fn, false)
+}
+
+func (r *RedisCache) TxPipeline(fn func(p *RedisPipeline)) {
+	r.runPipeline(fn, true)
+}
+
+func (r *RedisCache) runPipeline(fn func(p *RedisPipeline), tx bool) {
+	p := &RedisPipeline{ctx: r.client.Context()}
+	start := time.Now()
+	cmds, err := r.client.Pipelined(func(pipeline redis.Pipeliner) error {
+		p.pipeline = pipeline
+		fn(p)
+		return nil
+	}, tx)
+	if err == redis.Nil {
+		err = nil
+		for _, cmd := range cmds {
+			if cmd.Err() != nil && cmd.Err() != redis.Nil {
+				err = cmd.Err()
+				break
+			}
+		}
+	}
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		message := "[ORM][REDIS][PIPELINE]"
+		if tx {
+			message = "[ORM][REDIS][TX_PIPELINE]"
+		}
+		r.fillLogFields(message, start, "pipeline", -1, p.keys, map[string]interface{}{"Commands": p.commands}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisPipeline, 1)
+	checkError(err)
+}
+
+func (p *RedisPipeline) Get(key string) *redis.StringCmd {
+	p.add("get", 1)
+	return p.pipeline.Get(p.ctx, key)
+}
+
+func (p *RedisPipeline) Set(key string, value interface{}, ttlSeconds int) *redis.StatusCmd {
+	p.add("set", 1)
+	return p.pipeline.Set(p.ctx, key, value, time.Duration(ttlSeconds)*time.Second)
+}
+
+func (p *RedisPipeline) MSet(pairs ...interface{}) *redis.StatusCmd {
+	p.add("mset", len(pairs)/2)
+	return p.pipeline.MSet(p.ctx, pairs...)
+}
+
+func (p *RedisPipeline) MGet(keys ...string) *redis.SliceCmd {
+	p.add("mget", len(keys))
+	return p.pipeline.MGet(p.ctx, keys...)
+}
+
+func (p *RedisPipeline) Expire(key string, ttlSeconds int) *redis.BoolCmd {
+	p.add("expire", 1)
+	return p.pipeline.Expire(p.ctx, key, time.Duration(ttlSeconds)*time.Second)
+}
+
+func (p *RedisPipeline) HMset(key string, fields map[string]interface{}) *redis.BoolCmd {
+	p.add("hmset", 1)
+	return p.pipeline.HMSet(p.ctx, key, fields)
+}
+
+func (p *RedisPipeline) HSet(key string, field string, value interface{}) *redis.IntCmd {
+	p.add("hset", 1)
+	return p.pipeline.HSet(p.ctx, key, field, value)
+}
+
+func (p *RedisPipeline) HMget(key string, fields ...string) *redis.SliceCmd {
+	p.add("hmget", 1)
+	return p.pipeline.HMGet(p.ctx, key, fields...)
+}
+
+func (p *RedisPipeline) HGetAll(key string) *redis.StringStringMapCmd {
+	p.add("hgetall", 1)
+	return p.pipeline.HGetAll(p.ctx, key)
+}
+
+func (p *RedisPipeline) LPush(key string, values ...interface{}) *redis.IntCmd {
+	p.add("lpush", 1)
+	return p.pipeline.LPush(p.ctx, key, values...)
+}
+
+func (p *RedisPipeline) RPush(key string, values ...interface{}) *redis.IntCmd {
+	p.add("rpush", 1)
+	return p.pipeline.RPush(p.ctx, key, values...)
+}
+
+func (p *RedisPipeline) LTrim(key string, start, stop int64) *redis.StatusCmd {
+	p.add("ltrim", 1)
+	return p.pipeline.LTrim(p.ctx, key, start, stop)
+}
+
+func (p *RedisPipeline) ZAdd(key string, members ...*redis.Z) *redis.IntCmd {
+	p.add("zadd", 1)
+	return p.pipeline.ZAdd(p.ctx, key, members...)
+}
+
+func (p *RedisPipeline) SAdd(key string, members ...interface{}) *redis.IntCmd {
+	p.add("sadd", 1)
+	return p.pipeline.SAdd(p.ctx, key, members...)
+}
+
+func (p *RedisPipeline) Incr(key string) *redis.IntCmd {
+	p.add("incr", 1)
+	return p.pipeline.Incr(p.ctx, key)
+}
+
+func (p *RedisPipeline) Del(keys ...string) *redis.IntCmd {
+	p.add("del", len(keys))
+	return p.pipeline.Del(p.ctx, keys...)
+}
+
+func (p *RedisPipeline) add(command string, keys int) {
+	p.commands = append(p.commands, command)
+	p.keys += keys
+}
@@ -0,0 +1,66 @@
+package orm
+
+import (
+	"testing"
+
+	apexLog "github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisPipeline(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.InfoLevel, QueryLoggerSourceRedis)
+
+	var get *redis.StringCmd
+	var missing *redis.StringCmd
+	var pushed *redis.IntCmd
+	r.Pipeline(func(p *RedisPipeline) {
+		p.Set("test_pipeline", "a", 10)
+		p.HMset("test_pipeline_hash", map[string]interface{}{"a": "b"})
+		p.ZAdd("test_pipeline_set", &redis.Z{Member: "a", Score: 1})
+		pushed = p.LPush("test_pipeline_list", "a", "b")
+		get = p.Get("test_pipeline")
+		missing = p.Get("test_pipeline_missing")
+	})
+	assert.Equal(t, "a", get.Val())
+	assert.Equal(t, redis.Nil, missing.Err())
+	assert.Equal(t, int64(2), pushed.Val())
+	assert.Equal(t, map[string]string{"a": "b"}, r.HGetAll("test_pipeline_hash"))
+	assert.Equal(t, int64(1), r.ZCard("test_pipeline_set"))
+	assert.Len(t, testLogger.Entries, 3)
+	assert.Equal(t, "[ORM][REDIS][PIPELINE]", testLogger.Entries[0].Message)
+	assert.Equal(t, []string{"set", "hmset", "zadd", "lpush", "get", "get"}, testLogger.Entries[0].Fields["Commands"])
+
+	var counter *redis.IntCmd
+	r.TxPipeline(func(p *RedisPipeline) {
+		p.Incr("test_pipeline_counter")
+		counter = p.Incr("test_pipeline_counter")
+		p.Del("test_pipeline", "test_pipeline_hash")
+	})
+	assert.Equal(t, int64(2), counter.Val())
+	_, has := r.Get("test_pipeline")
+	assert.False(t, has)
+	assert.Equal(t, "[ORM][REDIS][TX_PIPELINE]", testLogger.Entries[3].Message)
+
+	assert.Panics(t, func() {
+		r.Pipeline(func(p *RedisPipeline) {
+			p.Incr("test_pipeline_list")
+		})
+	})
+	assert.Panics(t, func() {
+		r.Pipeline(func(p *RedisPipeline) {
+			p.Get("test_pipeline_missing")
+			p.Incr("test_pipeline_list")
+		})
+	})
+}
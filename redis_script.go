@@ -0,0 +1,47 @@
+package orm
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/juju/errors"
+)
+
+const counterRedisScript = "redis.script"
+
+func (c *standardRedisClient) RunScript(script *redis.Script, keys []string, args ...interface{}) (interface{}, error) {
+	return script.Run(c.client.Context(), c.client, keys, args...).Result()
+}
+
+func (r *RedisCache) RunScript(name string, keys []string, args ...interface{}) interface{} {
+	script, has := r.engine.registry.redisScripts[name]
+	if !has {
+		panic(errors.NotFoundf("redis script '%s'", name))
+	}
+	start := time.Now()
+	res, err := r.client.RunScript(script, keys, args...)
+	if err == redis.Nil {
+		err = nil
+	}
+	if r.engine.queryLoggers[QueryLoggerSourceRedis] != nil {
+		r.fillLogFields("[ORM][REDIS][SCRIPT]", start, "script", -1, len(keys),
+			map[string]interface{}{"Script": name, "Keys": keys, "Args": args}, err)
+	}
+	r.engine.dataDog.incrementCounter(counterRedisAll, 1)
+	r.engine.dataDog.incrementCounter(counterRedisScript, 1)
+	checkError(err)
+	return res
+}
+
+func loadRedisScripts(config *RedisCacheConfig, scripts map[string]*redis.Script) error {
+	for name, script := range scripts {
+		err := forEachRedisNode(config.client, func(ctx context.Context, client *redis.Client) error {
+			return script.Load(ctx, client).Err()
+		})
+		if err != nil {
+			return errors.Annotatef(err, "can't load redis script '%s' in pool '%s'", name, config.code)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package orm
+
+import (
+	"testing"
+
+	apexLog "github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCappedPushScript = `
+local size = redis.call("LPUSH", KEYS[1], ARGV[1])
+if size > tonumber(ARGV[2]) then
+	redis.call("LTRIM", KEYS[1], 0, tonumber(ARGV[2]) - 1)
+	size = tonumber(ARGV[2])
+end
+return size`
+
+func TestRedisScript(t *testing.T) {
+	registry := &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterRedisScript("capped_push", testCappedPushScript)
+	registry.RegisterRedisScript("get", `return redis.call("GET", KEYS[1])`)
+	validatedRegistry, err := registry.Validate()
+	assert.Nil(t, err)
+	engine := validatedRegistry.CreateEngine()
+	r := engine.GetRedis()
+	r.FlushDB()
+	testLogger := memory.New()
+	engine.AddQueryLogger(testLogger, apexLog.InfoLevel, QueryLoggerSourceRedis)
+
+	assert.Equal(t, int64(1), r.RunScript("capped_push", []string{"test_script"}, "a", 2))
+	assert.Equal(t, int64(2), r.RunScript("capped_push", []string{"test_script"}, "b", 2))
+	assert.Equal(t, int64(2), r.RunScript("capped_push", []string{"test_script"}, "c", 2))
+	assert.Equal(t, []string{"c", "b"}, r.LRange("test_script", 0, 10))
+	assert.Equal(t, "[ORM][REDIS][SCRIPT]", testLogger.Entries[0].Message)
+	assert.Equal(t, "capped_push", testLogger.Entries[0].Fields["Script"])
+
+	assert.Nil(t, r.RunScript("get", []string{"test_script_missing"}))
+
+	r.client.(*standardRedisClient).client.ScriptFlush(r.client.Context())
+	assert.Equal(t, int64(2), r.RunScript("capped_push", []string{"test_script"}, "d", 2))
+
+	assert.PanicsWithError(t, "redis script 'invalid' not found", func() {
+		r.RunScript("invalid", []string{"test_script"})
+	})
+	assert.Panics(t, func() {
+		r.RunScript("capped_push", []string{"test_script"}, "e", "invalid")
+	})
+
+	registry = &Registry{}
+	registry.RegisterRedis("localhost:6381", 15)
+	registry.RegisterRedisScript("invalid", "return invalid(")
+	_, err = registry.Validate()
+	assert.NotNil(t, err)
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"github.com/go-sql-driver/mysql"
 	"github.com/golang/groupcache/lru"
 	"github.com/jmoiron/sqlx"
 	"github.com/juju/errors"
@@ -19,20 +20,25 @@ import (
 )
 
 type Registry struct {
-	sqlClients           map[string]*DBConfig
-	clickHouseClients    map[string]*ClickHouseConfig
-	localCacheContainers map[string]*LocalCacheConfig
-	redisServers         map[string]*RedisCacheConfig
-	elasticServers       map[string]*ElasticConfig
-	rabbitMQServers      map[string]*rabbitMQConfig
-	rabbitMQQueues       map[string][]*RabbitMQQueueConfig
-	rabbitMQRouters      map[string][]*RabbitMQRouterConfig
-	entities             map[string]reflect.Type
-	elasticIndices       map[string]map[string]ElasticIndexDefinition
-	enums                map[string]Enum
-	dirtyQueues          map[string]int
-	locks                map[string]string
-	defaultEncoding      string
+	sqlClients                 map[string]*DBConfig
+	clickHouseClients          map[string]*ClickHouseConfig
+	localCacheContainers       map[string]*LocalCacheConfig
+	redisServers               map[string]*RedisCacheConfig
+	elasticServers             map[string]*ElasticConfig
+	rabbitMQServers            map[string]*rabbitMQConfig
+	rabbitMQQueues             map[string][]*RabbitMQQueueConfig
+	rabbitMQRouters            map[string][]*RabbitMQRouterConfig
+	entities                   map[string]reflect.Type
+	clickHouseEntities         map[string]reflect.Type
+	elasticIndices             map[string]map[string]ElasticIndexDefinition
+	enums                      map[string]Enum
+	dirtyQueues                map[string]int
+	locks                      map[string]string
+	defaultEncoding            string
+	localCacheInvalidationPool string
+	redisScripts               map[string]string
+	columnTypes                map[reflect.Type]ColumnTypeHandler
+	sqlLocations               map[string]*time.Location
 }
 
 func (r *Registry) Validate() (ValidatedRegistry, error) {
@@ -41,13 +47,29 @@ func (r *Registry) Validate() (ValidatedRegistry, error) {
 	}
 	registry := &validatedRegistry{}
 	registry.registry = r
+	registry.instanceID = generateRandomID()
+	registry.singleFlight = &singleFlight{}
 	l := len(r.entities)
 	registry.tableSchemas = make(map[reflect.Type]*tableSchema, l)
 	registry.entities = make(map[string]reflect.Type)
 	if registry.sqlClients == nil {
 		registry.sqlClients = make(map[string]*DBConfig)
 	}
+	for code := range r.sqlLocations {
+		_, has := r.sqlClients[code]
+		if !has {
+			return nil, errors.NotFoundf("mysql pool '%s' with timezone", code)
+		}
+	}
 	for k, v := range r.sqlClients {
+		location, has := r.sqlLocations[k]
+		if has {
+			dataSourceName, err := getTimezoneDataSourceName(v.dataSourceName, location)
+			if err != nil {
+				return nil, errors.Annotatef(err, "invalid data source name for mysql '%s'", v.code)
+			}
+			v.dataSourceName = dataSourceName
+		}
 		db, err := sql.Open("mysql", v.dataSourceName)
 		if err != nil {
 			return nil, errors.Trace(err)
@@ -60,6 +82,10 @@ func (r *Registry) Validate() (ValidatedRegistry, error) {
 			return nil, errors.Annotatef(err, "can't connect to mysql '%s'", v.code)
 		}
 		v.autoincrement = autoincrement
+		err = db.QueryRow("SELECT VERSION()").Scan(&v.version)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 
 		err = db.QueryRow("SHOW VARIABLES LIKE 'max_connections'").Scan(&skip, &maxConnections)
 		if err != nil {
@@ -122,8 +148,29 @@ func (r *Registry) Validate() (ValidatedRegistry, error) {
 	if registry.redisServers == nil {
 		registry.redisServers = make(map[string]*RedisCacheConfig)
 	}
+	registry.redisScripts = make(map[string]*redis.Script, len(r.redisScripts))
+	for name, source := range r.redisScripts {
+		registry.redisScripts[name] = redis.NewScript(source)
+	}
 	for k, v := range r.redisServers {
 		registry.redisServers[k] = v
+		err := loadRedisScripts(v, registry.redisScripts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.localCacheInvalidationPool != "" {
+		_, has := r.redisServers[r.localCacheInvalidationPool]
+		if !has {
+			return nil, errors.NotFoundf("redis pool '%s' for local cache invalidation", r.localCacheInvalidationPool)
+		}
+		registry.localCacheInvalidationPool = r.localCacheInvalidationPool
+		instanceID, err := registerLocalCacheInvalidationInstance(r.redisServers[r.localCacheInvalidationPool].client)
+		if err != nil {
+			return nil, errors.Annotatef(err, "can't register local cache invalidation instance in redis '%s'", r.localCacheInvalidationPool)
+		}
+		registry.instanceID = instanceID
 	}
 
 	if registry.elasticServers == nil {
@@ -194,6 +241,16 @@ func (r *Registry) Validate() (ValidatedRegistry, error) {
 		registry.tableSchemas[entityType] = tableSchema
 		registry.entities[name] = entityType
 	}
+	registry.clickHouseTableSchemas = make(map[reflect.Type]*clickHouseTableSchema, len(r.clickHouseEntities))
+	registry.clickHouseEntities = make(map[string]reflect.Type)
+	for name, entityType := range r.clickHouseEntities {
+		tableSchema, err := initClickHouseTableSchema(r, entityType)
+		if err != nil {
+			return nil, err
+		}
+		registry.clickHouseTableSchemas[entityType] = tableSchema
+		registry.clickHouseEntities[name] = entityType
+	}
 	engine := registry.CreateEngine()
 	hasLog := false
 	for _, schema := range registry.tableSchemas {
@@ -263,10 +320,86 @@ func (r *Registry) Validate() (ValidatedRegistry, error) {
 	return registry, err
 }
 
-func (r *Registry) SetDefaultEDefaultEncodingncoding(encoding string) {
+func (r *Registry) SetDefaultEncoding(encoding string) {
 	r.defaultEncoding = encoding
 }
 
+// Deprecated: use SetDefaultEncoding
+func (r *Registry) SetDefaultEDefaultEncodingncoding(encoding string) {
+	r.SetDefaultEncoding(encoding)
+}
+
+func (r *Registry) SetMySQLPoolTimezone(location *time.Location, code ...string) {
+	dbCode := "default"
+	if len(code) > 0 {
+		dbCode = code[0]
+	}
+	if r.sqlLocations == nil {
+		r.sqlLocations = make(map[string]*time.Location)
+	}
+	r.sqlLocations[dbCode] = location
+}
+
+func getTimezoneDataSourceName(dataSourceName string, location *time.Location) (string, error) {
+	config, err := mysql.ParseDSN(dataSourceName)
+	if err != nil {
+		return "", err
+	}
+	zone, err := getMySQLTimezone(location)
+	if err != nil {
+		return "", err
+	}
+	if config.Params == nil {
+		config.Params = make(map[string]string)
+	}
+	config.Params["time_zone"] = "'" + zone + "'"
+	return config.FormatDSN(), nil
+}
+
+func getMySQLTimezone(location *time.Location) (string, error) {
+	zone := location.String()
+	if zone == "Local" {
+		zone = getLocalTimezoneName()
+		if zone == "" {
+			return "", errors.NotSupportedf("local time zone without IANA name, set TZ or use UTC")
+		}
+	}
+	if zone == "UTC" || zone == "Etc/UTC" {
+		return "+00:00", nil
+	}
+	_, err := time.LoadLocation(zone)
+	if err == nil {
+		return zone, nil
+	}
+	year := time.Now().Year()
+	_, winter := time.Date(year, 1, 1, 0, 0, 0, 0, location).Zone()
+	_, summer := time.Date(year, 7, 1, 0, 0, 0, 0, location).Zone()
+	if winter != summer {
+		return "", errors.NotSupportedf("time zone '%s' without IANA name", zone)
+	}
+	return time.Date(year, 1, 1, 0, 0, 0, 0, location).Format("-07:00"), nil
+}
+
+func getLocalTimezoneName() string {
+	zone, has := os.LookupEnv("TZ")
+	if !has {
+		link, err := os.Readlink("/etc/localtime")
+		if err != nil {
+			return ""
+		}
+		zone = link
+	}
+	zone = strings.TrimPrefix(zone, ":")
+	if zone == "" {
+		return "UTC"
+	}
+	index := strings.Index(zone, "zoneinfo/")
+	if index >= 0 {
+		zone = zone[index+9:]
+	}
+	return zone
+}
+
 func (r *Registry) RegisterEntity(entity ...Entity) {
 	if r.entities == nil {
 		r.entities = make(map[string]reflect.Type)
@@ -280,6 +413,19 @@ func (r *Registry) RegisterEntity(entity ...Entity) {
 	}
 }
 
+func (r *Registry) RegisterClickHouseEntity(entity ...ClickHouseEntity) {
+	if r.clickHouseEntities == nil {
+		r.clickHouseEntities = make(map[string]reflect.Type)
+	}
+	for _, e := range entity {
+		t := reflect.TypeOf(e)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		r.clickHouseEntities[t.String()] = t
+	}
+}
+
 func (r *Registry) RegisterElasticIndex(index ElasticIndexDefinition, serverPool ...string) {
 	if r.elasticIndices == nil {
 		r.elasticIndices = make(map[string]map[string]ElasticIndexDefinition)
@@ -384,13 +530,52 @@ func (r *Registry) RegisterRedisRing(addresses []string, db int, code ...string)
 	if len(code) > 0 {
 		dbCode = code[0]
 	}
-	redisCache := &RedisCacheConfig{code: dbCode, ring: ring}
+	redisCache := &RedisCacheConfig{code: dbCode, client: ring}
+	if r.redisServers == nil {
+		r.redisServers = make(map[string]*RedisCacheConfig)
+	}
+	r.redisServers[dbCode] = redisCache
+}
+
+func (r *Registry) RegisterRedisCluster(addresses []string, code ...string) {
+	client := redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs: addresses,
+	})
+	dbCode := "default"
+	if len(code) > 0 {
+		dbCode = code[0]
+	}
+	redisCache := &RedisCacheConfig{code: dbCode, client: client}
 	if r.redisServers == nil {
 		r.redisServers = make(map[string]*RedisCacheConfig)
 	}
 	r.redisServers[dbCode] = redisCache
 }
 
+func (r *Registry) RegisterRedisSentinel(masterName string, db int, sentinels []string, code ...string) {
+	client := redis.NewFailoverClient(&redis.FailoverOptions{
+		MasterName:    masterName,
+		SentinelAddrs: sentinels,
+		DB:            db,
+	})
+	dbCode := "default"
+	if len(code) > 0 {
+		dbCode = code[0]
+	}
+	redisCache := &RedisCacheConfig{code: dbCode, client: client}
+	if r.redisServers == nil {
+		r.redisServers = make(map[string]*RedisCacheConfig)
+	}
+	r.redisServers[dbCode] = redisCache
+}
+
+func (r *Registry) RegisterRedisScript(name string, source string) {
+	if r.redisScripts == nil {
+		r.redisScripts = make(map[string]string)
+	}
+	r.redisScripts[name] = source
+}
+
 func (r *Registry) RegisterRabbitMQServer(address string, code ...string) {
 	dbCode := "default"
 	if len(code) > 0 {
@@ -438,6 +623,14 @@ func (r *Registry) RegisterDirtyQueue(code string, batchSize int) {
 	r.dirtyQueues[code] = batchSize
 }
 
+func (r *Registry) RegisterLocalCacheInvalidation(redisCode ...string) {
+	dbCode := "default"
+	if len(redisCode) > 0 {
+		dbCode = redisCode[0]
+	}
+	r.localCacheInvalidationPool = dbCode
+}
+
 func (r *Registry) RegisterLocker(code string, redisCode string) {
 	if r.locks == nil {
 		r.locks = make(map[string]string)
@@ -496,8 +689,7 @@ func (r *Registry) registerElastic(url string, withTrace bool, code ...string) {
 
 type RedisCacheConfig struct {
 	code   string
-	client *redis.Client
-	ring   *redis.Ring
+	client redis.UniversalClient
 }
 
 type ElasticConfig struct {
@@ -13,22 +13,32 @@ import (
 )
 
 type Alter struct {
-	SQL  string
-	Safe bool
-	Pool string
+	SQL      string
+	Safe     bool
+	Pool     string
+	rollback string
+	table    string
+	changes  []SchemaChange
 }
 
 type indexDB struct {
-	Skip      sql.NullString
-	NonUnique uint8
+	NonUnique string
 	KeyName   string
 	Seq       int
 	Column    string
+	Collation string
+	SubPart   int
+	IndexType string
+	Visible   string
 }
 
 type index struct {
-	Unique  bool
-	Columns map[int]string
+	Unique     bool
+	Type       string
+	Invisible  bool
+	Columns    map[int]string
+	SubParts   map[int]int
+	Descending map[int]bool
 }
 
 type foreignIndex struct {
@@ -67,6 +77,9 @@ func getAlters(engine *Engine) (alters []Alter) {
 		for _, t := range engine.registry.entities {
 			tableSchema := getTableSchema(engine.registry, t)
 			tablesInEntities[tableSchema.mysqlPoolName][tableSchema.tableName] = true
+			if tableSchema.renamedFrom != "" && !tablesInDB[tableSchema.mysqlPoolName][tableSchema.tableName] {
+				tablesInEntities[tableSchema.mysqlPoolName][tableSchema.renamedFrom] = true
+			}
 			has, newAlters := tableSchema.GetSchemaChanges(engine)
 			if tableSchema.hasLog {
 				logPool := engine.GetMysql(tableSchema.logPoolName)
@@ -76,19 +89,28 @@ func getAlters(engine *Engine) (alters []Alter) {
 					"`entity_id` int(10) unsigned NOT NULL,\n  `added_at` datetime NOT NULL,\n  `meta` json DEFAULT NULL,\n  `before` json DEFAULT NULL,\n  `changes` json DEFAULT NULL,\n  "+
 					"PRIMARY KEY (`id`),\n  KEY `entity_id` (`entity_id`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 ROW_FORMAT=COMPRESSED KEY_BLOCK_SIZE=8;",
 					logPool.databaseName, tableSchema.logTableName)
-				if !hasLogTable {
-					alters = append(alters, Alter{SQL: logTableSchema, Safe: true, Pool: tableSchema.logPoolName})
+				oldLogTableName := fmt.Sprintf("_log_%s_%s", tableSchema.mysqlPoolName, tableSchema.renamedFrom)
+				if !hasLogTable && tableSchema.renamedFrom != "" && tablesInDB[tableSchema.logPoolName][oldLogTableName] {
+					renameSQL := fmt.Sprintf("RENAME TABLE `%s`.`%s` TO `%s`.`%s`;", logPool.databaseName, oldLogTableName,
+						logPool.databaseName, tableSchema.logTableName)
+					rollbackSQL := fmt.Sprintf("RENAME TABLE `%s`.`%s` TO `%s`.`%s`;", logPool.databaseName, tableSchema.logTableName,
+						logPool.databaseName, oldLogTableName)
+					alters = append(alters, Alter{SQL: renameSQL, Safe: true, Pool: tableSchema.logPoolName, rollback: rollbackSQL,
+						table: tableSchema.logTableName, changes: []SchemaChange{getRenameTableChange(oldLogTableName, tableSchema.logTableName)}})
+					tablesInEntities[tableSchema.logPoolName][oldLogTableName] = true
+				} else if !hasLogTable {
+					dropTableSQL := fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`;", logPool.databaseName, tableSchema.logTableName)
+					alters = append(alters, Alter{SQL: logTableSchema, Safe: true, Pool: tableSchema.logPoolName, rollback: dropTableSQL,
+						table: tableSchema.logTableName, changes: []SchemaChange{{Kind: "table", Action: "created"}}})
 				} else {
-					var skip, createTableDB string
-					logPool.QueryRow(NewWhere(fmt.Sprintf("SHOW CREATE TABLE `%s`", tableSchema.logTableName)), &skip, &createTableDB)
-					createTableDB = strings.Replace(createTableDB, "CREATE TABLE ", fmt.Sprintf("CREATE TABLE `%s`.", logPool.databaseName), 1) + ";"
-					re := regexp.MustCompile(" AUTO_INCREMENT=[0-9]+ ")
-					createTableDB = re.ReplaceAllString(createTableDB, " ")
+					createTableDB := getCreateTableSQLDB(logPool, tableSchema.logTableName)
 					if logTableSchema != createTableDB {
 						isEmpty := isTableEmptyInPool(engine, tableSchema.logPoolName, tableSchema.logTableName)
 						dropTableSQL := fmt.Sprintf("DROP TABLE `%s`.`%s`;", logPool.databaseName, tableSchema.logTableName)
-						alters = append(alters, Alter{SQL: dropTableSQL, Safe: isEmpty, Pool: tableSchema.logPoolName})
-						alters = append(alters, Alter{SQL: logTableSchema, Safe: true, Pool: tableSchema.logPoolName})
+						alters = append(alters, Alter{SQL: dropTableSQL, Safe: isEmpty, Pool: tableSchema.logPoolName, rollback: createTableDB,
+							table: tableSchema.logTableName, changes: []SchemaChange{{Kind: "table", Action: "dropped"}}})
+						alters = append(alters, Alter{SQL: logTableSchema, Safe: true, Pool: tableSchema.logPoolName, rollback: dropTableSQL,
+							table: tableSchema.logTableName, changes: []SchemaChange{{Kind: "table", Action: "created"}}})
 					}
 				}
 				tablesInEntities[tableSchema.logPoolName][tableSchema.logTableName] = true
@@ -103,15 +125,16 @@ func getAlters(engine *Engine) (alters []Alter) {
 	for poolName, tables := range tablesInDB {
 		for tableName := range tables {
 			_, has := tablesInEntities[poolName][tableName]
-			if !has {
-				dropForeignKeyAlter := getDropForeignKeysAlter(engine, tableName, poolName)
-				if dropForeignKeyAlter != "" {
-					alters = append(alters, Alter{SQL: dropForeignKeyAlter, Safe: true, Pool: poolName})
+			if !has && tableName != migrationsTableName {
+				dropForeignKeyAlter, hasForeignKeys := getDropForeignKeysAlter(engine, tableName, poolName)
+				if hasForeignKeys {
+					alters = append(alters, dropForeignKeyAlter)
 				}
 				pool := engine.GetMysql(poolName)
 				dropSQL := fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`;", pool.GetDatabaseName(), tableName)
 				isEmpty := isTableEmptyInPool(engine, poolName, tableName)
-				alters = append(alters, Alter{SQL: dropSQL, Safe: isEmpty, Pool: poolName})
+				alters = append(alters, Alter{SQL: dropSQL, Safe: isEmpty, Pool: poolName, rollback: getCreateTableSQLDB(pool, tableName),
+					table: tableName, changes: []SchemaChange{{Kind: "table", Action: "dropped"}}})
 			}
 		}
 	}
@@ -177,7 +200,21 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 	pool := engine.GetMysql(tableSchema.mysqlPoolName)
 	createTableSQL := fmt.Sprintf("CREATE TABLE `%s`.`%s` (\n", pool.GetDatabaseName(), tableSchema.tableName)
 	createTableForiegnKeysSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s`\n", pool.GetDatabaseName(), tableSchema.tableName)
-	columns[0][1] += " AUTO_INCREMENT"
+	if tableSchema.primaryKeyType == "" {
+		columns[0][1] += " AUTO_INCREMENT"
+	} else {
+		columns[0][1] = tableSchema.getPrimaryKeyDefinition()
+	}
+	if tableSchema.primaryKey[0] != "ID" || (len(tableSchema.primaryKey) > 1 && tableSchema.partitioning == nil) {
+		indexes["ID"] = &index{Unique: true, Columns: map[int]string{1: "ID"}}
+	}
+	primaryKeySQL := fmt.Sprintf("PRIMARY KEY (`%s`)", strings.Join(tableSchema.primaryKey, "`,`"))
+	if !supportsIndexOptions(pool) {
+		for _, indexEntity := range indexes {
+			indexEntity.Descending = nil
+			indexEntity.Invisible = false
+		}
+	}
 	for _, value := range columns {
 		createTableSQL += fmt.Sprintf("  %s,\n", value[1])
 	}
@@ -188,6 +225,18 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 	for _, value := range newIndexes {
 		createTableSQL += fmt.Sprintf("  %s,\n", value[4:])
 	}
+	checks := tableSchema.getCheckConstraints()
+	if len(checks) > 0 && !supportsCheckConstraints(pool) {
+		checks = make(map[string]string)
+	}
+	var checkNames []string
+	for name := range checks {
+		checkNames = append(checkNames, name)
+	}
+	sort.Strings(checkNames)
+	for _, name := range checkNames {
+		createTableSQL += fmt.Sprintf("  %s,\n", buildCheckConstraintSQL(name, checks[name]))
+	}
 	for keyName, foreignKey := range foreignKeys {
 		newForeignKeys = append(newForeignKeys, buildCreateForeignKeySQL(keyName, foreignKey))
 	}
@@ -196,17 +245,51 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 		createTableForiegnKeysSQL += fmt.Sprintf("  %s,\n", value)
 	}
 
-	createTableSQL += "  PRIMARY KEY (`ID`)\n"
-	createTableSQL += fmt.Sprintf(") ENGINE=InnoDB DEFAULT CHARSET=%s;", engine.registry.registry.defaultEncoding)
+	createTableSQL += "  " + primaryKeySQL + "\n"
+	createTableSQL += fmt.Sprintf(") ENGINE=InnoDB DEFAULT CHARSET=%s", engine.registry.registry.defaultEncoding)
+	partitionSQL := ""
+	if tableSchema.partitioning != nil {
+		partitionSQL = tableSchema.partitioning.buildSQL(tableSchema.location)
+		createTableSQL += "\n" + partitionSQL
+	}
+	createTableSQL += ";"
 
 	var skip string
 	hasTable := pool.QueryRow(NewWhere(fmt.Sprintf("SHOW TABLES LIKE '%s'", tableSchema.tableName)), &skip)
 
+	if !hasTable && tableSchema.renamedFrom != "" {
+		hasOldTable := pool.QueryRow(NewWhere(fmt.Sprintf("SHOW TABLES LIKE '%s'", tableSchema.renamedFrom)), &skip)
+		if hasOldTable {
+			renameSQL := fmt.Sprintf("RENAME TABLE `%s`.`%s` TO `%s`.`%s`;", pool.GetDatabaseName(), tableSchema.renamedFrom,
+				pool.GetDatabaseName(), tableSchema.tableName)
+			rollbackSQL := fmt.Sprintf("RENAME TABLE `%s`.`%s` TO `%s`.`%s`;", pool.GetDatabaseName(), tableSchema.tableName,
+				pool.GetDatabaseName(), tableSchema.renamedFrom)
+			alters = []Alter{{SQL: renameSQL, Safe: true, Pool: tableSchema.mysqlPoolName, rollback: rollbackSQL,
+				table: tableSchema.tableName, changes: []SchemaChange{getRenameTableChange(tableSchema.renamedFrom, tableSchema.tableName)}}}
+			dropForeignKeyAlter, hasForeignKeys := getDropForeignKeysAlter(engine, tableSchema.renamedFrom, tableSchema.mysqlPoolName)
+			if hasForeignKeys {
+				dropForeignKeyAlter.table = tableSchema.tableName
+				alters = append(alters, dropForeignKeyAlter)
+				if len(newForeignKeys) > 0 {
+					createTableForiegnKeysSQL = strings.TrimRight(createTableForiegnKeysSQL, ",\n") + ";"
+					alters = append(alters, Alter{SQL: createTableForiegnKeysSQL, Safe: true, Pool: tableSchema.mysqlPoolName,
+						rollback: buildDropForeignKeysSQL(pool.GetDatabaseName(), tableSchema.tableName, foreignKeys),
+						table:    tableSchema.tableName, changes: getForeignKeysChanges(foreignKeys, "added")})
+				}
+			}
+			return true, alters
+		}
+	}
+
 	if !hasTable {
-		alters = []Alter{{SQL: createTableSQL, Safe: true, Pool: tableSchema.mysqlPoolName}}
+		dropTableSQL := fmt.Sprintf("DROP TABLE IF EXISTS `%s`.`%s`;", pool.GetDatabaseName(), tableSchema.tableName)
+		alters = []Alter{{SQL: createTableSQL, Safe: true, Pool: tableSchema.mysqlPoolName, rollback: dropTableSQL,
+			table: tableSchema.tableName, changes: []SchemaChange{{Kind: "table", Action: "created"}}}}
 		if len(newForeignKeys) > 0 {
 			createTableForiegnKeysSQL = strings.TrimRight(createTableForiegnKeysSQL, ",\n") + ";"
-			alters = append(alters, Alter{SQL: createTableForiegnKeysSQL, Safe: true, Pool: tableSchema.mysqlPoolName})
+			alters = append(alters, Alter{SQL: createTableForiegnKeysSQL, Safe: true, Pool: tableSchema.mysqlPoolName,
+				rollback: buildDropForeignKeysSQL(pool.GetDatabaseName(), tableSchema.tableName, foreignKeys),
+				table:    tableSchema.tableName, changes: getForeignKeysChanges(foreignKeys, "added")})
 		}
 		has = true
 		return
@@ -218,14 +301,17 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 	var createTableDB string
 	pool.QueryRow(NewWhere(fmt.Sprintf("SHOW CREATE TABLE `%s`", tableSchema.tableName)), &skip, &createTableDB)
 
+	generatedExpressions := tableSchema.getGeneratedExpressions()
 	hasAlters := false
 	hasAlterNormal := false
 	hasAlterEngineCharset := false
+	charsetDB := ""
 	lines := strings.Split(createTableDB, "\n")
 	for x := 1; x < len(lines); x++ {
 		if lines[x][2] != 96 {
 			for _, field := range strings.Split(lines[x], " ") {
 				if strings.HasPrefix(field, "CHARSET=") {
+					charsetDB = field[8:]
 					if field[8:] != engine.registry.registry.defaultEncoding {
 						hasAlters = true
 						hasAlterEngineCharset = true
@@ -236,6 +322,9 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 		}
 		var line = strings.TrimRight(lines[x], ",")
 		line = strings.TrimLeft(line, " ")
+		if tableSchema.hasGenerated {
+			line = normalizeGeneratedColumn(line, generatedExpressions)
+		}
 		var columnName = strings.Split(line, "`")[1]
 		tableDBColumns = append(tableDBColumns, [2]string{columnName, line})
 	}
@@ -244,9 +333,35 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 	/* #nosec */
 	results, def := pool.Query(fmt.Sprintf("SHOW INDEXES FROM `%s`", tableSchema.tableName))
 	defer def()
+	indexColumns := results.Columns()
 	for results.Next() {
+		values := make([]sql.NullString, len(indexColumns))
+		pointers := make([]interface{}, len(indexColumns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		results.Scan(pointers...)
 		var row indexDB
-		results.Scan(&row.Skip, &row.NonUnique, &row.KeyName, &row.Seq, &row.Column, &row.Skip, &row.Skip, &row.Skip, &row.Skip, &row.Skip, &row.Skip, &row.Skip, &row.Skip)
+		for i, column := range indexColumns {
+			switch column {
+			case "Non_unique":
+				row.NonUnique = values[i].String
+			case "Key_name":
+				row.KeyName = values[i].String
+			case "Seq_in_index":
+				row.Seq, _ = strconv.Atoi(values[i].String)
+			case "Column_name":
+				row.Column = values[i].String
+			case "Collation":
+				row.Collation = values[i].String
+			case "Sub_part":
+				row.SubPart, _ = strconv.Atoi(values[i].String)
+			case "Index_type":
+				row.IndexType = values[i].String
+			case "Visible":
+				row.Visible = values[i].String
+			}
+		}
 		rows = append(rows, row)
 	}
 	def()
@@ -254,17 +369,62 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 	for _, value := range rows {
 		current, has := indexesDB[value.KeyName]
 		if !has {
-			current = &index{Unique: value.NonUnique == 0, Columns: map[int]string{value.Seq: value.Column}}
+			current = &index{Unique: value.NonUnique == "0", Columns: make(map[int]string), SubParts: make(map[int]int),
+				Descending: make(map[int]bool), Invisible: value.Visible == "NO"}
+			if value.IndexType == "FULLTEXT" || value.IndexType == "SPATIAL" {
+				current.Type = value.IndexType
+			}
 			indexesDB[value.KeyName] = current
-		} else {
-			current.Columns[value.Seq] = value.Column
+		}
+		current.Columns[value.Seq] = value.Column
+		if value.SubPart > 0 {
+			current.SubParts[value.Seq] = value.SubPart
+		}
+		if value.Collation == "D" {
+			current.Descending[value.Seq] = true
+		}
+	}
+
+	indexesDBSQL := make(map[string]string)
+	for keyName, indexDB := range indexesDB {
+		indexesDBSQL[keyName] = buildCreateIndexSQL(keyName, indexDB)
+	}
+	renamedColumns := make(map[string]string)
+	for _, value := range columns {
+		oldName := tableSchema.tags[value[0]]["renamedFrom"]
+		if oldName == "" {
+			continue
+		}
+		hasNew := false
+		hasOld := false
+		for _, v := range tableDBColumns {
+			if v[0] == value[0] {
+				hasNew = true
+			} else if v[0] == oldName {
+				hasOld = true
+			}
+		}
+		if hasOld && !hasNew {
+			renamedColumns[oldName] = value[0]
+		}
+	}
+	for _, indexDB := range indexesDB {
+		for i, column := range indexDB.Columns {
+			newName, has := renamedColumns[column]
+			if has {
+				indexDB.Columns[i] = newName
+			}
 		}
 	}
 
 	foreignKeysDB := getForeignKeys(engine, createTableDB, tableSchema.tableName, tableSchema.mysqlPoolName)
+	clauseChanges := make(map[string]SchemaChange)
 
 	var newColumns []string
 	var changedColumns [][2]string
+	var renamedColumnsAlters [][2]string
+	var rollbackDroppedColumns []string
+	rollbackChangedColumns := make(map[string]string)
 
 	for key, value := range columns {
 		var tableColumn string
@@ -276,6 +436,7 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 		}
 		hasName := -1
 		hasDefinition := -1
+		oldName := ""
 		for z, v := range tableDBColumns {
 			if v[1] == value[1] {
 				hasDefinition = z
@@ -283,15 +444,40 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 			if v[0] == value[0] {
 				hasName = z
 			}
+			if renamedColumns[v[0]] == value[0] {
+				oldName = v[0]
+				hasName = z
+			}
 		}
-		if hasName == -1 {
+		if oldName != "" {
+			alter := fmt.Sprintf("CHANGE COLUMN `%s` %s", oldName, value[1])
+			if key > 0 {
+				alter += fmt.Sprintf(" AFTER `%s`", columns[key-1][0])
+			}
+			oldDefinition := strings.TrimPrefix(tableDBColumns[hasName][1], fmt.Sprintf("`%s`", oldName))
+			change := SchemaChange{Kind: "column", Action: "renamed", Name: value[0], Details: fmt.Sprintf("from `%s`", oldName)}
+			if oldDefinition == strings.TrimPrefix(value[1], fmt.Sprintf("`%s`", value[0])) {
+				renamedColumnsAlters = append(renamedColumnsAlters, [2]string{alter, fmt.Sprintf("RENAMED FROM `%s`", oldName)})
+			} else {
+				/* #nosec */
+				changedColumns = append(changedColumns, [2]string{alter, fmt.Sprintf("RENAMED AND CHANGED FROM %s", tableDBColumns[hasName][1])})
+				change.Details += " definition was " + tableDBColumns[hasName][1]
+			}
+			clauseChanges[alter] = change
+			rollbackChangedColumns[oldName] = value[0]
+			hasAlters = true
+		} else if hasName == -1 {
 			alter := fmt.Sprintf("ADD COLUMN %s", value[1])
 			if key > 0 {
 				alter += fmt.Sprintf(" AFTER `%s`", columns[key-1][0])
 			}
 			newColumns = append(newColumns, alter)
+			clauseChanges[alter] = SchemaChange{Kind: "column", Action: "added", Name: value[0],
+				Details: strings.TrimPrefix(alter, fmt.Sprintf("ADD COLUMN `%s` ", value[0]))}
+			rollbackDroppedColumns = append(rollbackDroppedColumns, fmt.Sprintf("DROP COLUMN `%s`", value[0]))
 			hasAlters = true
 		} else {
+			rollbackChangedColumns[value[0]] = value[0]
 			if hasDefinition == -1 {
 				alter := fmt.Sprintf("CHANGE COLUMN `%s` %s", value[0], value[1])
 				if key > 0 {
@@ -300,6 +486,8 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 				}
 				/* #nosec */
 				changedColumns = append(changedColumns, [2]string{alter, fmt.Sprintf("CHANGED FROM %s", tableDBColumns[hasName][1])})
+				clauseChanges[alter] = SchemaChange{Kind: "column", Action: "changed", Name: value[0],
+					Details: "definition was " + tableDBColumns[hasName][1]}
 				hasAlters = true
 			} else {
 				alter := fmt.Sprintf("CHANGE COLUMN `%s` %s", value[0], value[1])
@@ -307,11 +495,13 @@ func getSchemaChanges(engine *Engine, tableSchema *tableSchema) (has bool, alter
 					alter += fmt.Sprintf(" AFTER `%s`", columns[key-1][0])
 				}
 				changedColumns = append(changedColumns, [2]string{alter, "CHANGED ORDER"})
+				clauseChanges[alter] = SchemaChange{Kind: "column", Action: "changed", Name: value[0], Details: "order"}
 				hasAlters = true
 			}
 		}
 	}
 	droppedColumns := make([]string, 0)
+	rollbackAlters := rollbackDroppedColumns
 OUTER:
 	for _, value := range tableDBColumns {
 		for _, v := range columns {
@@ -319,39 +509,85 @@ OUTER:
 				continue OUTER
 			}
 		}
+		_, isRenamed := renamedColumns[value[0]]
+		if isRenamed {
+			continue
+		}
 		droppedColumns = append(droppedColumns, fmt.Sprintf("DROP COLUMN `%s`", value[0]))
+		clauseChanges[droppedColumns[len(droppedColumns)-1]] = SchemaChange{Kind: "column", Action: "dropped", Name: value[0]}
 		hasAlters = true
 	}
+	for key, value := range tableDBColumns {
+		position := " FIRST"
+		if key > 0 {
+			position = fmt.Sprintf(" AFTER `%s`", tableDBColumns[key-1][0])
+		}
+		currentName, isChanged := rollbackChangedColumns[value[0]]
+		if isChanged {
+			rollbackAlters = append(rollbackAlters, fmt.Sprintf("CHANGE COLUMN `%s` %s%s", currentName, value[1], position))
+		} else if !hasColumn(columns, value[0]) && renamedColumns[value[0]] == "" {
+			rollbackAlters = append(rollbackAlters, fmt.Sprintf("ADD COLUMN %s%s", value[1], position))
+		}
+	}
 
 	var droppedIndexes []string
+	var rollbackDroppedIndexes []string
+	var rollbackNewIndexes []string
+	clauseChanges["ADD "+primaryKeySQL] = SchemaChange{Kind: "primary key", Action: "added", Details: strings.TrimPrefix(primaryKeySQL, "PRIMARY KEY ")}
+	clauseChanges["DROP PRIMARY KEY"] = SchemaChange{Kind: "primary key", Action: "dropped"}
+	primaryKeyDB, has := indexesDB["PRIMARY"]
+	if !has {
+		newIndexes = append(newIndexes, "ADD "+primaryKeySQL)
+		rollbackDroppedIndexes = append(rollbackDroppedIndexes, "DROP PRIMARY KEY")
+		hasAlters = true
+	} else if strings.Replace(buildCreateIndexSQL("PRIMARY", primaryKeyDB), "ADD UNIQUE INDEX `PRIMARY`", "PRIMARY KEY", 1) != primaryKeySQL {
+		droppedIndexes = append(droppedIndexes, "DROP PRIMARY KEY")
+		newIndexes = append(newIndexes, "ADD "+primaryKeySQL)
+		rollbackDroppedIndexes = append(rollbackDroppedIndexes, "DROP PRIMARY KEY")
+		rollbackNewIndexes = append(rollbackNewIndexes, strings.Replace(indexesDBSQL["PRIMARY"], "ADD UNIQUE INDEX `PRIMARY`", "ADD PRIMARY KEY", 1))
+		hasAlters = true
+	}
 	for keyName, indexEntity := range indexes {
 		indexDB, has := indexesDB[keyName]
+		addIndexSQLEntity := buildCreateIndexSQL(keyName, indexEntity)
+		clauseChanges[addIndexSQLEntity] = SchemaChange{Kind: "index", Action: "added", Name: keyName,
+			Details: strings.SplitN(addIndexSQLEntity, fmt.Sprintf("`%s` ", keyName), 2)[1]}
+		clauseChanges[fmt.Sprintf("DROP INDEX `%s`", keyName)] = SchemaChange{Kind: "index", Action: "dropped", Name: keyName}
 		if !has {
-			newIndexes = append(newIndexes, buildCreateIndexSQL(keyName, indexEntity))
+			newIndexes = append(newIndexes, addIndexSQLEntity)
+			rollbackDroppedIndexes = append(rollbackDroppedIndexes, fmt.Sprintf("DROP INDEX `%s`", keyName))
 			hasAlters = true
 		} else {
-			addIndexSQLEntity := buildCreateIndexSQL(keyName, indexEntity)
 			addIndexSQLDB := buildCreateIndexSQL(keyName, indexDB)
 			if addIndexSQLEntity != addIndexSQLDB {
 				droppedIndexes = append(droppedIndexes, fmt.Sprintf("DROP INDEX `%s`", keyName))
 				newIndexes = append(newIndexes, addIndexSQLEntity)
+				rollbackDroppedIndexes = append(rollbackDroppedIndexes, fmt.Sprintf("DROP INDEX `%s`", keyName))
+				rollbackNewIndexes = append(rollbackNewIndexes, indexesDBSQL[keyName])
 				hasAlters = true
 			}
 		}
 	}
 
 	var droppedForeignKeys []string
+	var rollbackDroppedForeignKeys []string
+	var rollbackNewForeignKeys []string
 	for keyName, indexEntity := range foreignKeys {
 		indexDB, has := foreignKeysDB[keyName]
+		addIndexSQLEntity := buildCreateForeignKeySQL(keyName, indexEntity)
+		clauseChanges[addIndexSQLEntity] = SchemaChange{Kind: "foreign key", Action: "added", Name: keyName}
+		clauseChanges[fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName)] = SchemaChange{Kind: "foreign key", Action: "dropped", Name: keyName}
 		if !has {
-			newForeignKeys = append(newForeignKeys, buildCreateForeignKeySQL(keyName, indexEntity))
+			newForeignKeys = append(newForeignKeys, addIndexSQLEntity)
+			rollbackDroppedForeignKeys = append(rollbackDroppedForeignKeys, fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName))
 			hasAlters = true
 		} else {
-			addIndexSQLEntity := buildCreateForeignKeySQL(keyName, indexEntity)
 			addIndexSQLDB := buildCreateForeignKeySQL(keyName, indexDB)
 			if addIndexSQLEntity != addIndexSQLDB {
 				droppedForeignKeys = append(droppedForeignKeys, fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName))
 				newForeignKeys = append(newForeignKeys, addIndexSQLEntity)
+				rollbackDroppedForeignKeys = append(rollbackDroppedForeignKeys, fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName))
+				rollbackNewForeignKeys = append(rollbackNewForeignKeys, addIndexSQLDB)
 				hasAlters = true
 			}
 		}
@@ -362,17 +598,58 @@ OUTER:
 			_, has = foreignKeys[keyName]
 			if !has {
 				droppedIndexes = append(droppedIndexes, fmt.Sprintf("DROP INDEX `%s`", keyName))
+				clauseChanges[fmt.Sprintf("DROP INDEX `%s`", keyName)] = SchemaChange{Kind: "index", Action: "dropped", Name: keyName}
+				rollbackNewIndexes = append(rollbackNewIndexes, indexesDBSQL[keyName])
 				hasAlters = true
 			}
 		}
 	}
-	for keyName := range foreignKeysDB {
+	for keyName, indexDB := range foreignKeysDB {
 		_, has := foreignKeys[keyName]
 		if !has {
 			droppedForeignKeys = append(droppedForeignKeys, fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName))
+			clauseChanges[fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName)] = SchemaChange{Kind: "foreign key", Action: "dropped", Name: keyName}
+			rollbackNewForeignKeys = append(rollbackNewForeignKeys, buildCreateForeignKeySQL(keyName, indexDB))
 			hasAlters = true
 		}
 	}
+	var droppedChecks []string
+	var newChecks []string
+	var rollbackChecks []string
+	checksDB := getCheckConstraintsDB(createTableDB)
+	for _, name := range checkNames {
+		_, has := checksDB[name]
+		if !has {
+			newChecks = append(newChecks, "ADD "+buildCheckConstraintSQL(name, checks[name]))
+			clauseChanges[newChecks[len(newChecks)-1]] = SchemaChange{Kind: "check", Action: "added", Name: name,
+				Details: fmt.Sprintf("CHECK (%s)", checks[name])}
+			rollbackChecks = append(rollbackChecks, fmt.Sprintf("DROP CHECK `%s`", name))
+			hasAlters = true
+		}
+	}
+	for name, definition := range checksDB {
+		_, has := checks[name]
+		if !has {
+			droppedChecks = append(droppedChecks, fmt.Sprintf("DROP CHECK `%s`", name))
+			clauseChanges[droppedChecks[len(droppedChecks)-1]] = SchemaChange{Kind: "check", Action: "dropped", Name: name}
+			rollbackChecks = append(rollbackChecks, "ADD "+definition)
+			hasAlters = true
+		}
+	}
+	alterPartitioning := ""
+	rollbackPartitioning := ""
+	partitioningEntity := ""
+	if tableSchema.partitioning != nil {
+		partitioningEntity = tableSchema.partitioning.signature()
+	}
+	if partitioningEntity != getPartitioningDB(createTableDB) {
+		alterPartitioning = partitionSQL
+		if alterPartitioning == "" {
+			alterPartitioning = "REMOVE PARTITIONING"
+		}
+		rollbackPartitioning = getPartitionSQLDB(createTableDB)
+		hasAlters = true
+	}
 	if !hasAlters {
 		return
 	}
@@ -402,12 +679,22 @@ OUTER:
 		newAlters = append(newAlters, fmt.Sprintf("    %s", value[0]))
 		comments = append(comments, value[1])
 	}
+	for _, value := range renamedColumnsAlters {
+		newAlters = append(newAlters, fmt.Sprintf("    %s", value[0]))
+		comments = append(comments, value[1])
+	}
 	sort.Strings(droppedIndexes)
 	for _, value := range droppedIndexes {
 		newAlters = append(newAlters, fmt.Sprintf("    %s", value))
 		comments = append(comments, "")
 		hasAlterNormal = true
 	}
+	sort.Strings(droppedChecks)
+	for _, value := range droppedChecks {
+		newAlters = append(newAlters, fmt.Sprintf("    %s", value))
+		comments = append(comments, "")
+		hasAlterNormal = true
+	}
 	sort.Strings(droppedForeignKeys)
 	for _, value := range droppedForeignKeys {
 		newAltersRemoveForeignKey = append(newAltersRemoveForeignKey, fmt.Sprintf("    %s", value))
@@ -419,6 +706,11 @@ OUTER:
 		comments = append(comments, "")
 		hasAlterNormal = true
 	}
+	for _, value := range newChecks {
+		newAlters = append(newAlters, fmt.Sprintf("    %s", value))
+		comments = append(comments, "")
+		hasAlterNormal = true
+	}
 	sort.Strings(newForeignKeys)
 	for _, value := range newForeignKeys {
 		newAltersAddForeignKey = append(newAltersAddForeignKey, fmt.Sprintf("    %s", value))
@@ -436,10 +728,21 @@ OUTER:
 	lastIndex := len(newAlters) - 1
 	if lastIndex >= 0 {
 		hasAlterNormal = true
-		alterSQL += newAlters[lastIndex] + ";"
-		if comments[lastIndex] != "" {
-			alterSQL += fmt.Sprintf("/*%s*/", comments[lastIndex])
+		alterSQL += newAlters[lastIndex]
+		if alterPartitioning != "" {
+			if comments[lastIndex] != "" {
+				alterSQL += fmt.Sprintf("/*%s*/", comments[lastIndex])
+			}
+			alterSQL += fmt.Sprintf("\n    %s;", alterPartitioning)
+		} else {
+			alterSQL += ";"
+			if comments[lastIndex] != "" {
+				alterSQL += fmt.Sprintf("/*%s*/", comments[lastIndex])
+			}
 		}
+	} else if alterPartitioning != "" {
+		hasAlterNormal = true
+		alterSQL += fmt.Sprintf("    %s;", alterPartitioning)
 	}
 
 	for x := 0; x < len(newAltersAddForeignKey); x++ {
@@ -451,28 +754,58 @@ OUTER:
 		alterSQLRemoveForeignKey += "\n"
 	}
 
+	getClauseChanges := func(clauses []string) []SchemaChange {
+		changes := make([]SchemaChange, 0, len(clauses))
+		for _, clause := range clauses {
+			changes = append(changes, clauseChanges[strings.TrimSpace(clause)])
+		}
+		return changes
+	}
 	alters = make([]Alter, 0)
 	if hasAlterNormal {
 		safe := false
-		if len(droppedColumns) == 0 && len(changedColumns) == 0 {
+		if len(droppedColumns) == 0 && len(changedColumns) == 0 && alterPartitioning == "" {
 			safe = true
 		} else {
 			db := tableSchema.GetMysql(engine)
 			isEmpty := isTableEmpty(db.client, tableSchema.tableName)
 			safe = isEmpty
 		}
-		alters = append(alters, Alter{SQL: alterSQL, Safe: safe, Pool: tableSchema.mysqlPoolName})
+		sort.Strings(rollbackDroppedIndexes)
+		sort.Strings(rollbackChecks)
+		sort.Strings(rollbackNewIndexes)
+		rollbackAlters = append(rollbackAlters, rollbackDroppedIndexes...)
+		rollbackAlters = append(rollbackAlters, rollbackChecks...)
+		rollbackAlters = append(rollbackAlters, rollbackNewIndexes...)
+		rollbackSQL := buildRollbackAlterSQL(pool.GetDatabaseName(), tableSchema.tableName, rollbackAlters, rollbackPartitioning)
+		changes := getClauseChanges(newAlters)
+		if alterPartitioning == "REMOVE PARTITIONING" {
+			changes = append(changes, SchemaChange{Kind: "partitioning", Action: "dropped"})
+		} else if alterPartitioning != "" {
+			changes = append(changes, SchemaChange{Kind: "partitioning", Action: "changed", Details: alterPartitioning})
+		}
+		alters = append(alters, Alter{SQL: alterSQL, Safe: safe, Pool: tableSchema.mysqlPoolName, rollback: rollbackSQL,
+			table: tableSchema.tableName, changes: changes})
 	} else if hasAlterEngineCharset {
-		alterSQL += fmt.Sprintf(" ENGINE=InnoDB DEFAULT CHARSET=%s;", engine.registry.registry.defaultEncoding)
-		alters = append(alters, Alter{SQL: alterSQL, Safe: true, Pool: tableSchema.mysqlPoolName})
+		rollbackSQL := alterSQL + fmt.Sprintf(" ENGINE=InnoDB DEFAULT CHARSET=%s;", charsetDB)
+		charsetSQL := fmt.Sprintf("ENGINE=InnoDB DEFAULT CHARSET=%s", engine.registry.registry.defaultEncoding)
+		alterSQL += " " + charsetSQL + ";"
+		alters = append(alters, Alter{SQL: alterSQL, Safe: true, Pool: tableSchema.mysqlPoolName, rollback: rollbackSQL,
+			table: tableSchema.tableName, changes: []SchemaChange{{Kind: "charset", Action: "changed", Details: charsetSQL}}})
 	}
 	if hasAlterRemoveForeignKey {
 		alterSQLRemoveForeignKey = strings.TrimRight(alterSQLRemoveForeignKey, ",\n") + ";"
-		alters = append(alters, Alter{SQL: alterSQLRemoveForeignKey, Safe: true, Pool: tableSchema.mysqlPoolName})
+		sort.Strings(rollbackNewForeignKeys)
+		rollbackSQL := buildRollbackAlterSQL(pool.GetDatabaseName(), tableSchema.tableName, rollbackNewForeignKeys, "")
+		alters = append(alters, Alter{SQL: alterSQLRemoveForeignKey, Safe: true, Pool: tableSchema.mysqlPoolName, rollback: rollbackSQL,
+			table: tableSchema.tableName, changes: getClauseChanges(newAltersRemoveForeignKey)})
 	}
 	if hasAlterAddForeignKey {
 		alterSQLAddForeignKey = strings.TrimRight(alterSQLAddForeignKey, ",\n") + ";"
-		alters = append(alters, Alter{SQL: alterSQLAddForeignKey, Safe: true, Pool: tableSchema.mysqlPoolName})
+		sort.Strings(rollbackDroppedForeignKeys)
+		rollbackSQL := buildRollbackAlterSQL(pool.GetDatabaseName(), tableSchema.tableName, rollbackDroppedForeignKeys, "")
+		alters = append(alters, Alter{SQL: alterSQLAddForeignKey, Safe: true, Pool: tableSchema.mysqlPoolName, rollback: rollbackSQL,
+			table: tableSchema.tableName, changes: getClauseChanges(newAltersAddForeignKey)})
 	}
 
 	has = true
@@ -512,23 +845,98 @@ func getForeignKeys(engine *Engine, createTableDB string, tableName string, pool
 	return foreignKeysDB
 }
 
-func getDropForeignKeysAlter(engine *Engine, tableName string, poolName string) string {
+func getDropForeignKeysAlter(engine *Engine, tableName string, poolName string) (alter Alter, has bool) {
 	var skip string
 	var createTableDB string
 	pool := engine.GetMysql(poolName)
 	pool.QueryRow(NewWhere(fmt.Sprintf("SHOW CREATE TABLE `%s`", tableName)), &skip, &createTableDB)
-	alter := fmt.Sprintf("ALTER TABLE `%s`.`%s`\n", pool.GetDatabaseName(), tableName)
+	sql := fmt.Sprintf("ALTER TABLE `%s`.`%s`\n", pool.GetDatabaseName(), tableName)
 	foreignKeysDB := getForeignKeys(engine, createTableDB, tableName, poolName)
 	if len(foreignKeysDB) == 0 {
-		return ""
+		return alter, false
+	}
+	droppedForeignKeys := make([]string, 0)
+	addedForeignKeys := make([]string, 0)
+	for keyName, definition := range foreignKeysDB {
+		droppedForeignKeys = append(droppedForeignKeys, fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName))
+		addedForeignKeys = append(addedForeignKeys, buildCreateForeignKeySQL(keyName, definition))
+	}
+	sql += strings.Join(droppedForeignKeys, ",\t\n")
+	sql = strings.TrimRight(sql, ",") + ";"
+	sort.Strings(addedForeignKeys)
+	return Alter{SQL: sql, Safe: true, Pool: poolName, rollback: buildRollbackAlterSQL(pool.GetDatabaseName(), tableName, addedForeignKeys, ""),
+		table: tableName, changes: getForeignKeysChanges(foreignKeysDB, "dropped")}, true
+}
+
+func getForeignKeysChanges(foreignKeys map[string]*foreignIndex, action string) []SchemaChange {
+	names := make([]string, 0, len(foreignKeys))
+	for keyName := range foreignKeys {
+		names = append(names, keyName)
 	}
+	sort.Strings(names)
+	changes := make([]SchemaChange, len(names))
+	for i, keyName := range names {
+		changes[i] = SchemaChange{Kind: "foreign key", Action: action, Name: keyName}
+	}
+	return changes
+}
+
+func getRenameTableChange(from string, to string) SchemaChange {
+	return SchemaChange{Kind: "table", Action: "renamed", Name: to, Details: fmt.Sprintf("from `%s`", from)}
+}
+
+func buildDropForeignKeysSQL(database string, tableName string, foreignKeys map[string]*foreignIndex) string {
 	droppedForeignKeys := make([]string, 0)
-	for keyName := range foreignKeysDB {
+	for keyName := range foreignKeys {
 		droppedForeignKeys = append(droppedForeignKeys, fmt.Sprintf("DROP FOREIGN KEY `%s`", keyName))
 	}
-	alter += strings.Join(droppedForeignKeys, ",\t\n")
-	alter = strings.TrimRight(alter, ",") + ";"
-	return alter
+	sort.Strings(droppedForeignKeys)
+	return buildRollbackAlterSQL(database, tableName, droppedForeignKeys, "")
+}
+
+func buildRollbackAlterSQL(database string, tableName string, clauses []string, partitioning string) string {
+	if len(clauses) == 0 && partitioning == "" {
+		return ""
+	}
+	sql := fmt.Sprintf("ALTER TABLE `%s`.`%s`\n", database, tableName)
+	if len(clauses) > 0 {
+		sql += "    " + strings.Join(clauses, ",\n    ")
+		if partitioning != "" {
+			sql += "\n"
+		}
+	}
+	if partitioning != "" {
+		sql += "    " + partitioning
+	}
+	return sql + ";"
+}
+
+func getCreateTableSQLDB(pool *DB, tableName string) string {
+	var skip, createTableDB string
+	pool.QueryRow(NewWhere(fmt.Sprintf("SHOW CREATE TABLE `%s`", tableName)), &skip, &createTableDB)
+	lines := make([]string, 0)
+	for _, line := range strings.Split(createTableDB, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "CONSTRAINT ") && strings.Contains(trimmed, " FOREIGN KEY ") {
+			continue
+		}
+		if strings.HasPrefix(line, ")") && len(lines) > 0 {
+			lines[len(lines)-1] = strings.TrimRight(lines[len(lines)-1], ",")
+		}
+		lines = append(lines, line)
+	}
+	createTableDB = strings.Replace(strings.Join(lines, "\n"), "CREATE TABLE ", fmt.Sprintf("CREATE TABLE `%s`.", pool.GetDatabaseName()), 1) + ";"
+	re := regexp.MustCompile(" AUTO_INCREMENT=[0-9]+ ")
+	return re.ReplaceAllString(createTableDB, " ")
+}
+
+func hasColumn(columns [][2]string, name string) bool {
+	for _, column := range columns {
+		if column[0] == name {
+			return true
+		}
+	}
+	return false
 }
 
 func isTableEmpty(db sqlClient, tableName string) bool {
@@ -563,11 +971,15 @@ func checkColumn(engine *Engine, schema *tableSchema, field *reflect.StructField
 		return nil, nil
 	}
 
-	keys := []string{"index", "unique"}
+	keys := []string{"index", "unique", "fulltext", "spatial"}
 	var refOneSchema *tableSchema
 	for _, key := range keys {
 		indexAttribute, has := attributes[key]
 		unique := key == "unique"
+		indexType := ""
+		if key == "fulltext" || key == "spatial" {
+			indexType = strings.ToUpper(key)
+		}
 		if key == "index" && field.Type.Kind() == reflect.Ptr {
 			refOneSchema = getTableSchema(engine.registry, field.Type.Elem())
 			if refOneSchema != nil {
@@ -598,11 +1010,34 @@ func checkColumn(engine *Engine, schema *tableSchema, field *reflect.StructField
 				}
 				current, has := indexes[indexColumn[0]]
 				if !has {
-					current = &index{Unique: unique, Columns: map[int]string{location: field.Name}}
+					current = &index{Unique: unique, Type: indexType, Columns: map[int]string{location: field.Name}}
 					indexes[indexColumn[0]] = current
 				} else {
 					current.Columns[location] = field.Name
 				}
+				var options []string
+				if len(indexColumn) > 2 {
+					options = indexColumn[2:]
+				}
+				for _, option := range options {
+					subPart, err := strconv.Atoi(option)
+					switch {
+					case err == nil && subPart > 0:
+						if current.SubParts == nil {
+							current.SubParts = make(map[int]int)
+						}
+						current.SubParts[location] = subPart
+					case option == "desc":
+						if current.Descending == nil {
+							current.Descending = make(map[int]bool)
+						}
+						current.Descending[location] = true
+					case option == "invisible":
+						current.Invisible = true
+					default:
+						return nil, errors.Errorf("invalid option '%s' in index '%s'", option, indexColumn[0])
+					}
+				}
 			}
 		}
 	}
@@ -624,7 +1059,15 @@ func checkColumn(engine *Engine, schema *tableSchema, field *reflect.StructField
 	isRequired := hasRequired && required == "true"
 
 	var err error
+	handler, isCustom := schema.columnTypes[columnName]
+	if isCustom {
+		typeAsString = "custom"
+	}
 	switch typeAsString {
+	case "custom":
+		var nullable bool
+		definition, nullable = handler.MySQLDefinition(attributes)
+		addNotNullIfNotSet = !nullable
 	case "uint",
 		"uint8",
 		"uint32",
@@ -680,7 +1123,12 @@ func checkColumn(engine *Engine, schema *tableSchema, field *reflect.StructField
 	case "*time.Time":
 		definition, addNotNullIfNotSet, addDefaultNullIfNullable, defaultValue = handleTime(attributes, true)
 	case "[]uint8":
-		definition, addDefaultNullIfNullable = handleBlob(attributes)
+		geometry, isGeometry := attributes["geometry"]
+		if isGeometry {
+			definition, addNotNullIfNotSet = geometry, true
+		} else {
+			definition, addDefaultNullIfNullable = handleBlob(attributes)
+		}
 	case "*orm.CachedQuery":
 		return nil, nil
 	default:
@@ -700,6 +1148,10 @@ func checkColumn(engine *Engine, schema *tableSchema, field *reflect.StructField
 			definition = "json"
 		}
 	}
+	expression, isGenerated := attributes["generated"]
+	if isGenerated {
+		return [][2]string{{columnName, fmt.Sprintf("`%s` %s", columnName, buildGeneratedColumnDefinition(definition, expression, attributes))}}, nil
+	}
 	isNotNull := false
 	if addNotNullIfNotSet || isRequired {
 		definition += " NOT NULL"
@@ -808,10 +1260,32 @@ func handleSetEnum(registry *validatedRegistry, fieldType string, attribute stri
 }
 
 func handleTime(attributes map[string]string, nullable bool) (string, bool, bool, string) {
-	t := attributes["time"]
 	defaultValue := "nil"
-	if t == "true" {
-		return "datetime", !nullable, true, "nil"
+	precision := getTimePrecision(attributes)
+	if hasTimePart(attributes) {
+		definition := "datetime"
+		current := "CURRENT_TIMESTAMP"
+		if attributes["timestamp"] == "true" {
+			definition = "timestamp"
+		}
+		if precision > 0 {
+			definition += fmt.Sprintf("(%d)", precision)
+			current += fmt.Sprintf("(%d)", precision)
+		}
+		if strings.HasPrefix(definition, "timestamp") && nullable && attributes["required"] != "true" {
+			definition += " NULL"
+		}
+		_, onUpdate := attributes["onUpdateCurrentTimestamp"]
+		_, hasCurrent := attributes["currentTimestamp"]
+		if hasCurrent || (onUpdate && !nullable) {
+			defaultValue = current
+		} else if onUpdate {
+			defaultValue = "NULL"
+		}
+		if onUpdate {
+			defaultValue += " ON UPDATE " + current
+		}
+		return definition, !nullable, true, defaultValue
 	}
 	if !nullable {
 		defaultValue = "'0001-01-01'"
@@ -819,6 +1293,16 @@ func handleTime(attributes map[string]string, nullable bool) (string, bool, bool
 	return "date", !nullable, true, defaultValue
 }
 
+func hasTimePart(attributes map[string]string) bool {
+	_, hasPrecision := attributes["precision"]
+	return attributes["time"] == "true" || attributes["timestamp"] == "true" || hasPrecision
+}
+
+func getTimePrecision(attributes map[string]string) int {
+	precision, _ := strconv.Atoi(attributes["precision"])
+	return precision
+}
+
 func handleReferenceOne(schema *tableSchema, attributes map[string]string) string {
 	return convertIntToSchema(schema.t.Field(1).Type.String(), attributes)
 }
@@ -886,19 +1370,36 @@ func checkStruct(tableSchema *tableSchema, engine *Engine, t reflect.Type, index
 	return columns, nil
 }
 
+func supportsIndexOptions(pool *DB) bool {
+	return isMySQLVersionAtLeast(pool.version, 8, 0, 0)
+}
+
 func buildCreateIndexSQL(keyName string, definition *index) string {
 	var indexColumns []string
 	for i := 1; i <= 100; i++ {
 		value, has := definition.Columns[i]
 		if has {
-			indexColumns = append(indexColumns, fmt.Sprintf("`%s`", value))
+			column := fmt.Sprintf("`%s`", value)
+			if definition.SubParts[i] > 0 {
+				column += fmt.Sprintf("(%d)", definition.SubParts[i])
+			}
+			if definition.Descending[i] {
+				column += " DESC"
+			}
+			indexColumns = append(indexColumns, column)
 		} else {
 			break
 		}
 	}
 	indexType := "INDEX"
-	if definition.Unique {
+	if definition.Type != "" {
+		indexType = definition.Type + " " + indexType
+	} else if definition.Unique {
 		indexType = "UNIQUE " + indexType
 	}
-	return fmt.Sprintf("ADD %s `%s` (%s)", indexType, keyName, strings.Join(indexColumns, ","))
+	sql := fmt.Sprintf("ADD %s `%s` (%s)", indexType, keyName, strings.Join(indexColumns, ","))
+	if definition.Invisible {
+		sql += " INVISIBLE"
+	}
+	return sql
 }
@@ -0,0 +1,144 @@
+package orm
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type SchemaChange struct {
+	Kind    string
+	Action  string
+	Name    string
+	Details string
+}
+
+type SchemaTableDiff struct {
+	Pool    string
+	Table   string
+	Safe    bool
+	Reasons []string
+	Changes []SchemaChange
+	Alters  []Alter
+}
+
+func GetSchemaDiff(alters []Alter) []*SchemaTableDiff {
+	diffs := make([]*SchemaTableDiff, 0)
+	tables := make(map[string]*SchemaTableDiff)
+	for _, alter := range alters {
+		changes := alter.changes
+		if changes == nil {
+			changes = []SchemaChange{{Kind: "other", Action: "changed", Details: alter.SQL}}
+		}
+		diff, has := tables[alter.Pool+":"+alter.table]
+		if !has {
+			diff = &SchemaTableDiff{Pool: alter.Pool, Table: alter.table, Safe: true}
+			tables[alter.Pool+":"+alter.table] = diff
+			diffs = append(diffs, diff)
+		}
+		diff.Alters = append(diff.Alters, alter)
+		diff.Changes = append(diff.Changes, changes...)
+		if !alter.Safe {
+			diff.Safe = false
+			diff.Reasons = append(diff.Reasons, getUnsafeReasons(changes)...)
+		}
+	}
+	for _, diff := range diffs {
+		diff.Changes = mergeSchemaChanges(diff.Changes)
+	}
+	return diffs
+}
+
+func WriteSchemaDiff(w io.Writer, diffs []*SchemaTableDiff) {
+	lastPool := ""
+	for _, diff := range diffs {
+		if diff.Pool != lastPool {
+			_, _ = fmt.Fprintf(w, "pool %s\n", diff.Pool)
+			lastPool = diff.Pool
+		}
+		status := "safe"
+		if !diff.Safe {
+			status = "UNSAFE: " + strings.Join(diff.Reasons, ", ")
+		}
+		_, _ = fmt.Fprintf(w, "  table %s (%s)\n", diff.Table, status)
+		for _, change := range diff.Changes {
+			symbol := "~"
+			switch change.Action {
+			case "created", "added":
+				symbol = "+"
+			case "dropped":
+				symbol = "-"
+			}
+			line := fmt.Sprintf("    %s %s %s", symbol, change.Kind, change.Action)
+			if change.Name != "" {
+				line += " `" + change.Name + "`"
+			}
+			if change.Details != "" {
+				line += " " + change.Details
+			}
+			_, _ = fmt.Fprintln(w, line)
+		}
+	}
+}
+
+func WriteSchemaSQL(w io.Writer, alters []Alter) {
+	lastPool := ""
+	for _, alter := range alters {
+		if alter.Pool != lastPool {
+			_, _ = fmt.Fprintf(w, "-- pool: %s\n\n", alter.Pool)
+			lastPool = alter.Pool
+		}
+		if !alter.Safe {
+			_, _ = fmt.Fprintln(w, "-- unsafe: data can be lost")
+		}
+		_, _ = fmt.Fprintf(w, "%s\n\n", alter.SQL)
+	}
+}
+
+func mergeSchemaChanges(changes []SchemaChange) []SchemaChange {
+	added := make(map[string]bool)
+	dropped := make(map[string]bool)
+	for _, change := range changes {
+		if change.Kind == "index" || change.Kind == "foreign key" || change.Kind == "primary key" {
+			if change.Action == "added" {
+				added[change.Kind+":"+change.Name] = true
+			} else if change.Action == "dropped" {
+				dropped[change.Kind+":"+change.Name] = true
+			}
+		}
+	}
+	merged := make([]SchemaChange, 0, len(changes))
+	for _, change := range changes {
+		key := change.Kind + ":" + change.Name
+		if change.Action == "dropped" && added[key] {
+			continue
+		}
+		if change.Action == "added" && dropped[key] {
+			change.Action = "changed"
+		}
+		merged = append(merged, change)
+	}
+	return merged
+}
+
+func getUnsafeReasons(changes []SchemaChange) []string {
+	reasons := make([]string, 0)
+	for _, change := range changes {
+		reason := ""
+		switch {
+		case change.Kind == "table" && change.Action == "dropped":
+			reason = "table with data dropped"
+		case change.Kind == "column" && change.Action == "dropped":
+			reason = fmt.Sprintf("column `%s` with data dropped", change.Name)
+		case change.Kind == "column" && (change.Action == "changed" && change.Details != "order" || strings.Contains(change.Details, "definition was")):
+			reason = fmt.Sprintf("column `%s` with data changed", change.Name)
+		}
+		if reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "table is not empty")
+	}
+	return reasons
+}
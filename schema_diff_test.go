@@ -0,0 +1,74 @@
+package orm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaDiff(t *testing.T) {
+	alters := []Alter{
+		{SQL: "ALTER TABLE `test`.`users`\n    DROP FOREIGN KEY `test:users:Group`;", Safe: true, Pool: "default",
+			table: "users", changes: []SchemaChange{{Kind: "foreign key", Action: "dropped", Name: "test:users:Group"}}},
+		{SQL: "CREATE TABLE `test`.`groups` (\n  `ID` int(10) unsigned NOT NULL AUTO_INCREMENT,\n  PRIMARY KEY (`ID`)\n) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;", Safe: true, Pool: "default",
+			table: "groups", changes: []SchemaChange{{Kind: "table", Action: "created"}}},
+		{SQL: "ALTER TABLE `test`.`users`\n    DROP COLUMN `Old`,\n    ADD COLUMN `Age` int(11) NOT NULL DEFAULT '0' AFTER `ID`,\n" +
+			"    CHANGE COLUMN `Name` `Name` varchar(100) DEFAULT NULL AFTER `Age`,/*CHANGED FROM `Name` varchar(255) DEFAULT NULL*/\n" +
+			"    CHANGE COLUMN `Group` `Group` int(10) unsigned DEFAULT NULL AFTER `Name`,/*CHANGED ORDER*/\n" +
+			"    CHANGE COLUMN `Title` `Label` varchar(255) DEFAULT NULL AFTER `Group`,/*RENAMED FROM `Title`*/\n" +
+			"    DROP INDEX `Name`,\n    ADD INDEX `Name` (`Name`,`Age`);", Safe: false, Pool: "default",
+			table: "users", changes: []SchemaChange{
+				{Kind: "column", Action: "dropped", Name: "Old"},
+				{Kind: "column", Action: "added", Name: "Age", Details: "int(11) NOT NULL DEFAULT '0' AFTER `ID`"},
+				{Kind: "column", Action: "changed", Name: "Name", Details: "definition was `Name` varchar(255) DEFAULT NULL"},
+				{Kind: "column", Action: "changed", Name: "Group", Details: "order"},
+				{Kind: "column", Action: "renamed", Name: "Label", Details: "from `Title`"},
+				{Kind: "index", Action: "dropped", Name: "Name"},
+				{Kind: "index", Action: "added", Name: "Name", Details: "(`Name`,`Age`)"}}},
+		{SQL: "ALTER TABLE `test`.`users`\n    ADD CONSTRAINT `test:users:Group` FOREIGN KEY (`Group`) REFERENCES `test`.`groups` (`ID`) ON DELETE CASCADE;", Safe: true, Pool: "default",
+			table: "users", changes: []SchemaChange{{Kind: "foreign key", Action: "added", Name: "test:users:Group"}}},
+		{SQL: "DROP TABLE IF EXISTS `logs`.`old_logs`;", Safe: false, Pool: "log",
+			table: "old_logs", changes: []SchemaChange{{Kind: "table", Action: "dropped"}}},
+		{SQL: "RENAME TABLE `logs`.`a` TO `logs`.`b`;", Safe: true, Pool: "log",
+			table: "b", changes: []SchemaChange{getRenameTableChange("a", "b")}},
+		{SQL: "ALTER TABLE `logs`.`c`\n ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;", Safe: true, Pool: "log",
+			table: "c", changes: []SchemaChange{{Kind: "charset", Action: "changed", Details: "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4"}}},
+	}
+	diffs := GetSchemaDiff(alters)
+	assert.Len(t, diffs, 5)
+	assert.Equal(t, "users", diffs[0].Table)
+	assert.False(t, diffs[0].Safe)
+	assert.Len(t, diffs[0].Alters, 3)
+	assert.Equal(t, []string{"column `Old` with data dropped", "column `Name` with data changed"}, diffs[0].Reasons)
+	assert.Equal(t, "b", diffs[3].Table)
+
+	out := &bytes.Buffer{}
+	WriteSchemaDiff(out, diffs)
+	assert.Equal(t, "pool default\n"+
+		"  table users (UNSAFE: column `Old` with data dropped, column `Name` with data changed)\n"+
+		"    - column dropped `Old`\n"+
+		"    + column added `Age` int(11) NOT NULL DEFAULT '0' AFTER `ID`\n"+
+		"    ~ column changed `Name` definition was `Name` varchar(255) DEFAULT NULL\n"+
+		"    ~ column changed `Group` order\n"+
+		"    ~ column renamed `Label` from `Title`\n"+
+		"    ~ index changed `Name` (`Name`,`Age`)\n"+
+		"    ~ foreign key changed `test:users:Group`\n"+
+		"  table groups (safe)\n"+
+		"    + table created\n"+
+		"pool log\n"+
+		"  table old_logs (UNSAFE: table with data dropped)\n"+
+		"    - table dropped\n"+
+		"  table b (safe)\n"+
+		"    ~ table renamed `b` from `a`\n"+
+		"  table c (safe)\n"+
+		"    ~ charset changed ENGINE=InnoDB DEFAULT CHARSET=utf8mb4\n", out.String())
+
+	out = &bytes.Buffer{}
+	WriteSchemaSQL(out, alters[4:6])
+	assert.Equal(t, "-- pool: log\n\n-- unsafe: data can be lost\nDROP TABLE IF EXISTS `logs`.`old_logs`;\n\nRENAME TABLE `logs`.`a` TO `logs`.`b`;\n\n", out.String())
+
+	diffs = GetSchemaDiff([]Alter{{SQL: "ALTER TABLE `test`.`a` UPDATE `X` = 1;", Safe: true, Pool: "default", table: "a"}})
+	assert.Len(t, diffs, 1)
+	assert.Equal(t, []SchemaChange{{Kind: "other", Action: "changed", Details: "ALTER TABLE `test`.`a` UPDATE `X` = 1;"}}, diffs[0].Changes)
+}
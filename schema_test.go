@@ -1,6 +1,8 @@
 package orm
 
 import (
+	"encoding/binary"
+	"math"
 	"testing"
 	"time"
 
@@ -36,6 +38,27 @@ type schemaToDropEntity struct {
 	ID  uint
 }
 
+type schemaRenamedEntity struct {
+	ORM   `orm:"table=schemaRenamedEntityNew;renamedFrom=schemaRenamedEntityOld"`
+	ID    uint
+	Title string `orm:"renamedFrom=Name;index=TitleIndex"`
+	Age   uint8
+}
+
+type schemaRenamedLogEntity struct {
+	ORM    `orm:"log;table=schemaRenamedLogNew;renamedFrom=schemaRenamedLogOld"`
+	ID     uint
+	Parent *schemaRenamedEntity
+}
+
+type schemaIndexTypesEntity struct {
+	ORM
+	ID       uint
+	Title    string `orm:"fulltext=Search;index=TitlePrefix:1:20"`
+	Content  string `orm:"fulltext=Search:2"`
+	Location []byte `orm:"geometry=point;spatial=Location"`
+}
+
 type testEnum struct {
 	EnumModel
 	A string
@@ -270,6 +293,39 @@ func TestSchema(t *testing.T) {
 	_, err = registry.Validate()
 	assert.EqualError(t, err, "redis pool 'invalid' not found")
 
+	registry = &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterRedis("localhost:6381", 15)
+	type invalidSchemaTTL struct {
+		ORM `orm:"redisCache=default,ttl=abc"`
+		ID  uint
+	}
+	registry.RegisterEntity(&invalidSchemaTTL{})
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "redisCache in orm.invalidSchemaTTL: ttl 'abc' not valid")
+
+	registry = &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterLocalCache(100)
+	type invalidSchemaTTLOption struct {
+		ORM `orm:"localCache=default,size=10"`
+		ID  uint
+	}
+	registry.RegisterEntity(&invalidSchemaTTLOption{})
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "localCache in orm.invalidSchemaTTLOption: option 'size' not supported")
+
+	registry = &Registry{}
+	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test")
+	registry.RegisterLocalCache(100)
+	type invalidSchemaNilTTL struct {
+		ORM `orm:"localCache;nilTTL=-1"`
+		ID  uint
+	}
+	registry.RegisterEntity(&invalidSchemaNilTTL{})
+	_, err = registry.Validate()
+	assert.EqualError(t, err, "nilTTL '-1' in orm.invalidSchemaNilTTL not valid")
+
 	registry = &Registry{}
 	registry.RegisterMySQLPool("root:root@tcp(localhost:3311)/test", "other")
 	type invalidSchema4 struct {
@@ -341,3 +397,135 @@ func TestSchema(t *testing.T) {
 	_, err = registry.Validate()
 	assert.EqualError(t, err, "missing index for cached query 'IndexName' in orm.invalidSchema9")
 }
+
+func TestSchemaRename(t *testing.T) {
+	entity := &schemaRenamedEntity{}
+	engine := PrepareTables(t, &Registry{}, entity)
+	engine.TrackAndFlush(&schemaRenamedEntity{Title: "a", Age: 10})
+
+	engine.GetMysql().Exec("RENAME TABLE `schemaRenamedEntityNew` TO `schemaRenamedEntityOld`")
+	alters := engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.True(t, alters[0].Safe)
+	assert.Equal(t, "RENAME TABLE `test`.`schemaRenamedEntityOld` TO `test`.`schemaRenamedEntityNew`;", alters[0].SQL)
+	engine.GetMysql().Exec(alters[0].SQL)
+	assert.Len(t, engine.GetAlters(), 0)
+
+	engine.GetMysql().Exec("ALTER TABLE `schemaRenamedEntityNew` CHANGE COLUMN `Title` `Name` varchar(255) DEFAULT NULL")
+	alters = engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.True(t, alters[0].Safe)
+	assert.Equal(t, "ALTER TABLE `test`.`schemaRenamedEntityNew`\n    CHANGE COLUMN `Name` `Title` varchar(255) DEFAULT NULL AFTER `ID`;/*RENAMED FROM `Name`*/", alters[0].SQL)
+	engine.GetMysql().Exec(alters[0].SQL)
+	assert.Len(t, engine.GetAlters(), 0)
+
+	engine.GetMysql().Exec("ALTER TABLE `schemaRenamedEntityNew` CHANGE COLUMN `Title` `Name` varchar(100) DEFAULT NULL")
+	alters = engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.False(t, alters[0].Safe)
+	assert.Equal(t, "ALTER TABLE `test`.`schemaRenamedEntityNew`\n    CHANGE COLUMN `Name` `Title` varchar(255) DEFAULT NULL AFTER `ID`;/*RENAMED AND CHANGED FROM `Name` varchar(100) DEFAULT NULL*/", alters[0].SQL)
+	engine.GetMysql().Exec(alters[0].SQL)
+
+	entity = &schemaRenamedEntity{}
+	assert.True(t, engine.LoadByID(1, entity))
+	assert.Equal(t, "a", entity.Title)
+	assert.Equal(t, uint8(10), entity.Age)
+}
+
+func TestSchemaRenameWithLogAndForeignKeys(t *testing.T) {
+	var entity *schemaRenamedEntity
+	var logEntity *schemaRenamedLogEntity
+	engine := PrepareTables(t, &Registry{}, entity, logEntity)
+	assert.Len(t, engine.GetAlters(), 0)
+
+	pool := engine.GetMysql()
+	pool.Exec("ALTER TABLE `schemaRenamedLogNew` DROP FOREIGN KEY `test:schemaRenamedLogNew:Parent`")
+	pool.Exec("RENAME TABLE `schemaRenamedLogNew` TO `schemaRenamedLogOld`")
+	pool.Exec("ALTER TABLE `schemaRenamedLogOld` ADD CONSTRAINT `test:schemaRenamedLogOld:Parent` FOREIGN KEY (`Parent`) " +
+		"REFERENCES `test`.`schemaRenamedEntityNew` (`ID`) ON DELETE RESTRICT")
+	pool.Exec("RENAME TABLE `_log_default_schemaRenamedLogNew` TO `_log_default_schemaRenamedLogOld`")
+	alters := engine.GetAlters()
+	assert.Len(t, alters, 4)
+	assert.Equal(t, "ALTER TABLE `test`.`schemaRenamedLogOld`\nDROP FOREIGN KEY `test:schemaRenamedLogOld:Parent`;", alters[0].SQL)
+	assert.Equal(t, "RENAME TABLE `test`.`schemaRenamedLogOld` TO `test`.`schemaRenamedLogNew`;", alters[1].SQL)
+	assert.Equal(t, "RENAME TABLE `test`.`_log_default_schemaRenamedLogOld` TO `test`.`_log_default_schemaRenamedLogNew`;", alters[2].SQL)
+	assert.Equal(t, "ALTER TABLE `test`.`schemaRenamedLogNew`\n  ADD CONSTRAINT `test:schemaRenamedLogNew:Parent` FOREIGN KEY (`Parent`) "+
+		"REFERENCES `test`.`schemaRenamedEntityNew` (`ID`) ON DELETE RESTRICT;", alters[3].SQL)
+	for _, alter := range alters {
+		pool.Exec(alter.SQL)
+	}
+	assert.Len(t, engine.GetAlters(), 0)
+
+	pool.Exec("CREATE TABLE `schemaRenamedLogOld` LIKE `schemaRenamedLogNew`")
+	alters = engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.True(t, alters[0].Safe)
+	assert.Equal(t, "DROP TABLE IF EXISTS `test`.`schemaRenamedLogOld`;", alters[0].SQL)
+}
+
+func TestBuildCreateIndexSQL(t *testing.T) {
+	definition := &index{Columns: map[int]string{1: "Title", 2: "Created"}, SubParts: map[int]int{1: 20},
+		Descending: map[int]bool{2: true}, Invisible: true}
+	assert.Equal(t, "ADD INDEX `Test` (`Title`(20),`Created` DESC) INVISIBLE", buildCreateIndexSQL("Test", definition))
+	definition = &index{Type: "FULLTEXT", Columns: map[int]string{1: "Title", 2: "Content"}}
+	assert.Equal(t, "ADD FULLTEXT INDEX `Search` (`Title`,`Content`)", buildCreateIndexSQL("Search", definition))
+	definition = &index{Unique: true, Columns: map[int]string{1: "Name"}}
+	assert.Equal(t, "ADD UNIQUE INDEX `Name` (`Name`)", buildCreateIndexSQL("Name", definition))
+
+	assert.True(t, supportsIndexOptions(&DB{version: "8.0.21"}))
+	assert.False(t, supportsIndexOptions(&DB{version: "5.7.25-28"}))
+	assert.False(t, supportsIndexOptions(&DB{version: "10.5.8-MariaDB"}))
+	assert.False(t, supportsIndexOptions(&DB{version: "5.5.5-10.8.3-MariaDB"}))
+}
+
+type schemaIndexOptionsEntity struct {
+	ORM
+	ID      uint
+	Created time.Time `orm:"time;index=Created:1:desc"`
+	Age     uint      `orm:"index=Age:1:invisible"`
+}
+
+func TestSchemaIndexOptions(t *testing.T) {
+	var entity *schemaIndexOptionsEntity
+	engine := PrepareTables(t, &Registry{}, entity)
+	assert.Len(t, engine.GetAlters(), 0)
+	var collation string
+	engine.GetMysql().QueryRow(NewWhere("SELECT `COLLATION` FROM INFORMATION_SCHEMA.STATISTICS WHERE TABLE_SCHEMA = 'test' "+
+		"AND TABLE_NAME = 'schemaIndexOptionsEntity' AND INDEX_NAME = 'Created'"), &collation)
+	if supportsIndexOptions(engine.GetMysql()) {
+		assert.Equal(t, "D", collation)
+	} else {
+		assert.Equal(t, "A", collation)
+	}
+}
+
+func TestSchemaIndexTypes(t *testing.T) {
+	entity := &schemaIndexTypesEntity{}
+	engine := PrepareTables(t, &Registry{}, entity)
+	assert.Len(t, engine.GetAlters(), 0)
+
+	point := make([]byte, 25)
+	point[4] = 1
+	binary.LittleEndian.PutUint32(point[5:], 1)
+	binary.LittleEndian.PutUint64(point[9:], math.Float64bits(21.01))
+	binary.LittleEndian.PutUint64(point[17:], math.Float64bits(52.23))
+	engine.TrackAndFlush(&schemaIndexTypesEntity{Title: "Go orm", Content: "mysql and redis", Location: point},
+		&schemaIndexTypesEntity{Title: "Other", Content: "elastic search", Location: point})
+
+	var found []*schemaIndexTypesEntity
+	engine.Search(NewMatchAgainst([]string{"Title", "Content"}, "+redis", MatchBooleanMode), nil, &found)
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Go orm", found[0].Title)
+	assert.Equal(t, point, found[0].Location)
+
+	engine.GetMysql().Exec("ALTER TABLE `schemaIndexTypesEntity` DROP INDEX `TitlePrefix`, ADD INDEX `TitlePrefix` (`Title`(10))")
+	alters := engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.Equal(t, "ALTER TABLE `test`.`schemaIndexTypesEntity`\n    DROP INDEX `TitlePrefix`,\n    ADD INDEX `TitlePrefix` (`Title`(20));", alters[0].SQL)
+	engine.GetMysql().Exec(alters[0].SQL)
+
+	engine.GetMysql().Exec("ALTER TABLE `schemaIndexTypesEntity` DROP INDEX `Search`, ADD INDEX `Search` (`Title`,`Content`)")
+	alters = engine.GetAlters()
+	assert.Len(t, alters, 1)
+	assert.Equal(t, "ALTER TABLE `test`.`schemaIndexTypesEntity`\n    DROP INDEX `Search`,\n    ADD FULLTEXT INDEX `Search` (`Title`,`Content`);", alters[0].SQL)
+}
@@ -16,6 +16,10 @@ func searchIDsWithCount(skipFakeDelete bool, engine *Engine, where *Where, pager
 }
 
 func searchRow(skipFakeDelete bool, engine *Engine, where *Where, entity Entity, references []string) bool {
+	return searchRowWithLock(skipFakeDelete, engine, where, entity, references, "")
+}
+
+func searchRowWithLock(skipFakeDelete bool, engine *Engine, where *Where, entity Entity, references []string, lock string) bool {
 	orm := initIfNeeded(engine, entity)
 	schema := orm.tableSchema
 	whereQuery := where.String()
@@ -23,7 +27,7 @@ func searchRow(skipFakeDelete bool, engine *Engine, where *Where, entity Entity,
 		whereQuery = fmt.Sprintf("`FakeDelete` = 0 AND %s", whereQuery)
 	}
 	/* #nosec */
-	query := fmt.Sprintf("SELECT %s FROM `%s` WHERE %s LIMIT 1", schema.fieldsQuery, schema.tableName, whereQuery)
+	query := fmt.Sprintf("SELECT %s FROM `%s` WHERE %s LIMIT 1%s", schema.fieldsQuery, schema.tableName, whereQuery, lock)
 
 	pool := schema.GetMysql(engine)
 	results, def := pool.Query(query, where.GetParameters()...)
@@ -40,10 +44,7 @@ func searchRow(skipFakeDelete bool, engine *Engine, where *Where, entity Entity,
 	}
 	results.Scan(valuePointers...)
 	def()
-	id := uint64(0)
-	if values[0].Valid {
-		id, _ = strconv.ParseUint(values[0].String, 10, 64)
-	}
+	id := parsePrimaryKey(schema, values[0].String)
 
 	finalValues := make([]string, count)
 	for i, v := range values {
@@ -103,8 +104,7 @@ func search(skipFakeDelete bool, engine *Engine, where *Where, pager *Pager, wit
 			}
 		}
 		value := reflect.New(entityType)
-		id, _ := strconv.ParseUint(finalValues[0], 10, 64)
-		fillFromDBRow(id, engine, finalValues[1:], value.Interface().(Entity))
+		fillFromDBRow(parsePrimaryKey(schema, finalValues[0]), engine, finalValues[1:], value.Interface().(Entity))
 		val = reflect.Append(val, value)
 		i++
 	}
@@ -126,6 +126,7 @@ func searchIDs(skipFakeDelete bool, engine *Engine, where *Where, pager *Pager,
 		pager = NewPager(1, 50000)
 	}
 	schema := getTableSchema(engine.registry, entityType)
+	schema.checkIntegerPrimaryKey()
 	whereQuery := where.String()
 	if skipFakeDelete && schema.hasFakeDelete {
 		/* #nosec */
@@ -166,11 +167,11 @@ func getTotalRows(engine *Engine, withCount bool, pager *Pager, where *Where, sc
 	return totalRows
 }
 
-func fillFromDBRow(id uint64, engine *Engine, data []string, entity Entity) {
+func fillFromDBRow(id interface{}, engine *Engine, data []string, entity Entity) {
 	orm := initIfNeeded(engine, entity)
 	elem := orm.attributes.elem
-	orm.attributes.idElem.SetUint(id)
-	_ = fillStruct(engine, 0, data, orm.tableSchema.fields, elem)
+	setPrimaryKey(orm, id)
+	_ = fillStruct(engine, 0, data, orm.tableSchema.fields, elem, orm.tableSchema.location)
 	orm.dBData["ID"] = id
 	orm.attributes.loaded = true
 	for key, column := range orm.tableSchema.columnNames[1:] {
@@ -188,7 +189,19 @@ func convertStringToInt(value string) int64 {
 	return v
 }
 
-func fillStruct(engine *Engine, index uint16, data []string, fields *tableFields, value reflect.Value) uint16 {
+func parseTime(value string, location *time.Location) time.Time {
+	if len(value) < 19 {
+		parsed, _ := time.ParseInLocation("2006-01-02", value, time.Local)
+		return parsed
+	}
+	parsed, _ := time.ParseInLocation("2006-01-02 15:04:05", value, location)
+	if parsed.Year() == 1 || location == time.Local {
+		return parsed
+	}
+	return parsed.In(time.Local)
+}
+
+func fillStruct(engine *Engine, index uint16, data []string, fields *tableFields, value reflect.Value, location *time.Location) uint16 {
 	skip := 1
 	if fields.prefix != "" {
 		skip = -1
@@ -341,23 +354,14 @@ func fillStruct(engine *Engine, index uint16, data []string, fields *tableFields
 		if data[index] == "nil" {
 			field.Set(reflect.Zero(field.Type()))
 		} else {
-			layout := "2006-01-02"
-			if len(data[index]) == 19 {
-				layout += " 15:04:05"
-			}
-			value, _ := time.ParseInLocation(layout, data[index], time.Local)
+			value := parseTime(data[index], location)
 			field.Set(reflect.ValueOf(&value))
 		}
 		index++
 	}
 	for _, i := range fields.times {
 		field := value.Field(i)
-		layout := "2006-01-02"
-		if len(data[index]) == 19 {
-			layout += " 15:04:05"
-		}
-		val, _ := time.ParseInLocation(layout, data[index], time.Local)
-		field.Set(reflect.ValueOf(val))
+		field.Set(reflect.ValueOf(parseTime(data[index], location)))
 		index++
 	}
 	for _, i := range fields.jsons {
@@ -371,6 +375,10 @@ func fillStruct(engine *Engine, index uint16, data []string, fields *tableFields
 		}
 		index++
 	}
+	for k, i := range fields.customs {
+		fields.customsHandlers[k].Deserialize(data[index], data[index] == "nil", value.Field(i))
+		index++
+	}
 	for k, i := range fields.refs {
 		field := value.Field(i)
 		integer := uint64(0)
@@ -418,7 +426,7 @@ func fillStruct(engine *Engine, index uint16, data []string, fields *tableFields
 		field := value.Field(i)
 		newVal := reflect.New(field.Type())
 		value := newVal.Elem()
-		newIndex := fillStruct(engine, index, data, subFields, value)
+		newIndex := fillStruct(engine, index, data, subFields, value, location)
 		field.Set(value)
 		index = newIndex
 	}
@@ -0,0 +1,49 @@
+package orm
+
+import "sync"
+
+type singleFlightCall struct {
+	wg         sync.WaitGroup
+	value      interface{}
+	panicValue interface{}
+}
+
+type singleFlight struct {
+	m     sync.Mutex
+	calls map[string]*singleFlightCall
+}
+
+func (g *singleFlight) Do(key string, provider func() interface{}) (value interface{}, shared bool) {
+	g.m.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleFlightCall)
+	}
+	call, has := g.calls[key]
+	if has {
+		g.m.Unlock()
+		call.wg.Wait()
+		if call.panicValue != nil {
+			panic(call.panicValue)
+		}
+		return call.value, true
+	}
+	call = &singleFlightCall{}
+	call.wg.Add(1)
+	g.calls[key] = call
+	g.m.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			call.panicValue = r
+		}
+		g.m.Lock()
+		delete(g.calls, key)
+		g.m.Unlock()
+		call.wg.Done()
+		if call.panicValue != nil {
+			panic(call.panicValue)
+		}
+	}()
+	call.value = provider()
+	return call.value, false
+}
@@ -0,0 +1,58 @@
+package orm
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleFlight(t *testing.T) {
+	group := &singleFlight{}
+	calls := int32(0)
+	start := make(chan struct{})
+	wg := sync.WaitGroup{}
+	results := make([]interface{}, 10)
+	sharedCount := int32(0)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			value, shared := group.Do("test", func() interface{} {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(time.Millisecond * 50)
+				return "hello"
+			})
+			if shared {
+				atomic.AddInt32(&sharedCount, 1)
+			}
+			results[i] = value
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, int32(9), sharedCount)
+	for _, value := range results {
+		assert.Equal(t, "hello", value)
+	}
+
+	value, shared := group.Do("test", func() interface{} {
+		return "hello2"
+	})
+	assert.False(t, shared)
+	assert.Equal(t, "hello2", value)
+
+	assert.PanicsWithValue(t, "test panic", func() {
+		group.Do("test", func() interface{} {
+			panic("test panic")
+		})
+	})
+	value, _ = group.Do("test", func() interface{} {
+		return "hello3"
+	})
+	assert.Equal(t, "hello3", value)
+}
@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 
@@ -71,6 +72,7 @@ type TableSchema interface {
 	DropTable(engine *Engine)
 	TruncateTable(engine *Engine)
 	UpdateSchema(engine *Engine)
+	UpdateSchemaOnline(engine *Engine, options *OnlineSchemaChangeOptions)
 	UpdateSchemaAndTruncateTable(engine *Engine)
 	GetMysql(engine *Engine) *DB
 	GetLocalCache(engine *Engine) (cache *LocalCache, has bool)
@@ -83,6 +85,7 @@ type TableSchema interface {
 
 type tableSchema struct {
 	tableName           string
+	renamedFrom         string
 	mysqlPoolName       string
 	t                   reflect.Type
 	fields              *tableFields
@@ -92,6 +95,15 @@ type tableSchema struct {
 	cachedIndexesOne    map[string]*cachedQueryDefinition
 	cachedIndexesAll    map[string]*cachedQueryDefinition
 	columnNames         []string
+	primaryKeyType      string
+	primaryKey          []string
+	columnTypes         map[string]ColumnTypeHandler
+	location            *time.Location
+	hasLocation         bool
+	onUpdateTimestamps  []string
+	hasGenerated        bool
+	checks              []string
+	partitioning        *partitioning
 	uniqueIndices       map[string][]string
 	uniqueIndicesGlobal map[string][]string
 	refOne              []string
@@ -99,6 +111,11 @@ type tableSchema struct {
 	columnsStamp        string
 	localCacheName      string
 	redisCacheName      string
+	localCacheTTL       int
+	localCacheNilTTL    int
+	redisCacheTTL       int
+	redisCacheNilTTL    int
+	recomputeLocker     string
 	cachePrefix         string
 	hasFakeDelete       bool
 	hasLog              bool
@@ -126,6 +143,8 @@ type tableFields struct {
 	timesNullable     []int
 	times             []int
 	jsons             []int
+	customs           []int
+	customsHandlers   []ColumnTypeHandler
 	structs           map[int]*tableFields
 	refs              []int
 	refsTypes         []reflect.Type
@@ -191,6 +210,39 @@ func (tableSchema *tableSchema) GetRedisCache(engine *Engine) (cache *RedisCache
 	return engine.GetRedis(tableSchema.redisCacheName), true
 }
 
+func (tableSchema *tableSchema) getRecomputeLocker(engine *Engine) (locker *Locker, has bool) {
+	if tableSchema.recomputeLocker == "" {
+		return nil, false
+	}
+	return engine.GetLocker(tableSchema.recomputeLocker), true
+}
+
+func (tableSchema *tableSchema) getLocalCacheValue(value interface{}, isNil bool) interface{} {
+	ttl := tableSchema.localCacheTTL
+	if isNil {
+		ttl = tableSchema.localCacheNilTTL
+	}
+	if ttl == 0 {
+		return value
+	}
+	return localCacheExpiringValue{value: value, expire: time.Now().Unix() + int64(ttl)}
+}
+
+func (tableSchema *tableSchema) getRedisCacheTTL(isNil bool) int {
+	if isNil {
+		return tableSchema.redisCacheNilTTL
+	}
+	return tableSchema.redisCacheTTL
+}
+
+func (tableSchema *tableSchema) getRedisCacheStaleTTL(isNil bool) int {
+	ttl := tableSchema.getRedisCacheTTL(isNil)
+	if ttl == 0 {
+		return 0
+	}
+	return ttl + recomputeStaleTTL
+}
+
 func (tableSchema *tableSchema) GetReferences() []string {
 	return tableSchema.refOne
 }
@@ -241,12 +293,15 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 	}
 	localCache := ""
 	redisCache := ""
+	localCacheTTL := 0
+	redisCacheTTL := 0
+	var err error
 	userValue, has := tags["ORM"]["localCache"]
 	if has {
-		if userValue == "true" {
-			userValue = "default"
+		localCache, localCacheTTL, err = parseCacheTag(userValue)
+		if err != nil {
+			return nil, errors.Annotatef(err, "localCache in %s", entityType.String())
 		}
-		localCache = userValue
 	}
 	if localCache != "" {
 		_, has = registry.localCacheContainers[localCache]
@@ -256,10 +311,10 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 	}
 	userValue, has = tags["ORM"]["redisCache"]
 	if has {
-		if userValue == "true" {
-			userValue = "default"
+		redisCache, redisCacheTTL, err = parseCacheTag(userValue)
+		if err != nil {
+			return nil, errors.Annotatef(err, "redisCache in %s", entityType.String())
 		}
-		redisCache = userValue
 	}
 	if redisCache != "" {
 		_, has = registry.redisServers[redisCache]
@@ -267,6 +322,29 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 			return nil, errors.NotFoundf("redis pool '%s'", redisCache)
 		}
 	}
+	recomputeLocker, has := tags["ORM"]["recomputeLock"]
+	if has {
+		if recomputeLocker == "true" {
+			recomputeLocker = "default"
+		}
+		if redisCache == "" {
+			return nil, errors.NotSupportedf("recomputeLock without redisCache in %s", entityType.String())
+		}
+		_, has = registry.locks[recomputeLocker]
+		if !has {
+			return nil, errors.NotFoundf("locker pool '%s'", recomputeLocker)
+		}
+	}
+	nilTTL := 60
+	localCacheNilTTL := localCacheTTL
+	userValue, has = tags["ORM"]["nilTTL"]
+	if has {
+		nilTTL, err = strconv.Atoi(userValue)
+		if err != nil || nilTTL < 0 {
+			return nil, errors.NotValidf("nilTTL '%s' in %s", userValue, entityType.String())
+		}
+		localCacheNilTTL = nilTTL
+	}
 
 	cachePrefix := ""
 	if mysql != "default" {
@@ -444,8 +522,105 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 			}
 		}
 	}
-	fields := buildTableFields(entityType, 1, "", tags)
+	primaryKeyType, err := getPrimaryKeyType(entityType, tags["ID"])
+	if err != nil {
+		return nil, err
+	}
+	if primaryKeyType != "" {
+		switch {
+		case logPoolName != "":
+			return nil, errors.NotSupportedf("log in %s with %s primary key", entityType.String(), primaryKeyType)
+		case hasFakeDelete:
+			return nil, errors.NotSupportedf("fake delete in %s with %s primary key", entityType.String(), primaryKeyType)
+		case len(cachedQueriesAll) > 0:
+			return nil, errors.NotSupportedf("cached queries in %s with %s primary key", entityType.String(), primaryKeyType)
+		}
+		for _, values := range tags {
+			_, has := values["dirty"]
+			if has {
+				return nil, errors.NotSupportedf("dirty queues in %s with %s primary key", entityType.String(), primaryKeyType)
+			}
+		}
+	}
+	for _, values := range tags {
+		refName, has := values["ref"]
+		if !has {
+			refName, has = values["refs"]
+		}
+		if has && !hasIntegerPrimaryKey(registry.entities[refName]) {
+			return nil, errors.NotSupportedf("reference to %s without integer primary key in %s", refName, entityType.String())
+		}
+		if has && isPartitioned(registry, registry.entities[refName]) {
+			return nil, errors.NotSupportedf("reference to partitioned %s in %s", refName, entityType.String())
+		}
+	}
+	location := time.Local
+	userLocation, hasLocation := registry.sqlLocations[mysql]
+	if hasLocation {
+		location = userLocation
+	}
+	for name, values := range tags {
+		precision, has := values["precision"]
+		if has {
+			asInt, err := strconv.Atoi(precision)
+			if err != nil || asInt < 1 || asInt > 6 {
+				return nil, errors.NotValidf("precision '%s' in field %s of %s", precision, name, entityType.String())
+			}
+		}
+	}
+	onUpdateTimestamps := make([]string, 0)
+	for i := 1; i < entityType.NumField(); i++ {
+		field := entityType.Field(i)
+		_, has := tags[field.Name]["onUpdateCurrentTimestamp"]
+		if !has {
+			continue
+		}
+		if field.Type.String() != "time.Time" && field.Type.String() != "*time.Time" {
+			return nil, errors.NotValidf("onUpdateCurrentTimestamp in field %s of %s", field.Name, entityType.String())
+		}
+		onUpdateTimestamps = append(onUpdateTimestamps, field.Name)
+	}
+	hasGenerated := false
+	for name, values := range tags {
+		_, has := values["generated"]
+		if !has {
+			continue
+		}
+		if name == "ID" || values["generated"] == "" || values["generated"] == "true" {
+			return nil, errors.NotValidf("generated column %s in %s", name, entityType.String())
+		}
+		hasGenerated = true
+	}
+	checks := make([]string, 0)
+	for _, check := range strings.Split(tags["ORM"]["check"], ";") {
+		check = strings.TrimSpace(check)
+		if check != "" && check != "true" {
+			checks = append(checks, check)
+		}
+	}
+	fields := buildTableFields(registry, entityType, 1, "", tags)
 	columns := fields.getColumnNames()
+	primaryKey := []string{"ID"}
+	userValue, has = tags["ORM"]["primaryKey"]
+	if has {
+		primaryKey = strings.Split(userValue, ",")
+		for _, column := range primaryKey {
+			valid := false
+			for _, name := range columns {
+				if name == column {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, errors.NotFoundf("primary key column '%s' in %s", column, entityType.String())
+			}
+		}
+	}
+	partitioning, err := parsePartitioning(entityType, tags, primaryKey)
+	if err != nil {
+		return nil, err
+	}
 	fieldsQuery := ""
 	for _, column := range columns {
 		fieldsQuery += ",`" + column + "`"
@@ -453,18 +628,33 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 	columnsStamp := fmt.Sprintf("%d", fnv1a.HashString32(fieldsQuery))
 
 	tableSchema := &tableSchema{tableName: table,
+		renamedFrom:         tags["ORM"]["renamedFrom"],
 		mysqlPoolName:       mysql,
 		t:                   entityType,
 		fields:              fields,
 		fieldsQuery:         fieldsQuery[1:],
 		tags:                tags,
 		columnNames:         columns,
+		columnTypes:         fields.getColumnTypes(make(map[string]ColumnTypeHandler)),
+		location:            location,
+		hasLocation:         hasLocation,
+		onUpdateTimestamps:  onUpdateTimestamps,
+		hasGenerated:        hasGenerated,
+		checks:              checks,
+		partitioning:        partitioning,
+		primaryKeyType:      primaryKeyType,
+		primaryKey:          primaryKey,
 		columnsStamp:        columnsStamp,
 		cachedIndexes:       cachedQueries,
 		cachedIndexesOne:    cachedQueriesOne,
 		cachedIndexesAll:    cachedQueriesAll,
 		localCacheName:      localCache,
 		redisCacheName:      redisCache,
+		localCacheTTL:       localCacheTTL,
+		localCacheNilTTL:    localCacheNilTTL,
+		redisCacheTTL:       redisCacheTTL,
+		redisCacheNilTTL:    nilTTL,
+		recomputeLocker:     recomputeLocker,
 		refOne:              oneRefs,
 		refMany:             manyRefs,
 		cachePrefix:         cachePrefix,
@@ -569,7 +759,7 @@ func initTableSchema(registry *Registry, entityType reflect.Type) (*tableSchema,
 	return tableSchema, nil
 }
 
-func buildTableFields(t reflect.Type, start int, prefix string, schemaTags map[string]map[string]string) *tableFields {
+func buildTableFields(registry *Registry, t reflect.Type, start int, prefix string, schemaTags map[string]map[string]string) *tableFields {
 	fields := &tableFields{t: t, prefix: prefix, uintegers: make([]int, 0), uintegersNullable: make([]int, 0),
 		integers: make([]int, 0), integersNullable: make([]int, 0), strings: make([]int, 0), fields: make(map[int]reflect.StructField),
 		sliceStrings: make([]int, 0), bytes: make([]int, 0), booleans: make([]int, 0), booleansNullable: make([]int, 0), floats: make([]int, 0),
@@ -584,6 +774,16 @@ func buildTableFields(t reflect.Type, start int, prefix string, schemaTags map[s
 		if has {
 			continue
 		}
+		if prefix == "" && i == 1 {
+			fields.uintegers = append(fields.uintegers, i)
+			continue
+		}
+		handler, has := getColumnTypeHandler(registry, f.Type, tags)
+		if has {
+			fields.customs = append(fields.customs, i)
+			fields.customsHandlers = append(fields.customsHandlers, handler)
+			continue
+		}
 		switch typeName {
 		case "uint",
 			"uint8",
@@ -636,7 +836,7 @@ func buildTableFields(t reflect.Type, start int, prefix string, schemaTags map[s
 		default:
 			k := f.Type.Kind().String()
 			if k == "struct" {
-				fields.structs[i] = buildTableFields(f.Type, 0, f.Name, schemaTags)
+				fields.structs[i] = buildTableFields(registry, f.Type, 0, f.Name, schemaTags)
 			} else if k == "ptr" {
 				modelType := reflect.TypeOf((*Entity)(nil)).Elem()
 				if f.Type.Implements(modelType) {
@@ -726,9 +926,11 @@ func extractTag(registry *Registry, field reflect.StructField) map[string]map[st
 		length := len(args)
 		var attributes = make(map[string]string, length)
 		for j := 0; j < length; j++ {
-			arg := strings.Split(args[j], "=")
+			arg := strings.SplitN(args[j], "=", 2)
 			if len(arg) == 1 {
 				attributes[arg[0]] = "true"
+			} else if arg[0] == "check" && attributes["check"] != "" {
+				attributes["check"] += ";" + arg[1]
 			} else {
 				attributes[arg[0]] = arg[1]
 			}
@@ -743,8 +945,29 @@ func extractTag(registry *Registry, field reflect.StructField) map[string]map[st
 	return make(map[string]map[string]string)
 }
 
-func (tableSchema *tableSchema) getCacheKey(id uint64) string {
-	return tableSchema.cachePrefix + ":" + tableSchema.columnsStamp + ":" + strconv.FormatUint(id, 10)
+func parseCacheTag(value string) (pool string, ttl int, err error) {
+	pool = "default"
+	for i, part := range strings.Split(value, ",") {
+		option := strings.SplitN(part, "=", 2)
+		if len(option) == 1 {
+			if i == 0 && part != "true" && part != "" {
+				pool = part
+			}
+			continue
+		}
+		if option[0] != "ttl" {
+			return "", 0, errors.NotSupportedf("option '%s'", option[0])
+		}
+		ttl, err = strconv.Atoi(option[1])
+		if err != nil || ttl < 0 {
+			return "", 0, errors.NotValidf("ttl '%s'", option[1])
+		}
+	}
+	return pool, ttl, nil
+}
+
+func (tableSchema *tableSchema) getCacheKey(id interface{}) string {
+	return tableSchema.cachePrefix + ":" + tableSchema.columnsStamp + ":" + formatPrimaryKey(tableSchema, id)
 }
 
 func (fields *tableFields) getColumnNames() []string {
@@ -766,6 +989,7 @@ func (fields *tableFields) getColumnNames() []string {
 	ids = append(ids, fields.timesNullable...)
 	ids = append(ids, fields.times...)
 	ids = append(ids, fields.jsons...)
+	ids = append(ids, fields.customs...)
 	ids = append(ids, fields.refs...)
 	ids = append(ids, fields.refsMany...)
 	for _, i := range ids {
@@ -0,0 +1,128 @@
+package orm
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timePrecisionEntity struct {
+	ORM       `orm:"localCache"`
+	ID        uint
+	Name      string
+	Date      time.Time
+	Created   time.Time  `orm:"precision=3;currentTimestamp"`
+	Updated   *time.Time `orm:"timestamp;precision=6;onUpdateCurrentTimestamp"`
+	Published *time.Time `orm:"time"`
+}
+
+func TestTimeColumnDefinition(t *testing.T) {
+	definition, notNull, _, defaultValue := handleTime(map[string]string{}, false)
+	assert.Equal(t, "date", definition)
+	assert.True(t, notNull)
+	assert.Equal(t, "'0001-01-01'", defaultValue)
+
+	definition, _, _, defaultValue = handleTime(map[string]string{"time": "true", "precision": "3", "currentTimestamp": "true"}, false)
+	assert.Equal(t, "datetime(3)", definition)
+	assert.Equal(t, "CURRENT_TIMESTAMP(3)", defaultValue)
+
+	definition, notNull, _, defaultValue = handleTime(map[string]string{"timestamp": "true", "onUpdateCurrentTimestamp": "true"}, true)
+	assert.Equal(t, "timestamp NULL", definition)
+	assert.False(t, notNull)
+	assert.Equal(t, "NULL ON UPDATE CURRENT_TIMESTAMP", defaultValue)
+
+	_, _, _, defaultValue = handleTime(map[string]string{"timestamp": "true", "precision": "6", "onUpdateCurrentTimestamp": "true"}, false)
+	assert.Equal(t, "CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)", defaultValue)
+}
+
+func TestTimeColumnTimezone(t *testing.T) {
+	schema := &tableSchema{location: time.Local}
+	value := time.Date(2020, 5, 10, 12, 30, 15, 123456789, time.FixedZone("test", 2*3600))
+	assert.Equal(t, "2020-05-10 12:30:15", formatTime(schema, "Created", map[string]string{"time": "true"}, value))
+
+	schema = &tableSchema{location: time.UTC, hasLocation: true}
+	assert.Equal(t, "2020-05-10 10:30:15.123", formatTime(schema, "Created", map[string]string{"precision": "3"}, value))
+	assert.Equal(t, "2020-05-10 10:30:15", formatTime(schema, "Created", map[string]string{"time": "true"}, value))
+	assert.Equal(t, "2020-05-10", formatTime(schema, "Created", map[string]string{}, value))
+	assert.Equal(t, "0001-01-01 00:00:00.000000", formatTime(schema, "Created", map[string]string{"precision": "6"}, time.Time{}))
+
+	parsed := parseTime("2020-05-10 10:30:15.123", time.UTC)
+	assert.True(t, parsed.Equal(time.Date(2020, 5, 10, 10, 30, 15, 123000000, time.UTC)))
+	assert.Equal(t, time.Local, parsed.Location())
+	assert.Equal(t, 1, parseTime("0001-01-01 00:00:00", time.UTC).Year())
+
+	assert.Equal(t, 0, getCurrentTime(map[string]string{"precision": "3"}).Nanosecond()%int(time.Millisecond))
+
+	dataSourceName, err := getTimezoneDataSourceName("root:root@tcp(localhost:3311)/test?limit_connections=10", time.UTC)
+	assert.NoError(t, err)
+	assert.Equal(t, "root:root@tcp(localhost:3311)/test?limit_connections=10&time_zone=%27%2B00%3A00%27", dataSourceName)
+	location, _ := time.LoadLocation("Europe/Warsaw")
+	dataSourceName, err = getTimezoneDataSourceName("root:root@tcp(localhost:3311)/test", location)
+	assert.NoError(t, err)
+	assert.Equal(t, "root:root@tcp(localhost:3311)/test?time_zone=%27Europe%2FWarsaw%27", dataSourceName)
+	dataSourceName, err = getTimezoneDataSourceName("root:root@tcp(localhost:3311)/test", time.FixedZone("test", 2*3600))
+	assert.NoError(t, err)
+	assert.Equal(t, "root:root@tcp(localhost:3311)/test?time_zone=%27%2B02%3A00%27", dataSourceName)
+
+	tz, hasTZ := os.LookupEnv("TZ")
+	defer func() {
+		if hasTZ {
+			_ = os.Setenv("TZ", tz)
+		} else {
+			_ = os.Unsetenv("TZ")
+		}
+	}()
+	_ = os.Setenv("TZ", "Europe/Warsaw")
+	dataSourceName, err = getTimezoneDataSourceName("root:root@tcp(localhost:3311)/test", time.Local)
+	assert.NoError(t, err)
+	assert.Equal(t, "root:root@tcp(localhost:3311)/test?time_zone=%27Europe%2FWarsaw%27", dataSourceName)
+	_ = os.Setenv("TZ", ":/usr/share/zoneinfo/America/New_York")
+	dataSourceName, err = getTimezoneDataSourceName("root:root@tcp(localhost:3311)/test", time.Local)
+	assert.NoError(t, err)
+	assert.Equal(t, "root:root@tcp(localhost:3311)/test?time_zone=%27America%2FNew_York%27", dataSourceName)
+}
+
+func TestTimePrecision(t *testing.T) {
+	var entity *timePrecisionEntity
+	registry := &Registry{}
+	registry.SetMySQLPoolTimezone(time.UTC)
+	engine := PrepareTables(t, registry, entity)
+	alters := engine.GetAlters()
+	assert.Len(t, alters, 0)
+	var timeZone string
+	engine.GetMysql().QueryRow(NewWhere("SELECT @@session.time_zone"), &timeZone)
+	assert.Equal(t, "+00:00", timeZone)
+
+	published := time.Date(2020, 5, 10, 12, 30, 15, 0, time.Local)
+	entity = &timePrecisionEntity{Name: "a", Published: &published}
+	engine.Track(entity)
+	assert.True(t, engine.IsDirty(entity))
+	assert.True(t, entity.Created.IsZero())
+	engine.Flush()
+	assert.False(t, entity.Created.IsZero())
+	assert.Equal(t, 0, entity.Created.Nanosecond()%int(time.Millisecond))
+	assert.Nil(t, entity.Updated)
+
+	var publishedDB string
+	engine.GetMysql().QueryRow(NewWhere("SELECT `Published` FROM `timePrecisionEntity` WHERE `ID` = 1"), &publishedDB)
+	assert.Equal(t, published.UTC().Format("2006-01-02 15:04:05"), publishedDB)
+
+	loaded := &timePrecisionEntity{}
+	assert.True(t, engine.LoadByID(1, loaded))
+	assert.True(t, published.Equal(*loaded.Published))
+	assert.True(t, entity.Created.Equal(loaded.Created))
+
+	loaded.Name = "b"
+	engine.Track(loaded)
+	assert.True(t, engine.IsDirty(loaded))
+	assert.Nil(t, loaded.Updated)
+	engine.Flush()
+	assert.NotNil(t, loaded.Updated)
+	loaded2 := &timePrecisionEntity{}
+	assert.True(t, engine.LoadByID(1, loaded2))
+	assert.True(t, loaded.Updated.Equal(*loaded2.Updated))
+	engine.Track(loaded2)
+	assert.False(t, engine.IsDirty(loaded2))
+}
@@ -1,11 +1,11 @@
 package orm
 
 import (
-	"context"
 	"fmt"
 	"reflect"
+	"sync"
 
-	"github.com/bsm/redislock"
+	"github.com/go-redis/redis/v8"
 )
 
 type ValidatedRegistry interface {
@@ -17,23 +17,32 @@ type ValidatedRegistry interface {
 	GetEnum(code string) Enum
 	GetEnums() map[string]Enum
 	GetEntities() map[string]reflect.Type
+	GetClickHouseTableSchema(entityName string) ClickHouseTableSchema
+	GetClickHouseEntities() map[string]reflect.Type
 }
 
 type validatedRegistry struct {
-	registry                *Registry
-	tableSchemas            map[reflect.Type]*tableSchema
-	entities                map[string]reflect.Type
-	sqlClients              map[string]*DBConfig
-	clickHouseClients       map[string]*ClickHouseConfig
-	dirtyQueues             map[string]int
-	localCacheContainers    map[string]*LocalCacheConfig
-	redisServers            map[string]*RedisCacheConfig
-	elasticServers          map[string]*ElasticConfig
-	rabbitMQServers         map[string]*rabbitMQConnection
-	rabbitMQChannelsToQueue map[string]*rabbitMQChannelToQueue
-	rabbitMQRouterConfigs   map[string]*RabbitMQRouterConfig
-	lockServers             map[string]string
-	enums                   map[string]Enum
+	registry                   *Registry
+	tableSchemas               map[reflect.Type]*tableSchema
+	entities                   map[string]reflect.Type
+	clickHouseTableSchemas     map[reflect.Type]*clickHouseTableSchema
+	clickHouseEntities         map[string]reflect.Type
+	sqlClients                 map[string]*DBConfig
+	clickHouseClients          map[string]*ClickHouseConfig
+	dirtyQueues                map[string]int
+	localCacheContainers       map[string]*LocalCacheConfig
+	redisServers               map[string]*RedisCacheConfig
+	elasticServers             map[string]*ElasticConfig
+	rabbitMQServers            map[string]*rabbitMQConnection
+	rabbitMQChannelsToQueue    map[string]*rabbitMQChannelToQueue
+	rabbitMQRouterConfigs      map[string]*RabbitMQRouterConfig
+	lockServers                map[string]string
+	enums                      map[string]Enum
+	localCacheInvalidationPool string
+	redisScripts               map[string]*redis.Script
+	instanceID                 string
+	singleFlight               *singleFlight
+	localCacheInvalidation     sync.Once
 }
 
 func (r *validatedRegistry) GetSourceRegistry() *Registry {
@@ -44,11 +53,25 @@ func (r *validatedRegistry) GetEntities() map[string]reflect.Type {
 	return r.entities
 }
 
+func (r *validatedRegistry) GetClickHouseEntities() map[string]reflect.Type {
+	return r.clickHouseEntities
+}
+
 func (r *validatedRegistry) GetEnums() map[string]Enum {
 	return r.enums
 }
 
 func (r *validatedRegistry) CreateEngine() *Engine {
+	e := r.createEngine()
+	if r.localCacheInvalidationPool != "" {
+		r.localCacheInvalidation.Do(func() {
+			go r.startLocalCacheInvalidation()
+		})
+	}
+	return e
+}
+
+func (r *validatedRegistry) createEngine() *Engine {
 	e := &Engine{registry: r}
 	e.dataDog = &dataDog{engine: e}
 	e.dbs = make(map[string]*DB)
@@ -56,7 +79,7 @@ func (r *validatedRegistry) CreateEngine() *Engine {
 	if e.registry.sqlClients != nil {
 		for key, val := range e.registry.sqlClients {
 			e.dbs[key] = &DB{engine: e, code: val.code, databaseName: val.databaseName,
-				client: &standardSQLClient{db: val.db}, autoincrement: val.autoincrement}
+				client: &standardSQLClient{db: val.db}, autoincrement: val.autoincrement, version: val.version}
 		}
 	}
 	if e.registry.clickHouseClients != nil {
@@ -74,15 +97,7 @@ func (r *validatedRegistry) CreateEngine() *Engine {
 	e.redis = make(map[string]*RedisCache)
 	if e.registry.redisServers != nil {
 		for key, val := range e.registry.redisServers {
-			client := val.client
-			if client != nil {
-				client = client.WithContext(context.Background())
-			}
-			ring := val.ring
-			if ring != nil {
-				ring = ring.WithContext(context.Background())
-			}
-			e.redis[key] = &RedisCache{engine: e, code: val.code, client: &standardRedisClient{client, ring}}
+			e.redis[key] = &RedisCache{engine: e, code: val.code, client: &standardRedisClient{client: val.client}}
 		}
 	}
 	e.elastic = make(map[string]*Elastic)
@@ -102,7 +117,8 @@ func (r *validatedRegistry) CreateEngine() *Engine {
 	e.locks = make(map[string]*Locker)
 	if e.registry.lockServers != nil {
 		for key, val := range e.registry.lockServers {
-			locker := &standardLockerClient{client: redislock.New(e.registry.redisServers[val].client)}
+			client := e.registry.redisServers[val].client
+			locker := &standardLockerClient{redis: client}
 			e.locks[key] = &Locker{locker: locker, code: val, engine: e}
 		}
 	}
@@ -129,6 +145,14 @@ func (r *validatedRegistry) GetTableSchemaForEntity(entity Entity) TableSchema {
 	return tableSchema
 }
 
+func (r *validatedRegistry) GetClickHouseTableSchema(entityName string) ClickHouseTableSchema {
+	t, has := r.clickHouseEntities[entityName]
+	if !has {
+		return nil
+	}
+	return getClickHouseTableSchema(r, t)
+}
+
 func (r *validatedRegistry) GetEnum(code string) Enum {
 	return r.enums[code]
 }
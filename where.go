@@ -25,6 +25,18 @@ func (where *Where) Append(query string, parameters ...interface{}) {
 	where.parameters = append(where.parameters, newWhere.parameters...)
 }
 
+const MatchNaturalLanguageMode = "IN NATURAL LANGUAGE MODE"
+const MatchBooleanMode = "IN BOOLEAN MODE"
+const MatchQueryExpansion = "WITH QUERY EXPANSION"
+
+func NewMatchAgainst(columns []string, search string, mode ...string) *Where {
+	query := fmt.Sprintf("MATCH(`%s`) AGAINST(?", strings.Join(columns, "`,`"))
+	if len(mode) > 0 {
+		query += " " + mode[0]
+	}
+	return &Where{query + ")", []interface{}{search}}
+}
+
 func NewWhere(query string, parameters ...interface{}) *Where {
 	finalParameters := make([]interface{}, 0, len(parameters))
 	for _, value := range parameters {
@@ -14,3 +14,13 @@ func TestWhere(t *testing.T) {
 	assert.Equal(t, "1 AND Field = ? AND Field2 IN (?,?) AND Field3 = ? AND Field4 IN (?,?)", where.String())
 	assert.Equal(t, []interface{}{2, "a", "b", "c", "d", "e"}, where.GetParameters())
 }
+
+func TestMatchAgainst(t *testing.T) {
+	where := NewMatchAgainst([]string{"Title", "Content"}, "orm")
+	assert.Equal(t, "MATCH(`Title`,`Content`) AGAINST(?)", where.String())
+	assert.Equal(t, []interface{}{"orm"}, where.GetParameters())
+	where = NewMatchAgainst([]string{"Title"}, "+orm -sql", MatchBooleanMode)
+	where.Append("AND `Age` > ?", 18)
+	assert.Equal(t, "MATCH(`Title`) AGAINST(? IN BOOLEAN MODE) AND `Age` > ?", where.String())
+	assert.Equal(t, []interface{}{"+orm -sql", 18}, where.GetParameters())
+}
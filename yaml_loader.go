@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 )
@@ -37,11 +38,22 @@ func InitByYaml(yaml map[string]interface{}) (registry *Registry) {
 				validateClickHouseURI(registry, value, key)
 			case "redis":
 				validateRedisURI(registry, value, key)
+			case "redis_cluster":
+				validateRedisCluster(registry, value, key)
+			case "redis_sentinel":
+				validateRedisSentinel(registry, value, key)
 			case "rabbitmq":
 				validateOrmRabbitMQ(registry, value, key)
 			case "locker":
 				valAsString := validateOrmString(value, key)
 				registry.RegisterLocker(key, valAsString)
+			case "mysqlTimezone":
+				valAsString := validateOrmString(value, key)
+				location, err := time.LoadLocation(valAsString)
+				if err != nil {
+					panic(errors.NotValidf("mysql timezone '%s'", valAsString))
+				}
+				registry.SetMySQLPoolTimezone(location, key)
 			case "mysqlEncoding":
 				valAsString := validateOrmString(value, key)
 				registry.SetDefaultEncoding(valAsString)
@@ -60,6 +72,14 @@ func InitByYaml(yaml map[string]interface{}) (registry *Registry) {
 			case "local_cache":
 				number := validateOrmInt(value, key)
 				registry.RegisterLocalCache(number, key)
+			case "local_cache_invalidation":
+				asBool, ok := value.(bool)
+				if !ok {
+					panic(errors.NotValidf("local_cache_invalidation value '%v'", value))
+				}
+				if asBool {
+					registry.RegisterLocalCacheInvalidation(key)
+				}
 			}
 		}
 	}
@@ -132,6 +152,46 @@ func validateRedisURI(registry *Registry, value interface{}, key string) {
 	registry.RegisterRedis(uri, int(db), key)
 }
 
+func validateRedisCluster(registry *Registry, value interface{}, key string) {
+	asSlice, ok := value.([]interface{})
+	if !ok || len(asSlice) == 0 {
+		panic(errors.NotValidf("redis cluster definition '%s'", key))
+	}
+	addresses := make([]string, len(asSlice))
+	for i, row := range asSlice {
+		asString, ok := row.(string)
+		if !ok || len(strings.Split(asString, ":")) != 2 {
+			panic(errors.NotValidf("redis cluster uri '%v'", row))
+		}
+		addresses[i] = asString
+	}
+	registry.RegisterRedisCluster(addresses, key)
+}
+
+func validateRedisSentinel(registry *Registry, value interface{}, key string) {
+	def, ok := value.(map[interface{}]interface{})
+	if !ok {
+		panic(errors.NotValidf("redis sentinel definition '%s'", key))
+	}
+	master, ok := def["master"].(string)
+	if !ok || master == "" {
+		panic(errors.NotValidf("redis sentinel master '%s'", key))
+	}
+	asSlice, ok := def["sentinels"].([]interface{})
+	if !ok || len(asSlice) == 0 {
+		panic(errors.NotValidf("redis sentinel sentinels '%s'", key))
+	}
+	sentinels := make([]string, len(asSlice))
+	for i, row := range asSlice {
+		asString, ok := row.(string)
+		if !ok || len(strings.Split(asString, ":")) != 2 {
+			panic(errors.NotValidf("redis sentinel uri '%v'", row))
+		}
+		sentinels[i] = asString
+	}
+	registry.RegisterRedisSentinel(master, getIntOptional(def, "db", 0), sentinels, key)
+}
+
 func getBoolOptional(data map[interface{}]interface{}, key string, defaultValue bool) bool {
 	val, has := data[key]
 	if !has {
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"testing"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v2"
 )
@@ -82,6 +83,51 @@ func TestYamlLoader(t *testing.T) {
 		registry = InitByYaml(invalidYaml)
 	})
 
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"redis_cluster": "localhost:7000"}
+	assert.PanicsWithError(t, "redis cluster definition 'default' not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"redis_cluster": []interface{}{"invalid"}}
+	assert.PanicsWithError(t, "redis cluster uri 'invalid' not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"redis_sentinel": "invalid"}
+	assert.PanicsWithError(t, "redis sentinel definition 'default' not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"redis_sentinel": map[interface{}]interface{}{"sentinels": []interface{}{"localhost:26379"}}}
+	assert.PanicsWithError(t, "redis sentinel master 'default' not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"redis_sentinel": map[interface{}]interface{}{"master": "main"}}
+	assert.PanicsWithError(t, "redis sentinel sentinels 'default' not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"redis_sentinel": map[interface{}]interface{}{"master": "main", "sentinels": []interface{}{"invalid"}}}
+	assert.PanicsWithError(t, "redis sentinel uri 'invalid' not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
+	validYaml := make(map[string]interface{})
+	validYaml["cluster"] = map[string]interface{}{"redis_cluster": []interface{}{"localhost:7000", "localhost:7001"}}
+	validYaml["sentinel"] = map[string]interface{}{"redis_sentinel": map[interface{}]interface{}{"master": "main", "db": 2,
+		"sentinels": []interface{}{"localhost:26379"}}}
+	registry = InitByYaml(validYaml)
+	assert.IsType(t, &redis.ClusterClient{}, registry.redisServers["cluster"].client)
+	assert.IsType(t, &redis.Client{}, registry.redisServers["sentinel"].client)
+	assert.Equal(t, 2, registry.redisServers["sentinel"].client.(*redis.Client).Options().DB)
+
 	invalidYaml = make(map[string]interface{})
 	invalidYaml["default"] = map[string]interface{}{"rabbitmq": []int{1}}
 	assert.PanicsWithError(t, "rabbitMQ definition `default` not valid", func() {
@@ -184,6 +230,12 @@ func TestYamlLoader(t *testing.T) {
 		registry = InitByYaml(invalidYaml)
 	})
 
+	invalidYaml = make(map[string]interface{})
+	invalidYaml["default"] = map[string]interface{}{"local_cache_invalidation": "test"}
+	assert.PanicsWithError(t, "local_cache_invalidation value 'test' not valid", func() {
+		registry = InitByYaml(invalidYaml)
+	})
+
 	invalidYaml = make(map[string]interface{})
 	invalidYaml["default"] = map[string]interface{}{"locker": 1}
 	assert.PanicsWithError(t, "orm value for default: 1 not valid", func() {